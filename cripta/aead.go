@@ -0,0 +1,102 @@
+package cripta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrAuthenticationFailed возвращается, если тег не совпал с шифртекстом и AAD
+var ErrAuthenticationFailed = errors.New("authentication failed: ciphertext, tag or associated data was modified")
+
+// EncryptAEAD шифрует сообщение и вычисляет тег (Encrypt-then-MAC, HMAC-SHA256)
+// над связанными данными aad, IV и шифртекстом
+func (ctx *CipherContext) EncryptAEAD(plaintext []uint8, aad []uint8) ([]uint8, []uint8, error) {
+	ciphertext, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ciphertext, ctx.computeTag(ciphertext, aad), nil
+}
+
+// DecryptAEAD проверяет тег и только после этого расшифровывает сообщение
+func (ctx *CipherContext) DecryptAEAD(ciphertext []uint8, tag []uint8, aad []uint8) ([]uint8, error) {
+	if ciphertext == nil {
+		return nil, fmt.Errorf("ciphertext cannot be nil")
+	}
+
+	expected := ctx.computeTag(ciphertext, aad)
+	if !hmac.Equal(expected, tag) {
+		return nil, ErrAuthenticationFailed
+	}
+
+	return ctx.Decrypt(ciphertext)
+}
+
+// computeTag вычисляет HMAC-SHA256(len(aad) || aad || iv || ciphertext)
+func (ctx *CipherContext) computeTag(ciphertext []uint8, aad []uint8) []uint8 {
+	return ctx.computeTagWithKey(ctx.key, ciphertext, aad)
+}
+
+func (ctx *CipherContext) computeTagWithKey(key []uint8, ciphertext []uint8, aad []uint8) []uint8 {
+	mac := hmac.New(sha256.New, macKey(key))
+
+	var aadLen [8]uint8
+	binary.BigEndian.PutUint64(aadLen[:], uint64(len(aad)))
+	mac.Write(aadLen[:])
+	mac.Write(aad)
+	mac.Write(ctx.iv)
+	mac.Write(ciphertext)
+
+	return mac.Sum(nil)
+}
+
+// macKey выводит ключ MAC из ключа шифрования через HKDF,
+// чтобы не использовать один ключ для шифрования и аутентификации
+func macKey(key []uint8) []uint8 {
+	return HKDF(key, nil, []uint8("OKLabs encrypt-then-MAC key"), sha256.Size)
+}
+
+// DecryptWithKeys расшифровывает сообщение ciphertext || tag (тег EncryptAEAD без AAD),
+// перебирая ключи до первого, с которым сходится тег. Нужна при ротации ключей,
+// когда данные могут быть зашифрованы старым или новым ключом. Возвращает открытый
+// текст и индекс подошедшего ключа; ключ контекста после вызова не меняется.
+//
+// На время расшифровки ключ шифра контекста временно заменяется подошедшим ключом,
+// поэтому метод небезопасен для одновременного использования: Encrypt, Decrypt,
+// EncryptBatch и другие вызовы на том же контексте из других горутин в это время
+// молча работали бы с чужим ключом. Для параллельной работы используйте отдельный
+// контекст со своим экземпляром шифра.
+func (ctx *CipherContext) DecryptWithKeys(ciphertext []uint8, keys [][]uint8) ([]uint8, int, error) {
+	if len(ciphertext) < sha256.Size {
+		return nil, -1, fmt.Errorf("ciphertext is too short to contain tag: %d bytes", len(ciphertext))
+	}
+
+	body := ciphertext[:len(ciphertext)-sha256.Size]
+	tag := ciphertext[len(ciphertext)-sha256.Size:]
+
+	for index, key := range keys {
+		if !hmac.Equal(ctx.computeTagWithKey(key, body, nil), tag) {
+			continue
+		}
+
+		original := ctx.key
+		if err := ctx.SetKey(key); err != nil {
+			return nil, -1, fmt.Errorf("key %d: %w", index, err)
+		}
+		plaintext, err := ctx.Decrypt(body)
+		if restoreErr := ctx.SetKey(original); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+		if err != nil {
+			return nil, -1, err
+		}
+
+		return plaintext, index, nil
+	}
+
+	return nil, -1, ErrAuthenticationFailed
+}
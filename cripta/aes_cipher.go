@@ -1,10 +1,14 @@
 package cripta
 
 import (
+	"crypto/subtle"
 	"fmt"
 )
 
-// RijndaelCipher реализует алгоритм Rijndael (AES)
+// RijndaelCipher реализует алгоритм Rijndael (AES).
+// Состояние хранится плоским массивом байтов по столбцам, как в FIPS-197 (раздел 3.4):
+// байт входа i попадает в строку i%4 столбца i/4, то есть state[4*c + r] = s[r][c].
+// На этом соответствии основаны shiftRows (сдвиг строк r) и mixColumns (столбцы по 4 байта).
 type RijndaelCipher struct {
 	keySchedule   IKeySchedule
 	roundFunction IRoundFunction
@@ -16,10 +20,25 @@ type RijndaelCipher struct {
 	sBox          []byte
 	invSBox       []byte
 	roundKeys     [][]byte
+	constantTime  bool     // подстановка без зависящих от данных обращений к памяти
+	eqInverse     bool     // дешифрование эквивалентным обратным шифром
+	eqInvKeys     [][]byte // раундовые ключи эквивалентного обратного шифра
+}
+
+// RijndaelOptions дополнительные параметры создания шифра Rijndael
+type RijndaelOptions struct {
+	AllowReducibleModulus bool // не проверять неприводимость модуля (вырожденный S-бокс все равно отклоняется)
+	ConstantTimeSBox      bool // обходить весь S-бокс при каждой подстановке
+	EquivalentInverse     bool // дешифровать эквивалентным обратным шифром (FIPS-197, 5.3.5)
 }
 
 // NewRijndaelCipher создает новый шифр Rijndael
 func NewRijndaelCipher(blockSize, keySize int, modulus byte) (*RijndaelCipher, error) {
+	return NewRijndaelCipherWithOptions(blockSize, keySize, modulus, RijndaelOptions{})
+}
+
+// NewRijndaelCipherWithOptions создает шифр Rijndael с дополнительными параметрами
+func NewRijndaelCipherWithOptions(blockSize, keySize int, modulus byte, options RijndaelOptions) (*RijndaelCipher, error) {
 	// Проверяем допустимые размеры
 	if !(blockSize == 16 || blockSize == 24 || blockSize == 32) {
 		return nil, fmt.Errorf("block size must be 128, 192 or 256 bits (16, 24 or 32 bytes)")
@@ -29,31 +48,32 @@ func NewRijndaelCipher(blockSize, keySize int, modulus byte) (*RijndaelCipher, e
 	}
 
 	gfService := NewGF28Service()
-	
-	// Не проверяем неприводимость для тестов - всегда продолжаем
-	// if !gfService.IsIrreducible(modulus) {
-	//     return nil, fmt.Errorf("modulus 0x%02x is reducible", modulus)
-	// }
 
-	// Определяем количество раундов
-	rounds := 10
-	switch keySize {
-	case 24:
-		rounds = 12
-	case 32:
-		rounds = 14
+	// При приводимом модуле часть элементов не имеет обратных и S-бокс вырождается
+	if !options.AllowReducibleModulus && !gfService.IsIrreducible(modulus) {
+		return nil, fmt.Errorf("modulus 0x%02x is reducible over GF(2): S-box would be degenerate", modulus)
+	}
+
+	// Количество раундов: max(Nk, Nb) + 6
+	rounds := keySize/4 + 6
+	if blockSize > keySize {
+		rounds = blockSize/4 + 6
 	}
 
 	cipher := &RijndaelCipher{
-		gfService: gfService,
-		modulus:   modulus,
-		blockSize: blockSize,
-		keySize:   keySize,
-		rounds:    rounds,
+		gfService:    gfService,
+		modulus:      modulus,
+		blockSize:    blockSize,
+		keySize:      keySize,
+		rounds:       rounds,
+		constantTime: options.ConstantTimeSBox,
+		eqInverse:    options.EquivalentInverse,
 	}
 
 	// Инициализируем S-боксы
-	cipher.initSBoxes()
+	if err := cipher.initSBoxes(); err != nil {
+		return nil, err
+	}
 
 	// Создаем реализации интерфейсов
 	cipher.keySchedule = &RijndaelKeySchedule{
@@ -66,8 +86,9 @@ func NewRijndaelCipher(blockSize, keySize int, modulus byte) (*RijndaelCipher, e
 	return cipher, nil
 }
 
-// initSBoxes инициализирует S-боксы
-func (rc *RijndaelCipher) initSBoxes() {
+// initSBoxes инициализирует S-боксы. Возвращает ошибку, если S-бокс не является
+// перестановкой 0..255: элементы без обратного отображаются в 0 и дают коллизии
+func (rc *RijndaelCipher) initSBoxes() error {
 	rc.sBox = make([]byte, 256)
 	rc.invSBox = make([]byte, 256)
 
@@ -86,10 +107,18 @@ func (rc *RijndaelCipher) initSBoxes() {
 		}
 	}
 
-	// Создаем обратный S-бокс
+	// Создаем обратный S-бокс, попутно проверяя, что каждое значение встречается один раз
+	var seen [256]bool
 	for i := 0; i < 256; i++ {
+		if seen[rc.sBox[i]] {
+			return fmt.Errorf("S-box for modulus 0x%02x is not a permutation: value 0x%02x occurs more than once",
+				rc.modulus, rc.sBox[i])
+		}
+		seen[rc.sBox[i]] = true
 		rc.invSBox[rc.sBox[i]] = byte(i)
 	}
+
+	return nil
 }
 
 // affineTransform выполняет аффинное преобразование для S-бокса
@@ -121,11 +150,40 @@ func (rc *RijndaelCipher) SetKey(key []byte) error {
 	}
 
 	rc.roundKeys = roundKeys
+	if rc.eqInverse {
+		rc.eqInvKeys = rc.equivalentInverseKeys(roundKeys)
+	}
 	return nil
 }
 
+// equivalentInverseKeys вычисляет раундовые ключи эквивалентного обратного шифра:
+// к ключам раундов 1..Nr-1 применяется InvMixColumns, первый и последний не меняются.
+// Благодаря линейности InvMixColumns ее можно переставить с AddRoundKey, и дешифрование
+// выполняет InvSubBytes, InvShiftRows, InvMixColumns, AddRoundKey в порядке шифрования.
+func (rc *RijndaelCipher) equivalentInverseKeys(roundKeys [][]byte) [][]byte {
+	keys := make([][]byte, len(roundKeys))
+	for round, key := range roundKeys {
+		keys[round] = append([]byte{}, key...)
+		if round > 0 && round < rc.rounds {
+			rc.invMixColumns(keys[round])
+		}
+	}
+	return keys
+}
+
+// RijndaelTraceFunc получает копию состояния после каждого этапа шифрования.
+// Этапы названы как в приложении C FIPS-197: "input", "start", "s_box", "s_row",
+// "m_col", "k_sch" (раундовый ключ) и "output".
+type RijndaelTraceFunc func(round int, stage string, state []byte)
+
 // EncryptBlock шифрует блок данных
 func (rc *RijndaelCipher) EncryptBlock(plainBlock []byte) ([]byte, error) {
+	return rc.EncryptBlockWithTrace(plainBlock, nil)
+}
+
+// EncryptBlockWithTrace шифрует блок, передавая trace состояние после каждого этапа,
+// что позволяет сверить промежуточные значения с примерами FIPS-197
+func (rc *RijndaelCipher) EncryptBlockWithTrace(plainBlock []byte, trace RijndaelTraceFunc) ([]byte, error) {
 	if len(plainBlock) != rc.blockSize {
 		return nil, fmt.Errorf("block size must be %d bytes, got %d", rc.blockSize, len(plainBlock))
 	}
@@ -137,21 +195,39 @@ func (rc *RijndaelCipher) EncryptBlock(plainBlock []byte) ([]byte, error) {
 	state := make([]byte, rc.blockSize)
 	copy(state, plainBlock)
 
+	emit := func(round int, stage string, value []byte) {
+		if trace != nil {
+			trace(round, stage, append([]byte{}, value...))
+		}
+	}
+	emit(0, "input", state)
+	emit(0, "k_sch", rc.roundKeys[0])
+
 	// Начальное добавление ключа
 	rc.addRoundKey(state, rc.roundKeys[0])
 
 	// Основные раунды
 	for round := 1; round < rc.rounds; round++ {
+		emit(round, "start", state)
 		rc.subBytes(state)
+		emit(round, "s_box", state)
 		rc.shiftRows(state)
+		emit(round, "s_row", state)
 		rc.mixColumns(state)
+		emit(round, "m_col", state)
+		emit(round, "k_sch", rc.roundKeys[round])
 		rc.addRoundKey(state, rc.roundKeys[round])
 	}
 
 	// Финальный раунд (без mixColumns)
+	emit(rc.rounds, "start", state)
 	rc.subBytes(state)
+	emit(rc.rounds, "s_box", state)
 	rc.shiftRows(state)
+	emit(rc.rounds, "s_row", state)
+	emit(rc.rounds, "k_sch", rc.roundKeys[rc.rounds])
 	rc.addRoundKey(state, rc.roundKeys[rc.rounds])
+	emit(rc.rounds, "output", state)
 
 	return state, nil
 }
@@ -169,6 +245,11 @@ func (rc *RijndaelCipher) DecryptBlock(cipherBlock []byte) ([]byte, error) {
 	state := make([]byte, rc.blockSize)
 	copy(state, cipherBlock)
 
+	if rc.eqInverse {
+		rc.decryptEquivalentInverse(state)
+		return state, nil
+	}
+
 	// Начальное добавление ключа (обратное)
 	rc.addRoundKey(state, rc.roundKeys[rc.rounds])
 	rc.invShiftRows(state)
@@ -188,73 +269,88 @@ func (rc *RijndaelCipher) DecryptBlock(cipherBlock []byte) ([]byte, error) {
 	return state, nil
 }
 
+// decryptEquivalentInverse расшифровывает состояние эквивалентным обратным шифром:
+// раунды устроены как при шифровании, но с обратными преобразованиями и ключами eqInvKeys
+func (rc *RijndaelCipher) decryptEquivalentInverse(state []byte) {
+	rc.addRoundKey(state, rc.eqInvKeys[rc.rounds])
+
+	for round := rc.rounds - 1; round > 0; round-- {
+		rc.invSubBytes(state)
+		rc.invShiftRows(state)
+		rc.invMixColumns(state)
+		rc.addRoundKey(state, rc.eqInvKeys[round])
+	}
+
+	rc.invSubBytes(state)
+	rc.invShiftRows(state)
+	rc.addRoundKey(state, rc.eqInvKeys[0])
+}
+
 // subBytes применяет S-бокс к каждому байту состояния
 func (rc *RijndaelCipher) subBytes(state []byte) {
 	for i := 0; i < len(state); i++ {
-		state[i] = rc.sBox[state[i]]
+		state[i] = rc.substitute(rc.sBox, state[i])
 	}
 }
 
 // invSubBytes применяет обратный S-бокс
 func (rc *RijndaelCipher) invSubBytes(state []byte) {
 	for i := 0; i < len(state); i++ {
-		state[i] = rc.invSBox[state[i]]
+		state[i] = rc.substitute(rc.invSBox, state[i])
 	}
 }
 
-// shiftRows выполняет сдвиг строк
+// substitute выполняет подстановку по таблице с учетом режима постоянного времени
+func (rc *RijndaelCipher) substitute(table []byte, b byte) byte {
+	if !rc.constantTime {
+		return table[b]
+	}
+
+	// Читаем все 256 элементов и выбираем нужный маской,
+	// чтобы обращения к кэшу не зависели от значения b
+	var result byte
+	for i := 0; i < len(table); i++ {
+		mask := byte(-subtle.ConstantTimeByteEq(byte(i), b))
+		result |= table[i] & mask
+	}
+	return result
+}
+
+// shiftRowOffsets возвращает сдвиги строк 1..3 для числа столбцов Nb:
+// (1, 2, 3) для Nb = 4 и 6, (1, 3, 4) для Nb = 8
+func (rc *RijndaelCipher) shiftRowOffsets() [4]int {
+	if rc.blockSize == 32 {
+		return [4]int{0, 1, 3, 4}
+	}
+	return [4]int{0, 1, 2, 3}
+}
+
+// shiftRows выполняет сдвиг строк. Состояние хранится по столбцам:
+// байт строки r столбца c находится в state[4*c + r]
 func (rc *RijndaelCipher) shiftRows(state []byte) {
-	// Для блока 16 байт (стандартный AES)
-	if rc.blockSize == 16 {
-		// Вторая строка: циклический сдвиг на 1
-		temp := state[1]
-		state[1] = state[5]
-		state[5] = state[9]
-		state[9] = state[13]
-		state[13] = temp
-
-		// Третья строка: циклический сдвиг на 2
-		temp = state[2]
-		state[2] = state[10]
-		state[10] = temp
-		temp = state[6]
-		state[6] = state[14]
-		state[14] = temp
-
-		// Четвертая строка: циклический сдвиг на 3
-		temp = state[15]
-		state[15] = state[11]
-		state[11] = state[7]
-		state[7] = state[3]
-		state[3] = temp
+	nb := rc.blockSize / 4
+	offsets := rc.shiftRowOffsets()
+	original := make([]byte, len(state))
+	copy(original, state)
+
+	for r := 1; r < 4; r++ {
+		for c := 0; c < nb; c++ {
+			state[4*c+r] = original[4*((c+offsets[r])%nb)+r]
+		}
 	}
 }
 
 // invShiftRows выполняет обратный сдвиг строк
 func (rc *RijndaelCipher) invShiftRows(state []byte) {
-	// Для блока 16 байт
-	if rc.blockSize == 16 {
-		// Вторая строка: обратный сдвиг на 1
-		temp := state[13]
-		state[13] = state[9]
-		state[9] = state[5]
-		state[5] = state[1]
-		state[1] = temp
-
-		// Третья строка: обратный сдвиг на 2
-		temp = state[2]
-		state[2] = state[10]
-		state[10] = temp
-		temp = state[6]
-		state[6] = state[14]
-		state[14] = temp
-
-		// Четвертая строка: обратный сдвиг на 3
-		temp = state[3]
-		state[3] = state[7]
-		state[7] = state[11]
-		state[11] = state[15]
-		state[15] = temp
+	nb := rc.blockSize / 4
+	offsets := rc.shiftRowOffsets()
+	original := make([]byte, len(state))
+	copy(original, state)
+
+	for r := 1; r < 4; r++ {
+		for c := 0; c < nb; c++ {
+			state[4*((c+offsets[r])%nb)+r] = original[4*c+r]
+		}
 	}
 }
 
@@ -328,6 +424,11 @@ func (rc *RijndaelCipher) addRoundKey(state []byte, roundKey []byte) {
 	}
 }
 
+// Name возвращает "Rijndael-<блок>/<ключ>" с размерами в битах
+func (rc *RijndaelCipher) Name() string {
+	return fmt.Sprintf("Rijndael-%d/%d", rc.blockSize*8, rc.keySize*8)
+}
+
 // GetBlockSize возвращает размер блока
 func (rc *RijndaelCipher) GetBlockSize() int {
 	return rc.blockSize
@@ -338,6 +439,25 @@ func (rc *RijndaelCipher) GetKeySize() int {
 	return rc.keySize
 }
 
+// GetSBox возвращает копию S-бокса
+func (rc *RijndaelCipher) GetSBox() []byte {
+	sBox := make([]byte, len(rc.sBox))
+	copy(sBox, rc.sBox)
+	return sBox
+}
+
+// GetInvSBox возвращает копию обратного S-бокса
+func (rc *RijndaelCipher) GetInvSBox() []byte {
+	invSBox := make([]byte, len(rc.invSBox))
+	copy(invSBox, rc.invSBox)
+	return invSBox
+}
+
+// RequiredKeySize возвращает требуемую длину ключа в байтах
+func (rc *RijndaelCipher) RequiredKeySize() int {
+	return rc.keySize
+}
+
 // GetRounds возвращает количество раундов
 func (rc *RijndaelCipher) GetRounds() int {
 	return rc.rounds
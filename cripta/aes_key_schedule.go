@@ -1,5 +1,7 @@
 package cripta
 
+import "fmt"
+
 // RijndaelKeySchedule реализует расписание ключей для Rijndael/AES
 type RijndaelKeySchedule struct {
 	cipher *RijndaelCipher
@@ -11,80 +13,53 @@ func (rks *RijndaelKeySchedule) GenerateRoundKeys(masterKey []byte) ([][]byte, e
 	blockSize := rks.cipher.blockSize
 	rounds := rks.cipher.rounds
 
+	if len(masterKey) != keySize {
+		return nil, fmt.Errorf("master key size must be %d bytes, got %d", keySize, len(masterKey))
+	}
+
 	// Количество слов в ключе (4 байта на слово)
 	nk := keySize / 4
 	// Количество слов в блоке
 	nb := blockSize / 4
-	// Количество раундовых ключей
-	nr := rounds
-
-	// Инициализируем массив раундовых ключей
-	roundKeys := make([][]byte, nr+1)
-	for i := 0; i <= nr; i++ {
-		roundKeys[i] = make([]byte, blockSize)
-	}
-
-	// Копируем мастер-ключ в первые nk слов
-	for i := 0; i < nk; i++ {
-		if i*4 < len(masterKey) && i*4 < blockSize {
-			copy(roundKeys[0][i*4:], masterKey[i*4:min((i+1)*4, len(masterKey))])
-		}
-	}
+	// Общее количество слов расширенного ключа
+	totalWords := nb * (rounds + 1)
 
-	// Генерируем остальные раундовые ключи
-	for i := 1; i <= nr; i++ {
-		prevKey := roundKeys[i-1]
-		currentKey := roundKeys[i]
+	// Расширенный ключ как последовательность слов
+	words := make([]byte, totalWords*4)
+	copy(words, masterKey)
 
-		// Первое слово нового раундового ключа
-		temp := make([]byte, 4)
-		startIdx := (i-1)*nk*4 + (nk-1)*4
-		if startIdx+4 <= len(prevKey) {
-			copy(temp, prevKey[startIdx:startIdx+4])
-		} else {
-			copy(temp, prevKey[len(prevKey)-4:])
-		}
+	temp := make([]byte, 4)
+	for i := nk; i < totalWords; i++ {
+		copy(temp, words[(i-1)*4:i*4])
 
 		if i%nk == 0 {
-			// Применяем RotWord, SubWord и Rcon
 			// RotWord: циклический сдвиг влево
-			tempByte := temp[0]
-			temp[0] = temp[1]
-			temp[1] = temp[2]
-			temp[2] = temp[3]
-			temp[3] = tempByte
+			temp[0], temp[1], temp[2], temp[3] = temp[1], temp[2], temp[3], temp[0]
 
 			// SubWord: применяем S-бокс
 			for j := 0; j < 4; j++ {
-				temp[j] = rks.cipher.sBox[temp[j]]
+				temp[j] = rks.cipher.substitute(rks.cipher.sBox, temp[j])
 			}
 
 			// Rcon: добавляем константу раунда
-			rcon := rks.rcon(i/nk)
-			temp[0] ^= rcon
+			temp[0] ^= rks.rcon(i / nk)
 		} else if nk > 6 && i%nk == 4 {
 			// Для ключей 256 бит: дополнительное преобразование
 			for j := 0; j < 4; j++ {
-				temp[j] = rks.cipher.sBox[temp[j]]
+				temp[j] = rks.cipher.substitute(rks.cipher.sBox, temp[j])
 			}
 		}
 
-		// Генерируем первое слово нового ключа
 		for j := 0; j < 4; j++ {
-			if j < len(prevKey) && j < len(currentKey) {
-				currentKey[j] = prevKey[j] ^ temp[j]
-			}
+			words[i*4+j] = words[(i-nk)*4+j] ^ temp[j]
 		}
+	}
 
-		// Генерируем остальные слова нового ключа
-		for word := 1; word < nb; word++ {
-			for j := 0; j < 4; j++ {
-				idx := word*4 + j
-				if idx < len(currentKey) && idx-4 >= 0 && idx-4 < len(prevKey) {
-					currentKey[idx] = prevKey[idx] ^ currentKey[idx-4]
-				}
-			}
-		}
+	// Разбиваем расширенный ключ на раундовые ключи размером в блок
+	roundKeys := make([][]byte, rounds+1)
+	for i := 0; i <= rounds; i++ {
+		roundKeys[i] = make([]byte, blockSize)
+		copy(roundKeys[i], words[i*blockSize:(i+1)*blockSize])
 	}
 
 	return roundKeys, nil
@@ -98,11 +73,3 @@ func (rks *RijndaelKeySchedule) rcon(round int) byte {
 	}
 	return rcon
 }
-
-// min возвращает минимальное из двух чисел
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
\ No newline at end of file
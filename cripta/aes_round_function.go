@@ -7,8 +7,8 @@ type RijndaelRoundFunction struct {
 	cipher *RijndaelCipher
 }
 
-// Apply применяет раундовую функцию
-func (rrf *RijndaelRoundFunction) Apply(inputBlock []byte, roundKey []byte) ([]byte, error) {
+// Apply применяет раундовую функцию; номер раунда не используется
+func (rrf *RijndaelRoundFunction) Apply(inputBlock []byte, roundKey []byte, round int) ([]byte, error) {
 	if len(inputBlock) != rrf.cipher.blockSize {
 		return nil, fmt.Errorf("input block size must be %d bytes", rrf.cipher.blockSize)
 	}
@@ -0,0 +1,47 @@
+package cripta
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// AvalancheTest измеряет лавинный эффект: поочередно инвертирует каждый бит
+// входного блока и возвращает среднюю долю изменившихся битов шифртекста.
+// Для хорошего шифра значение близко к 0.5.
+func AvalancheTest(cipher ISymmetricCipher, key, block []uint8) (float64, error) {
+	if cipher == nil {
+		return 0, fmt.Errorf("cipher implementation cannot be nil")
+	}
+	if len(block) == 0 {
+		return 0, fmt.Errorf("block cannot be empty")
+	}
+
+	if err := cipher.SetKey(key); err != nil {
+		return 0, fmt.Errorf("failed to set key: %w", err)
+	}
+
+	reference, err := cipher.EncryptBlock(block)
+	if err != nil {
+		return 0, fmt.Errorf("encryption failed: %w", err)
+	}
+
+	flipped := make([]uint8, len(block))
+	totalChanged := 0
+	inputBits := len(block) * 8
+
+	for bit := 0; bit < inputBits; bit++ {
+		copy(flipped, block)
+		flipped[bit/8] ^= 0x80 >> (bit % 8)
+
+		encrypted, err := cipher.EncryptBlock(flipped)
+		if err != nil {
+			return 0, fmt.Errorf("encryption failed for flipped bit %d: %w", bit, err)
+		}
+
+		for i := range encrypted {
+			totalChanged += bits.OnesCount8(encrypted[i] ^ reference[i])
+		}
+	}
+
+	return float64(totalChanged) / float64(inputBits*len(reference)*8), nil
+}
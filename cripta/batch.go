@@ -0,0 +1,72 @@
+package cripta
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// EncryptBatch шифрует независимые сообщения одним ключом, выдавая каждому
+// новый IV из источника IV контекста. Сообщения обрабатываются пулом воркеров;
+// results[i] и ivs[i] соответствуют messages[i].
+func (ctx *CipherContext) EncryptBatch(messages [][]uint8) ([][]uint8, [][]uint8, error) {
+	if ctx.mode == CipherModeECB {
+		return nil, nil, fmt.Errorf("batch encryption requires a mode with IV, got ECB")
+	}
+
+	// IV выдаются последовательно, чтобы порядок счетчика совпадал с порядком сообщений
+	ivs := make([][]uint8, len(messages))
+	for i, message := range messages {
+		if message == nil {
+			return nil, nil, fmt.Errorf("message %d cannot be nil", i)
+		}
+
+		iv, err := ctx.nextIV()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := ctx.recordIV(iv); err != nil {
+			return nil, nil, err
+		}
+		ivs[i] = iv
+	}
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(messages) {
+		numWorkers = len(messages)
+	}
+
+	results := make([][]uint8, len(messages))
+	jobs := make(chan int)
+	errors := make(chan error, len(messages))
+	var wg sync.WaitGroup
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ciphertext, err := ctx.encryptWithIV(messages[i], ivs[i])
+				if err != nil {
+					errors <- fmt.Errorf("message %d: %w", i, err)
+					continue
+				}
+				results[i] = ciphertext
+			}
+		}()
+	}
+
+	for i := range messages {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		return nil, nil, err
+	}
+
+	return results, ivs, nil
+}
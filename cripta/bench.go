@@ -0,0 +1,109 @@
+package cripta
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// BenchResult результат замера скорости одного алгоритма в одном режиме
+type BenchResult struct {
+	Algorithm  string
+	Mode       CipherMode
+	EncryptMBs float64
+	DecryptMBs float64
+	Err        error // ошибка создания контекста, шифрования или несовпадение данных
+}
+
+// benchMinDuration минимальная длительность одного замера. Операция повторяется,
+// пока не наберется это время: однократный замер малого буфера на грубом таймере
+// может занять ноль и дать бесконечную скорость.
+const benchMinDuration = 10 * time.Millisecond
+
+// BenchmarkMatrix замеряет скорость шифрования и дешифрования data для каждой пары
+// алгоритм (имя в реестре шифров) и режим. Ключ и IV случайные, набивка PKCS7.
+// Ошибка одной пары записывается в ее результат и не прерывает остальные замеры.
+func BenchmarkMatrix(data []byte, algos []string, modes []CipherMode) []BenchResult {
+	results := make([]BenchResult, 0, len(algos)*len(modes))
+
+	for _, algo := range algos {
+		for _, mode := range modes {
+			result := BenchResult{Algorithm: algo, Mode: mode}
+			result.EncryptMBs, result.DecryptMBs, result.Err = benchmarkPair(data, algo, mode)
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// benchmarkPair выполняет один замер и возвращает скорости в MB/s
+func benchmarkPair(data []byte, algo string, mode CipherMode) (float64, float64, error) {
+	cipher, keySize, err := NewCipherByName(algo, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blockSize := 16
+	if sized, ok := cipher.(IBlockSizeProvider); ok {
+		blockSize = sized.GetBlockSize()
+	}
+
+	key := make([]uint8, keySize)
+	if _, err := GenerateRandomBytes(key); err != nil {
+		return 0, 0, fmt.Errorf("failed to generate key: %w", err)
+	}
+	var iv []uint8
+	if mode.RequiresIV() {
+		iv = make([]uint8, blockSize)
+		if _, err := GenerateRandomBytes(iv); err != nil {
+			return 0, 0, fmt.Errorf("failed to generate IV: %w", err)
+		}
+	}
+
+	ctx, err := NewCipherContext(cipher, key, mode, PaddingModePKCS7, iv, blockSize, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var encrypted []byte
+	encryptMBs, err := measureThroughput(len(data), func() (err error) {
+		encrypted, err = ctx.Encrypt(data)
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("encryption failed: %w", err)
+	}
+
+	var decrypted []byte
+	decryptMBs, err := measureThroughput(len(data), func() (err error) {
+		decrypted, err = ctx.Decrypt(encrypted)
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("decryption failed: %w", err)
+	}
+	if !bytes.Equal(decrypted, data) {
+		return 0, 0, fmt.Errorf("decrypted data does not match original")
+	}
+
+	return encryptMBs, decryptMBs, nil
+}
+
+// measureThroughput повторяет operation не меньше benchMinDuration и возвращает
+// скорость обработки size байтов за вызов в MB/s
+func measureThroughput(size int, operation func() error) (float64, error) {
+	iterations := 0
+	start := time.Now()
+	elapsed := time.Duration(0)
+	for elapsed < benchMinDuration {
+		if err := operation(); err != nil {
+			return 0, err
+		}
+		iterations++
+		elapsed = time.Since(start)
+	}
+
+	totalMB := float64(size) * float64(iterations) / (1024 * 1024)
+	return totalMB / elapsed.Seconds(), nil
+}
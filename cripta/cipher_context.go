@@ -1,12 +1,38 @@
 package cripta
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"runtime"
 	"sync"
+	"time"
+	"unsafe"
 )
 
+var ErrInvalidKeyLength = errors.New("invalid key length")
+
+// ErrParallelUnsupported возвращается Encrypt/Decrypt, если запрошена параллельная
+// обработка для режима без параллельной реализации и не включен SilentFallback
+var ErrParallelUnsupported = errors.New("parallel processing is not supported for this cipher mode")
+
+// ErrRoundTripMismatch возвращается Encrypt при включенной самопроверке,
+// если расшифрованный шифртекст не совпал с исходными данными
+var ErrRoundTripMismatch = errors.New("round-trip verification failed: decrypted data does not match plaintext")
+
+// ErrCounterOverflow возвращается в режиме CTR, если сообщение длиннее пространства
+// счетчика: счетчик вернулся бы к начальному значению и гамма повторилась бы
+var ErrCounterOverflow = errors.New("CTR counter overflow: message exceeds counter space")
+
+// RandReader источник случайных байтов для набивки ISO10126, дельт RandomDelta,
+// GenerateRandomBytes и ключей и шифртекстов Эль-Гамаля. В тестах может быть
+// заменен детерминированным источником.
+var RandReader io.Reader = rand.Reader
+
 type CipherMode int
 
 const (
@@ -17,8 +43,52 @@ const (
 	CipherModeOFB
 	CipherModeCTR
 	CipherModeRandomDelta
+	CipherModeCBC_CTS
+	// CipherModeGCM и CipherModeSIV обозначают режимы с аутентификацией, реализованные
+	// отдельными типами (NewGCM, NewSIV); CipherContext их не поддерживает
+	CipherModeGCM
+	CipherModeSIV
 )
 
+// SupportsParallel сообщает, есть ли у режима параллельная реализация:
+// блоки ECB, CTR и RandomDelta обрабатываются независимо друг от друга
+func (m CipherMode) SupportsParallel() bool {
+	switch m {
+	case CipherModeECB, CipherModeCTR, CipherModeRandomDelta:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAuthenticated сообщает, обнаруживает ли режим изменение шифртекста. Остальные
+// режимы обеспечивают только конфиденциальность, и без MAC (например EncryptAEAD)
+// подмена шифртекста останется незамеченной.
+func (m CipherMode) IsAuthenticated() bool {
+	return m == CipherModeGCM || m == CipherModeSIV
+}
+
+// RequiresIV сообщает, использует ли режим вектор инициализации: ECB шифрует блоки
+// независимо, а RandomDelta генерирует собственную случайную дельту для каждого блока
+func (m CipherMode) RequiresIV() bool {
+	return m != CipherModeECB && m != CipherModeRandomDelta
+}
+
+// SupportedModes возвращает режимы, которые принимает NewCipherContext
+// (без GCM и SIV, реализованных отдельными типами)
+func SupportedModes() []CipherMode {
+	return []CipherMode{
+		CipherModeECB,
+		CipherModeCBC,
+		CipherModePCBC,
+		CipherModeCFB,
+		CipherModeOFB,
+		CipherModeCTR,
+		CipherModeRandomDelta,
+		CipherModeCBC_CTS,
+	}
+}
+
 type PaddingMode int
 
 const (
@@ -26,8 +96,22 @@ const (
 	PaddingModeANSIX923
 	PaddingModePKCS7
 	PaddingModeISO10126
+	PaddingModeNone
+	PaddingModeISO7816 // ISO/IEC 7816-4: байт 0x80, затем нули до конца блока
 )
 
+// SupportedPaddings возвращает все режимы набивки
+func SupportedPaddings() []PaddingMode {
+	return []PaddingMode{
+		PaddingModeZeros,
+		PaddingModeANSIX923,
+		PaddingModePKCS7,
+		PaddingModeISO10126,
+		PaddingModeNone,
+		PaddingModeISO7816,
+	}
+}
+
 type CipherContext struct {
 	cipher      ISymmetricCipher
 	key         []uint8
@@ -36,6 +120,13 @@ type CipherContext struct {
 	iv          []uint8
 	blockSize   int
 	parallel    bool
+	ivSource    IVSource
+	autoIV      bool
+	verify      bool
+	fallback    bool
+	counterBits int                             // ширина счетчика CTR в младших битах IV, 0 - весь блок
+	ivHistory   map[[sha256.Size]uint8]struct{} // SHA-256 использованных IV, nil - без проверки
+	keyExpiry   time.Time                       // срок действия ключа для Seal, нулевое значение - без срока
 }
 
 func NewCipherContext(
@@ -50,6 +141,9 @@ func NewCipherContext(
 	if cipher == nil {
 		return nil, fmt.Errorf("cipher implementation cannot be nil")
 	}
+	if mode == CipherModeGCM || mode == CipherModeSIV {
+		return nil, fmt.Errorf("mode %d is not supported by CipherContext, use NewGCM or NewSIV", mode)
+	}
 
 	ctx := &CipherContext{
 		cipher:      cipher,
@@ -67,7 +161,11 @@ func NewCipherContext(
 		return nil, fmt.Errorf("failed to set key: %w", err)
 	}
 
-	if len(iv) == 0 && mode != CipherModeECB {
+	if mode.RequiresIV() && len(iv) != 0 && len(iv) != blockSize {
+		return nil, fmt.Errorf("IV must be %d bytes, got %d", blockSize, len(iv))
+	}
+
+	if len(iv) == 0 && mode.RequiresIV() {
 		ctx.iv = make([]uint8, blockSize)
 	} else {
 		ctx.iv = make([]uint8, len(iv))
@@ -77,6 +175,20 @@ func NewCipherContext(
 	return ctx, nil
 }
 
+func isStreamMode(mode CipherMode) bool {
+	return mode == CipherModeCFB || mode == CipherModeOFB || mode == CipherModeCTR
+}
+
+// effectivePaddingMode возвращает фактически применяемую набивку:
+// потоковые режимы (CFB, OFB, CTR) и CBC с кражей шифртекста не требуют набивки
+// и всегда работают как PaddingModeNone
+func (ctx *CipherContext) effectivePaddingMode() PaddingMode {
+	if isStreamMode(ctx.mode) || ctx.mode == CipherModeCBC_CTS {
+		return PaddingModeNone
+	}
+	return ctx.paddingMode
+}
+
 func (ctx *CipherContext) xorBlocks(dest []uint8, src []uint8) []uint8 {
 	minSize := len(dest)
 	if len(src) < minSize {
@@ -91,6 +203,37 @@ func (ctx *CipherContext) xorBlocks(dest []uint8, src []uint8) []uint8 {
 }
 
 func (ctx *CipherContext) incrementCounter(counter []uint8) {
+	if ctx.counterBits == 0 {
+		incrementCounter(counter)
+		return
+	}
+	addCounter(counter, 1, ctx.counterBits)
+}
+
+// counterWidth возвращает число младших битов IV, которые занимает счетчик CTR
+func (ctx *CipherContext) counterWidth() int {
+	if ctx.counterBits == 0 {
+		return ctx.blockSize * 8
+	}
+	return ctx.counterBits
+}
+
+// checkCounterSpace проверяет, что length байт в режиме CTR помещаются в пространство
+// счетчика, то есть счетчик не вернется к начальному значению
+func (ctx *CipherContext) checkCounterSpace(length int) error {
+	if ctx.mode != CipherModeCTR {
+		return nil
+	}
+
+	width := ctx.counterWidth()
+	blocks := (length + ctx.blockSize - 1) / ctx.blockSize
+	if width < 62 && blocks > 1<<width {
+		return fmt.Errorf("%w: %d blocks, counter space is 2^%d", ErrCounterOverflow, blocks, width)
+	}
+	return nil
+}
+
+func incrementCounter(counter []uint8) {
 	for i := len(counter) - 1; i >= 0; i-- {
 		counter[i]++
 		if counter[i] != 0 {
@@ -99,14 +242,35 @@ func (ctx *CipherContext) incrementCounter(counter []uint8) {
 	}
 }
 
+// addCounter прибавляет n к младшим bits битам счетчика как к big-endian числу
+// по модулю 2^bits; старшие биты не меняются
+func addCounter(counter []uint8, n int, bits int) {
+	carry := uint64(n)
+	for i := len(counter) - 1; i >= 0 && bits > 0 && carry != 0; i-- {
+		width := min(bits, 8)
+		mask := uint64(1)<<width - 1
+
+		sum := uint64(counter[i])&mask + carry&mask
+		counter[i] = counter[i]&^uint8(mask) | uint8(sum&mask)
+		carry = carry>>width + sum>>width
+		bits -= width
+	}
+}
+
 func (ctx *CipherContext) applyPadding(data []uint8) ([]uint8, error) {
 	if data == nil {
 		return nil, fmt.Errorf("data cannot be nil")
 	}
 
 	dataLength := len(data)
+	if ctx.effectivePaddingMode() == PaddingModeNone {
+		if dataLength%ctx.blockSize != 0 && !isStreamMode(ctx.mode) && ctx.mode != CipherModeCBC_CTS {
+			return nil, fmt.Errorf("data length %d is not a multiple of block size %d", dataLength, ctx.blockSize)
+		}
+		return data, nil
+	}
+
 	paddingLength := ctx.blockSize - (dataLength % ctx.blockSize)
-	fmt.Println("\nPadding added, size:", paddingLength)
 	if paddingLength == 0 {
 		paddingLength = ctx.blockSize
 	}
@@ -132,7 +296,7 @@ func (ctx *CipherContext) applyPadding(data []uint8) ([]uint8, error) {
 	case PaddingModeISO10126:
 		if paddingLength > 1 {
 			randomBytes := make([]uint8, paddingLength-1)
-			_, err := rand.Read(randomBytes)
+			_, err := io.ReadFull(RandReader, randomBytes)
 			if err != nil {
 				return nil, fmt.Errorf("failed to generate random bytes: %w", err)
 			}
@@ -140,6 +304,9 @@ func (ctx *CipherContext) applyPadding(data []uint8) ([]uint8, error) {
 		}
 		padded[len(padded)-1] = uint8(paddingLength)
 
+	case PaddingModeISO7816:
+		padded[dataLength] = 0x80
+
 	default:
 		return nil, fmt.Errorf("unsupported padding mode")
 	}
@@ -148,12 +315,18 @@ func (ctx *CipherContext) applyPadding(data []uint8) ([]uint8, error) {
 }
 
 func (ctx *CipherContext) removePadding(data []uint8) ([]uint8, error) {
-	if len(data) == 0 {
+	if len(data) == 0 || ctx.effectivePaddingMode() == PaddingModeNone {
+		return data, nil
+	}
+
+	if ctx.paddingMode == PaddingModeISO7816 {
+		if paddingLength := iso7816PaddingLength(data, ctx.blockSize); paddingLength > 0 {
+			return data[:len(data)-paddingLength], nil
+		}
 		return data, nil
 	}
 
 	paddingLength := int(data[len(data)-1])
-	fmt.Println("Padding size to remove:", paddingLength)
 
 	if paddingLength <= 0 || paddingLength > ctx.blockSize || paddingLength > len(data) {
 		return data, nil
@@ -169,6 +342,12 @@ func (ctx *CipherContext) removePadding(data []uint8) ([]uint8, error) {
 		return data[:len(data)-paddingLength], nil
 
 	case PaddingModeANSIX923:
+		// Набивка всегда добавляется и дополняет данные до целого числа блоков,
+		// поэтому снимается только с последнего полного блока: байт длины и
+		// все байты перед ним в пределах набивки должны быть нулями
+		if len(data)%ctx.blockSize != 0 {
+			return data, nil
+		}
 		for i := len(data) - paddingLength; i < len(data)-1; i++ {
 			if data[i] != 0 {
 				return data, nil
@@ -192,14 +371,92 @@ func (ctx *CipherContext) removePadding(data []uint8) ([]uint8, error) {
 	}
 }
 
+// iso7816PaddingLength ищет набивку ISO/IEC 7816-4 в последних blockSize байтах:
+// нули в конце и байт 0x80 перед ними. Возвращает длину набивки вместе с 0x80
+// (blockSize, если набивка занимает весь блок) или 0, если набивка некорректна.
+func iso7816PaddingLength(data []uint8, blockSize int) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-blockSize; i-- {
+		switch data[i] {
+		case 0:
+			continue
+		case 0x80:
+			return len(data) - i
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// InspectPadding анализирует расшифрованный последний блок и сообщает длину набивки
+// и ее корректность для набивки контекста. Не изменяет данные; полезна для диагностики
+// неверного ключа или неверно выбранной набивки.
+func (ctx *CipherContext) InspectPadding(lastBlock []uint8) (paddingMode PaddingMode, paddingLen int, valid bool) {
+	paddingMode = ctx.effectivePaddingMode()
+	if paddingMode == PaddingModeNone {
+		return paddingMode, 0, true
+	}
+	if len(lastBlock) != ctx.blockSize {
+		return paddingMode, 0, false
+	}
+
+	if paddingMode == PaddingModeZeros {
+		// Нулевая набивка добавляет от 1 до blockSize нулевых байтов
+		for paddingLen < len(lastBlock) && lastBlock[len(lastBlock)-1-paddingLen] == 0 {
+			paddingLen++
+		}
+		return paddingMode, paddingLen, paddingLen > 0
+	}
+
+	if paddingMode == PaddingModeISO7816 {
+		paddingLen = iso7816PaddingLength(lastBlock, ctx.blockSize)
+		return paddingMode, paddingLen, paddingLen > 0
+	}
+
+	paddingLen = int(lastBlock[len(lastBlock)-1])
+	if paddingLen == 0 || paddingLen > ctx.blockSize {
+		return paddingMode, paddingLen, false
+	}
+
+	switch paddingMode {
+	case PaddingModePKCS7:
+		for _, b := range lastBlock[len(lastBlock)-paddingLen:] {
+			if b != uint8(paddingLen) {
+				return paddingMode, paddingLen, false
+			}
+		}
+
+	case PaddingModeANSIX923:
+		for _, b := range lastBlock[len(lastBlock)-paddingLen : len(lastBlock)-1] {
+			if b != 0 {
+				return paddingMode, paddingLen, false
+			}
+		}
+
+	case PaddingModeISO10126:
+		// Заполняющие байты случайны, проверяется только длина
+
+	default:
+		return paddingMode, 0, false
+	}
+
+	return paddingMode, paddingLen, true
+}
+
 func (ctx *CipherContext) encryptECBParallel(padded []uint8) ([]uint8, error) {
 	numBlocks := len(padded) / ctx.blockSize
+	if numBlocks == 0 {
+		return []uint8{}, nil
+	}
 	ciphertext := make([]uint8, len(padded))
 
 	numThreads := runtime.NumCPU()
 	if numThreads == 0 {
 		numThreads = 4
 	}
+	if numThreads > numBlocks {
+		numThreads = numBlocks
+	}
 
 	var wg sync.WaitGroup
 	errors := make(chan error, numThreads)
@@ -247,12 +504,18 @@ func (ctx *CipherContext) encryptECBParallel(padded []uint8) ([]uint8, error) {
 
 func (ctx *CipherContext) decryptECBParallel(ciphertext []uint8) ([]uint8, error) {
 	numBlocks := len(ciphertext) / ctx.blockSize
+	if numBlocks == 0 {
+		return []uint8{}, nil
+	}
 	plaintext := make([]uint8, len(ciphertext))
 
 	numThreads := runtime.NumCPU()
 	if numThreads == 0 {
 		numThreads = 4
 	}
+	if numThreads > numBlocks {
+		numThreads = numBlocks
+	}
 
 	var wg sync.WaitGroup
 	errors := make(chan error, numThreads)
@@ -298,8 +561,11 @@ func (ctx *CipherContext) decryptECBParallel(ciphertext []uint8) ([]uint8, error
 	return plaintext, nil
 }
 
-func (ctx *CipherContext) encryptCTRParallel(padded []uint8) ([]uint8, error) {
-	numBlocks := len(padded) / ctx.blockSize
+func (ctx *CipherContext) encryptCTRParallel(padded []uint8, iv []uint8) ([]uint8, error) {
+	numBlocks := (len(padded) + ctx.blockSize - 1) / ctx.blockSize
+	if numBlocks == 0 {
+		return []uint8{}, nil
+	}
 	ciphertext := make([]uint8, len(padded))
 
 	numThreads := runtime.NumCPU()
@@ -331,16 +597,20 @@ func (ctx *CipherContext) encryptCTRParallel(padded []uint8) ([]uint8, error) {
 		go func(start, end int, threadID int) {
 			defer wg.Done()
 
-			localCounter := make([]uint8, len(ctx.iv))
+			localCounter := make([]uint8, len(iv))
 			mutex.Lock()
-			copy(localCounter, ctx.iv)
+			copy(localCounter, iv)
 			for i := 0; i < start; i++ {
 				ctx.incrementCounter(localCounter)
 			}
 			mutex.Unlock()
 
 			for i := start; i < end; i++ {
-				block := padded[i*ctx.blockSize : (i+1)*ctx.blockSize]
+				blockEnd := (i + 1) * ctx.blockSize
+				if blockEnd > len(padded) {
+					blockEnd = len(padded)
+				}
+				block := padded[i*ctx.blockSize : blockEnd]
 
 				encryptedCounter, err := ctx.cipher.EncryptBlock(localCounter)
 				if err != nil {
@@ -366,30 +636,200 @@ func (ctx *CipherContext) encryptCTRParallel(padded []uint8) ([]uint8, error) {
 	return ciphertext, nil
 }
 
-func (ctx *CipherContext) decryptCTRParallel(ciphertext []uint8) ([]uint8, error) {
-	return ctx.encryptCTRParallel(ciphertext)
+func (ctx *CipherContext) decryptCTRParallel(ciphertext []uint8, iv []uint8) ([]uint8, error) {
+	return ctx.encryptCTRParallel(ciphertext, iv)
+}
+
+// encryptRandomDeltaParallel шифрует блоки RandomDelta параллельно: блоки независимы,
+// каждый рабочий поток записывает пары [delta][шифртекст] по своим смещениям.
+// Дельты читаются из RandReader одним вызовом заранее, поэтому при одинаковом
+// источнике результат совпадает с последовательным шифрованием.
+func (ctx *CipherContext) encryptRandomDeltaParallel(padded []uint8) ([]uint8, error) {
+	numBlocks := len(padded) / ctx.blockSize
+	ciphertext := make([]uint8, 2*len(padded))
+
+	deltas := make([]uint8, len(padded))
+	if _, err := io.ReadFull(RandReader, deltas); err != nil {
+		return nil, fmt.Errorf("failed to generate random delta: %w", err)
+	}
+
+	err := ctx.runBlocksParallel(numBlocks, func(i int) error {
+		offset := i * ctx.blockSize
+		delta := deltas[offset : offset+ctx.blockSize]
+
+		encryptedBlock, err := ctx.cipher.EncryptBlock(ctx.xorBlocks(padded[offset:offset+ctx.blockSize], delta))
+		if err != nil {
+			return fmt.Errorf("random delta encryption failed for block %d: %w", i, err)
+		}
+
+		copy(ciphertext[2*offset:], delta)
+		copy(ciphertext[2*offset+ctx.blockSize:], encryptedBlock)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ciphertext, nil
+}
+
+func (ctx *CipherContext) decryptRandomDeltaParallel(ciphertext []uint8) ([]uint8, error) {
+	step := 2 * ctx.blockSize
+	numBlocks := len(ciphertext) / step
+	plaintext := make([]uint8, numBlocks*ctx.blockSize)
+
+	err := ctx.runBlocksParallel(numBlocks, func(i int) error {
+		delta := ciphertext[i*step : i*step+ctx.blockSize]
+
+		decryptedBlock, err := ctx.cipher.DecryptBlock(ciphertext[i*step+ctx.blockSize : (i+1)*step])
+		if err != nil {
+			return fmt.Errorf("random delta decryption failed for block %d: %w", i, err)
+		}
+
+		copy(plaintext[i*ctx.blockSize:], ctx.xorBlocks(decryptedBlock, delta))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
 }
 
+// runBlocksParallel делит блоки 0..numBlocks-1 на непрерывные диапазоны по числу
+// процессоров и вызывает process для каждого блока; возвращает первую ошибку
+func (ctx *CipherContext) runBlocksParallel(numBlocks int, process func(i int) error) error {
+	if numBlocks == 0 {
+		return nil
+	}
+
+	numThreads := runtime.NumCPU()
+	if numThreads > numBlocks {
+		numThreads = numBlocks
+	}
+
+	var wg sync.WaitGroup
+	errors := make(chan error, numThreads)
+
+	blocksPerThread := (numBlocks + numThreads - 1) / numThreads
+
+	for start := 0; start < numBlocks; start += blocksPerThread {
+		end := start + blocksPerThread
+		if end > numBlocks {
+			end = numBlocks
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+
+			for i := start; i < end; i++ {
+				if err := process(i); err != nil {
+					errors <- err
+					return
+				}
+			}
+		}(start, end)
+	}
+
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		return err
+	}
+
+	return nil
+}
+
+// Encrypt шифрует данные. При включенном AutoIV для каждого сообщения
+// из источника IV берется новый вектор, который записывается перед шифртекстом.
 func (ctx *CipherContext) Encrypt(plaintext []uint8) ([]uint8, error) {
 	if plaintext == nil {
 		return nil, fmt.Errorf("plaintext cannot be nil")
 	}
 
+	iv := ctx.iv
+	if ctx.usesAutoIV() {
+		var err error
+		if iv, err = ctx.nextIV(); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.recordIV(iv); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := ctx.encryptWithIV(plaintext, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.verify {
+		if err := ctx.verifyRoundTrip(plaintext, ciphertext, iv); err != nil {
+			return nil, err
+		}
+	}
+
+	if !ctx.usesAutoIV() {
+		return ciphertext, nil
+	}
+	return append(iv, ciphertext...), nil
+}
+
+// verifyRoundTrip расшифровывает только что полученный шифртекст и сравнивает
+// результат с открытым текстом. Набивка нулями неотличима от нулей в конце данных
+// и при дешифровании не удаляется, поэтому для нее хвостовые нули не сравниваются.
+func (ctx *CipherContext) verifyRoundTrip(plaintext, ciphertext, iv []uint8) error {
+	decrypted, err := ctx.decryptWithIV(ciphertext, iv)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRoundTripMismatch, err)
+	}
+
+	expected := plaintext
+	if ctx.effectivePaddingMode() == PaddingModeZeros {
+		expected = bytes.TrimRight(plaintext, "\x00")
+		decrypted = bytes.TrimRight(decrypted, "\x00")
+	}
+	if !bytes.Equal(decrypted, expected) {
+		return ErrRoundTripMismatch
+	}
+
+	return nil
+}
+
+func (ctx *CipherContext) encryptWithIV(plaintext []uint8, iv []uint8) ([]uint8, error) {
+	if err := ctx.checkParallel(); err != nil {
+		return nil, err
+	}
+
 	padded, err := ctx.applyPadding(plaintext)
 	if err != nil {
 		return nil, fmt.Errorf("padding failed: %w", err)
 	}
+	if err := ctx.checkCounterSpace(len(padded)); err != nil {
+		return nil, err
+	}
 
 	if ctx.mode == CipherModeECB && ctx.parallel {
 		return ctx.encryptECBParallel(padded)
 	} else if ctx.mode == CipherModeCTR && ctx.parallel {
-		return ctx.encryptCTRParallel(padded)
+		return ctx.encryptCTRParallel(padded, iv)
+	} else if ctx.mode == CipherModeRandomDelta && ctx.parallel {
+		return ctx.encryptRandomDeltaParallel(padded)
+	} else if ctx.mode == CipherModeCBC_CTS {
+		return ctx.encryptCBCCTS(padded, iv)
 	}
 
-	ciphertext := make([]uint8, 0, len(padded))
-
 	currentBlock := make([]uint8, ctx.blockSize)
-	copy(currentBlock, ctx.iv)
+	copy(currentBlock, iv)
+
+	ciphertext, _, err := ctx.encryptBlocks(padded, currentBlock)
+	return ciphertext, err
+}
+
+func (ctx *CipherContext) encryptBlocks(padded []uint8, currentBlock []uint8) ([]uint8, []uint8, error) {
+	ciphertext := make([]uint8, 0, len(padded))
 
 	for i := 0; i < len(padded); i += ctx.blockSize {
 		end := i + ctx.blockSize
@@ -405,27 +845,32 @@ func (ctx *CipherContext) Encrypt(plaintext []uint8) ([]uint8, error) {
 		}
 
 		var encryptedBlock []uint8
+		var err error
 
 		switch ctx.mode {
 		case CipherModeECB:
 			encryptedBlock, err = ctx.cipher.EncryptBlock(block)
 			if err != nil {
-				return nil, fmt.Errorf("ECB encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("ECB encryption failed: %w", err)
 			}
 
 		case CipherModeCBC:
 			xored := ctx.xorBlocks(block, currentBlock)
 			encryptedBlock, err = ctx.cipher.EncryptBlock(xored)
 			if err != nil {
-				return nil, fmt.Errorf("CBC encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CBC encryption failed: %w", err)
 			}
 			currentBlock = encryptedBlock
 
+		// PCBC: C_i = E(P_i xor P_{i-1} xor C_{i-1}). Состояние после блока равно
+		// IV xor сумма всех предыдущих P_j xor C_j, поэтому перестановка двух соседних
+		// блоков шифртекста искажает только их самих, а последующие блоки расшифровываются
+		// верно: ошибка не распространяется, и подмена остается незамеченной
 		case CipherModePCBC:
 			xored := ctx.xorBlocks(block, currentBlock)
 			encryptedBlock, err = ctx.cipher.EncryptBlock(xored)
 			if err != nil {
-				return nil, fmt.Errorf("PCBC encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("PCBC encryption failed: %w", err)
 			}
 			temp := make([]uint8, len(block))
 			copy(temp, block)
@@ -434,7 +879,7 @@ func (ctx *CipherContext) Encrypt(plaintext []uint8) ([]uint8, error) {
 		case CipherModeCFB:
 			encryptedBlock, err = ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("CFB encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CFB encryption failed: %w", err)
 			}
 			encryptedBlock = ctx.xorBlocks(encryptedBlock, block)
 			currentBlock = encryptedBlock
@@ -442,46 +887,172 @@ func (ctx *CipherContext) Encrypt(plaintext []uint8) ([]uint8, error) {
 		case CipherModeOFB:
 			currentBlock, err = ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("OFB encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("OFB encryption failed: %w", err)
 			}
 			encryptedBlock = ctx.xorBlocks(currentBlock, block)
 
 		case CipherModeCTR:
 			encryptedCounter, err := ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("CTR encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CTR encryption failed: %w", err)
 			}
 			encryptedBlock = ctx.xorBlocks(encryptedCounter, block)
 			ctx.incrementCounter(currentBlock)
 
 		case CipherModeRandomDelta:
 			delta := make([]uint8, ctx.blockSize)
-			_, err := rand.Read(delta)
+			_, err := io.ReadFull(RandReader, delta)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate random delta: %w", err)
+				return nil, nil, fmt.Errorf("failed to generate random delta: %w", err)
 			}
 			xored := ctx.xorBlocks(block, delta)
 			encryptedBlock, err = ctx.cipher.EncryptBlock(xored)
 			if err != nil {
-				return nil, fmt.Errorf("random delta encryption failed: %w", err)
+				return nil, nil, fmt.Errorf("random delta encryption failed: %w", err)
 			}
 			ciphertext = append(ciphertext, delta...)
 
 		default:
-			return nil, fmt.Errorf("unsupported cipher mode")
+			return nil, nil, fmt.Errorf("unsupported cipher mode")
+		}
+
+		if isStreamMode(ctx.mode) {
+			// Последний неполный блок потокового режима не дополняется
+			encryptedBlock = encryptedBlock[:end-i]
 		}
 
 		ciphertext = append(ciphertext, encryptedBlock...)
 	}
 
-	return ciphertext, nil
+	return ciphertext, currentBlock, nil
 }
 
+// Decrypt расшифровывает данные. При включенном AutoIV вектор читается из начала шифртекста.
 func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 	if ciphertext == nil {
 		return nil, fmt.Errorf("ciphertext cannot be nil")
 	}
 
+	if !ctx.usesAutoIV() {
+		return ctx.decryptWithIV(ciphertext, ctx.iv)
+	}
+
+	if len(ciphertext) < ctx.blockSize {
+		return nil, fmt.Errorf("ciphertext is too short to contain IV: %d bytes", len(ciphertext))
+	}
+
+	return ctx.decryptWithIV(ciphertext[ctx.blockSize:], ciphertext[:ctx.blockSize])
+}
+
+// EncryptDetached шифрует данные на новом IV из источника IV и возвращает IV отдельно,
+// не добавляя его к шифртексту. Настройка AutoIV на этот вызов не влияет.
+func (ctx *CipherContext) EncryptDetached(plaintext []uint8) (ciphertext, iv []uint8, err error) {
+	if plaintext == nil {
+		return nil, nil, fmt.Errorf("plaintext cannot be nil")
+	}
+	if ctx.mode == CipherModeECB {
+		return nil, nil, fmt.Errorf("ECB mode does not use an IV")
+	}
+
+	iv, err = ctx.nextIV()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.recordIV(iv); err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err = ctx.encryptWithIV(plaintext, iv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ctx.verify {
+		if err := ctx.verifyRoundTrip(plaintext, ciphertext, iv); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return ciphertext, iv, nil
+}
+
+// DecryptDetached расшифровывает шифртекст, полученный EncryptDetached, с переданным IV
+func (ctx *CipherContext) DecryptDetached(ciphertext, iv []uint8) ([]uint8, error) {
+	if ciphertext == nil {
+		return nil, fmt.Errorf("ciphertext cannot be nil")
+	}
+	if ctx.mode == CipherModeECB {
+		return nil, fmt.Errorf("ECB mode does not use an IV")
+	}
+	if len(iv) != ctx.blockSize {
+		return nil, fmt.Errorf("IV must be %d bytes, got %d", ctx.blockSize, len(iv))
+	}
+
+	return ctx.decryptWithIV(ciphertext, iv)
+}
+
+// DecryptRange расшифровывает только байты [start, end) открытого текста, не трогая
+// остальной шифртекст. В CTR счетчик сразу устанавливается на блок start/blockSize,
+// в OFB гамма пропущенных блоков вычисляется без расшифрования. Только для CTR и OFB;
+// при включенном AutoIV IV читается из начала шифртекста, а смещения отсчитываются после него.
+func (ctx *CipherContext) DecryptRange(ciphertext []uint8, start, end int) ([]uint8, error) {
+	if ciphertext == nil {
+		return nil, fmt.Errorf("ciphertext cannot be nil")
+	}
+	if ctx.mode != CipherModeCTR && ctx.mode != CipherModeOFB {
+		return nil, fmt.Errorf("range decryption is only supported in CTR and OFB modes")
+	}
+
+	iv := ctx.iv
+	if ctx.usesAutoIV() {
+		if len(ciphertext) < ctx.blockSize {
+			return nil, fmt.Errorf("ciphertext is too short to contain IV: %d bytes", len(ciphertext))
+		}
+		iv, ciphertext = ciphertext[:ctx.blockSize], ciphertext[ctx.blockSize:]
+	}
+
+	if start < 0 || end < start || end > len(ciphertext) {
+		return nil, fmt.Errorf("invalid range [%d, %d) for %d bytes of ciphertext", start, end, len(ciphertext))
+	}
+
+	firstBlock := start / ctx.blockSize
+	state := make([]uint8, len(iv))
+	copy(state, iv)
+
+	if ctx.mode == CipherModeCTR {
+		if err := ctx.checkCounterSpace(end); err != nil {
+			return nil, err
+		}
+		addCounter(state, firstBlock, ctx.counterWidth())
+	} else {
+		for i := 0; i < firstBlock; i++ {
+			var err error
+			state, err = ctx.cipher.EncryptBlock(state)
+			if err != nil {
+				return nil, fmt.Errorf("OFB keystream failed for block %d: %w", i, err)
+			}
+		}
+	}
+
+	// Емкость ограничена end: decryptBlocks дополняет неполный блок через append
+	// и иначе затер бы шифртекст вызывающего за границей диапазона
+	offset := firstBlock * ctx.blockSize
+	plaintext, _, err := ctx.decryptBlocks(ciphertext[offset:end:end], state)
+	if err != nil {
+		return nil, err
+	}
+
+	return plaintext[start-offset:], nil
+}
+
+func (ctx *CipherContext) decryptWithIV(ciphertext []uint8, iv []uint8) ([]uint8, error) {
+	if err := ctx.checkParallel(); err != nil {
+		return nil, err
+	}
+	if err := ctx.checkCounterSpace(len(ciphertext)); err != nil {
+		return nil, err
+	}
+
 	if ctx.mode == CipherModeECB && ctx.parallel {
 		plaintext, err := ctx.decryptECBParallel(ciphertext)
 		if err != nil {
@@ -489,17 +1060,34 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 		}
 		return ctx.removePadding(plaintext)
 	} else if ctx.mode == CipherModeCTR && ctx.parallel {
-		plaintext, err := ctx.decryptCTRParallel(ciphertext)
+		plaintext, err := ctx.decryptCTRParallel(ciphertext, iv)
+		if err != nil {
+			return nil, err
+		}
+		return ctx.removePadding(plaintext)
+	} else if ctx.mode == CipherModeRandomDelta && ctx.parallel {
+		plaintext, err := ctx.decryptRandomDeltaParallel(ciphertext)
 		if err != nil {
 			return nil, err
 		}
 		return ctx.removePadding(plaintext)
+	} else if ctx.mode == CipherModeCBC_CTS {
+		return ctx.decryptCBCCTS(ciphertext, iv)
 	}
 
-	plaintext := make([]uint8, 0, len(ciphertext))
+	currentBlock := make([]uint8, len(iv))
+	copy(currentBlock, iv)
 
-	currentBlock := make([]uint8, len(ctx.iv))
-	copy(currentBlock, ctx.iv)
+	plaintext, _, err := ctx.decryptBlocks(ciphertext, currentBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.removePadding(plaintext)
+}
+
+func (ctx *CipherContext) decryptBlocks(ciphertext []uint8, currentBlock []uint8) ([]uint8, []uint8, error) {
+	plaintext := make([]uint8, 0, len(ciphertext))
 
 	step := ctx.blockSize
 	if ctx.mode == CipherModeRandomDelta {
@@ -507,7 +1095,7 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 	}
 
 	for i := 0; i < len(ciphertext); i += step {
-		if i+ctx.blockSize > len(ciphertext) {
+		if i+ctx.blockSize > len(ciphertext) && !isStreamMode(ctx.mode) {
 			break
 		}
 
@@ -528,6 +1116,7 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 			}
 			block = ciphertext[i:end]
 		}
+		blockLength := len(block)
 
 		if len(block) < ctx.blockSize {
 			block = append(block, make([]uint8, ctx.blockSize-len(block))...)
@@ -540,13 +1129,13 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 		case CipherModeECB:
 			decryptedBlock, err = ctx.cipher.DecryptBlock(block)
 			if err != nil {
-				return nil, fmt.Errorf("ECB decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("ECB decryption failed: %w", err)
 			}
 
 		case CipherModeCBC:
 			decryptedBlock, err = ctx.cipher.DecryptBlock(block)
 			if err != nil {
-				return nil, fmt.Errorf("CBC decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CBC decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(decryptedBlock, currentBlock)
 			currentBlock = block
@@ -556,7 +1145,7 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 			copy(encryptedCopy, block)
 			decryptedBlock, err = ctx.cipher.DecryptBlock(block)
 			if err != nil {
-				return nil, fmt.Errorf("PCBC decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("PCBC decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(decryptedBlock, currentBlock)
 			currentBlock = ctx.xorBlocks(decryptedBlock, encryptedCopy)
@@ -564,7 +1153,7 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 		case CipherModeCFB:
 			decryptedBlock, err = ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("CFB decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CFB decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(decryptedBlock, block)
 			currentBlock = block
@@ -572,14 +1161,14 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 		case CipherModeOFB:
 			currentBlock, err = ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("OFB decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("OFB decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(currentBlock, block)
 
 		case CipherModeCTR:
 			encryptedCounter, err := ctx.cipher.EncryptBlock(currentBlock)
 			if err != nil {
-				return nil, fmt.Errorf("CTR decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("CTR decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(encryptedCounter, block)
 			ctx.incrementCounter(currentBlock)
@@ -587,21 +1176,235 @@ func (ctx *CipherContext) Decrypt(ciphertext []uint8) ([]uint8, error) {
 		case CipherModeRandomDelta:
 			decryptedBlock, err = ctx.cipher.DecryptBlock(block)
 			if err != nil {
-				return nil, fmt.Errorf("random delta decryption failed: %w", err)
+				return nil, nil, fmt.Errorf("random delta decryption failed: %w", err)
 			}
 			decryptedBlock = ctx.xorBlocks(decryptedBlock, delta)
 
 		default:
-			return nil, fmt.Errorf("unsupported cipher mode")
+			return nil, nil, fmt.Errorf("unsupported cipher mode")
+		}
+
+		if isStreamMode(ctx.mode) {
+			decryptedBlock = decryptedBlock[:blockLength]
 		}
 
 		plaintext = append(plaintext, decryptedBlock...)
 	}
 
-	return ctx.removePadding(plaintext)
+	// В CBC и CFB состояние ссылается на входной буфер, который может быть переиспользован
+	state := make([]uint8, len(currentBlock))
+	copy(state, currentBlock)
+
+	return plaintext, state, nil
+}
+
+// encryptCBCCTS шифрует в режиме CBC с кражей шифртекста (вариант CS3):
+// последний неполный блок дополняется нулями, а два последних блока шифртекста
+// меняются местами с усечением предпоследнего, так что длина шифртекста равна длине данных
+func (ctx *CipherContext) encryptCBCCTS(plaintext []uint8, iv []uint8) ([]uint8, error) {
+	n := len(plaintext)
+	if n < ctx.blockSize {
+		return nil, fmt.Errorf("CTS requires at least one full block (%d bytes), got %d", ctx.blockSize, n)
+	}
+
+	tail := n % ctx.blockSize
+	if tail == 0 {
+		tail = ctx.blockSize
+	}
+
+	currentBlock := make([]uint8, ctx.blockSize)
+	copy(currentBlock, iv)
+
+	ciphertext := make([]uint8, 0, n+ctx.blockSize)
+	for i := 0; i < n; i += ctx.blockSize {
+		block := make([]uint8, ctx.blockSize)
+		copy(block, plaintext[i:])
+
+		encryptedBlock, err := ctx.cipher.EncryptBlock(ctx.xorBlocks(block, currentBlock))
+		if err != nil {
+			return nil, fmt.Errorf("CTS encryption failed: %w", err)
+		}
+		ciphertext = append(ciphertext, encryptedBlock...)
+		currentBlock = encryptedBlock
+	}
+
+	if n == ctx.blockSize {
+		return ciphertext, nil
+	}
+
+	last := len(ciphertext) - ctx.blockSize
+	result := make([]uint8, 0, n)
+	result = append(result, ciphertext[:last-ctx.blockSize]...)
+	result = append(result, ciphertext[last:]...)
+	result = append(result, ciphertext[last-ctx.blockSize:last-ctx.blockSize+tail]...)
+
+	return result, nil
+}
+
+// decryptCBCCTS расшифровывает шифртекст, полученный encryptCBCCTS
+func (ctx *CipherContext) decryptCBCCTS(ciphertext []uint8, iv []uint8) ([]uint8, error) {
+	n := len(ciphertext)
+	if n < ctx.blockSize {
+		return nil, fmt.Errorf("CTS requires at least one full block (%d bytes), got %d", ctx.blockSize, n)
+	}
+
+	tail := n % ctx.blockSize
+	if tail == 0 {
+		tail = ctx.blockSize
+	}
+
+	currentBlock := make([]uint8, ctx.blockSize)
+	copy(currentBlock, iv)
+
+	if n == ctx.blockSize {
+		decryptedBlock, err := ctx.cipher.DecryptBlock(ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("CTS decryption failed: %w", err)
+		}
+		return ctx.xorBlocks(decryptedBlock, currentBlock), nil
+	}
+
+	// Все блоки до двух последних обрабатываются как в обычном CBC
+	head := n - ctx.blockSize - tail
+	plaintext := make([]uint8, 0, n)
+	for i := 0; i < head; i += ctx.blockSize {
+		block := ciphertext[i : i+ctx.blockSize]
+		decryptedBlock, err := ctx.cipher.DecryptBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("CTS decryption failed: %w", err)
+		}
+		plaintext = append(plaintext, ctx.xorBlocks(decryptedBlock, currentBlock)...)
+		currentBlock = block
+	}
+
+	lastFull := ciphertext[head : head+ctx.blockSize]
+	stolen := ciphertext[head+ctx.blockSize:]
+
+	// D(Cn) = Cn-1 xor (Pn || 0...0): хвост восстанавливает украденную часть Cn-1
+	z, err := ctx.cipher.DecryptBlock(lastFull)
+	if err != nil {
+		return nil, fmt.Errorf("CTS decryption failed: %w", err)
+	}
+	previous := make([]uint8, ctx.blockSize)
+	copy(previous, stolen)
+	copy(previous[tail:], z[tail:])
+	lastPlain := ctx.xorBlocks(z[:tail], stolen)
+
+	decryptedBlock, err := ctx.cipher.DecryptBlock(previous)
+	if err != nil {
+		return nil, fmt.Errorf("CTS decryption failed: %w", err)
+	}
+	plaintext = append(plaintext, ctx.xorBlocks(decryptedBlock, currentBlock)...)
+	plaintext = append(plaintext, lastPlain...)
+
+	return plaintext, nil
+}
+
+// EncryptInPlace шифрует buf на IV контекста, записывая шифртекст поверх открытого
+// текста; результат совпадает с Encrypt. Длина buf не меняется, поэтому требуются
+// набивка PaddingModeNone и длина, кратная блоку. По той же причине некуда записать
+// новый IV, и при включенном AutoIV (кроме ECB) возвращается ошибка.
+func (ctx *CipherContext) EncryptInPlace(buf []uint8) error {
+	if ctx.effectivePaddingMode() != PaddingModeNone {
+		return fmt.Errorf("in-place encryption requires PaddingModeNone: padding would change the length")
+	}
+	if ctx.usesAutoIV() {
+		return fmt.Errorf("in-place encryption does not support AutoIV: there is no room to store the IV")
+	}
+	if ctx.mode == CipherModeRandomDelta || ctx.mode == CipherModeCBC_CTS {
+		return fmt.Errorf("in-place encryption is not supported for random delta and CTS modes")
+	}
+	if len(buf)%ctx.blockSize != 0 {
+		return fmt.Errorf("buffer length %d is not a multiple of block size %d", len(buf), ctx.blockSize)
+	}
+	if err := ctx.recordIV(ctx.iv); err != nil {
+		return err
+	}
+
+	state := make([]uint8, ctx.blockSize)
+	copy(state, ctx.iv)
+
+	for i := 0; i < len(buf); i += ctx.blockSize {
+		encrypted, nextState, err := ctx.encryptBlocks(buf[i:i+ctx.blockSize], state)
+		if err != nil {
+			return err
+		}
+		copy(buf[i:], encrypted)
+		state = nextState
+	}
+
+	return nil
+}
+
+// buffersOverlap сообщает, занимают ли a и b общие байты памяти
+func buffersOverlap(a, b []uint8) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	aStart := uintptr(unsafe.Pointer(&a[0]))
+	bStart := uintptr(unsafe.Pointer(&b[0]))
+	return aStart < bStart+uintptr(len(b)) && bStart < aStart+uintptr(len(a))
+}
+
+// DecryptInto расшифровывает ciphertext в dst и возвращает длину открытого текста
+// в dst после снятия набивки. Буфер под весь открытый текст не выделяется: блоки
+// расшифровываются по одному и копируются в dst. dst должен вмещать весь шифртекст
+// (без IV в режиме AutoIV): набивка снимается уже после расшифровки.
+// Как и EncryptInPlace, не поддерживает режимы random delta и CTS и всегда работает
+// последовательно. Перекрывающиеся dst и ciphertext отклоняются: запись в dst
+// испортила бы еще не расшифрованные блоки и состояние сцепления.
+func (ctx *CipherContext) DecryptInto(dst, ciphertext []uint8) (int, error) {
+	if ciphertext == nil {
+		return 0, fmt.Errorf("ciphertext cannot be nil")
+	}
+	if buffersOverlap(dst, ciphertext) {
+		return 0, fmt.Errorf("destination buffer must not overlap ciphertext")
+	}
+	if ctx.mode == CipherModeRandomDelta || ctx.mode == CipherModeCBC_CTS {
+		return 0, fmt.Errorf("decryption into a buffer is not supported for random delta and CTS modes")
+	}
+
+	iv := ctx.iv
+	if ctx.usesAutoIV() {
+		if len(ciphertext) < ctx.blockSize {
+			return 0, fmt.Errorf("ciphertext is too short to contain IV: %d bytes", len(ciphertext))
+		}
+		iv, ciphertext = ciphertext[:ctx.blockSize], ciphertext[ctx.blockSize:]
+	}
+
+	if len(dst) < len(ciphertext) {
+		return 0, fmt.Errorf("destination buffer of %d bytes is too small for %d bytes of ciphertext", len(dst), len(ciphertext))
+	}
+	if err := ctx.checkCounterSpace(len(ciphertext)); err != nil {
+		return 0, err
+	}
+
+	state := make([]uint8, ctx.blockSize)
+	copy(state, iv)
+
+	n := 0
+	for i := 0; i < len(ciphertext); i += ctx.blockSize {
+		end := min(i+ctx.blockSize, len(ciphertext))
+		decrypted, nextState, err := ctx.decryptBlocks(ciphertext[i:end], state)
+		if err != nil {
+			return 0, err
+		}
+		n += copy(dst[n:], decrypted)
+		state = nextState
+	}
+
+	plaintext, err := ctx.removePadding(dst[:n])
+	if err != nil {
+		return 0, err
+	}
+	return len(plaintext), nil
 }
 
 func (ctx *CipherContext) SetKey(newKey []uint8) error {
+	if sized, ok := ctx.cipher.(IKeySizeProvider); ok && len(newKey) != sized.RequiredKeySize() {
+		return fmt.Errorf("%w: cipher requires %d bytes, got %d", ErrInvalidKeyLength, sized.RequiredKeySize(), len(newKey))
+	}
+
 	ctx.key = make([]uint8, len(newKey))
 	copy(ctx.key, newKey)
 	return ctx.cipher.SetKey(ctx.key)
@@ -611,6 +1414,8 @@ func (ctx *CipherContext) SetMode(newMode CipherMode) {
 	ctx.mode = newMode
 }
 
+// SetPaddingMode задает набивку для блочных режимов. В потоковых режимах
+// (CFB, OFB, CTR) набивка не применяется и шифртекст равен по длине открытому тексту.
 func (ctx *CipherContext) SetPaddingMode(newPaddingMode PaddingMode) {
 	ctx.paddingMode = newPaddingMode
 }
@@ -620,6 +1425,139 @@ func (ctx *CipherContext) SetIV(newIV []uint8) {
 	copy(ctx.iv, newIV)
 }
 
+// SetIVSource задает источник векторов инициализации для режима AutoIV
+func (ctx *CipherContext) SetIVSource(source IVSource) {
+	ctx.ivSource = source
+}
+
+// SetAutoIV включает выбор нового IV для каждого сообщения. IV берется из источника,
+// заданного SetIVSource (по умолчанию RandomIVSource), и передается перед шифртекстом.
+// В режиме ECB IV не используется и настройка не действует.
+func (ctx *CipherContext) SetAutoIV(enabled bool) {
+	ctx.autoIV = enabled
+}
+
+// SetVerifyRoundTrip включает самопроверку: после каждого Encrypt шифртекст сразу
+// расшифровывается и сравнивается с исходными данными, при расхождении возвращается
+// ErrRoundTripMismatch. Удваивает стоимость шифрования, по умолчанию выключена.
+func (ctx *CipherContext) SetVerifyRoundTrip(enabled bool) {
+	ctx.verify = enabled
+}
+
+// SetKeyExpiry задает срок действия ключа, который Seal записывает в заголовок
+// контейнера; Open такого контейнера после этого момента возвращает ErrKeyExpired.
+// Срок хранится с точностью до секунды, нулевое время отключает его.
+func (ctx *CipherContext) SetKeyExpiry(expiry time.Time) {
+	ctx.keyExpiry = expiry
+}
+
+// SetSilentFallback разрешает молча выполнять последовательную обработку, если
+// параллельная запрошена для режима, который ее не поддерживает. По умолчанию
+// в этом случае Encrypt и Decrypt возвращают ErrParallelUnsupported.
+func (ctx *CipherContext) SetSilentFallback(enabled bool) {
+	ctx.fallback = enabled
+}
+
+// SetCounterBits ограничивает счетчик CTR младшими bits битами IV (как, например,
+// 32-битный счетчик GCM): при переполнении они сбрасываются, а старшие биты IV
+// не меняются. Сообщение длиннее 2^bits блоков отклоняется с ErrCounterOverflow.
+// 0 возвращает счетчик на весь блок.
+func (ctx *CipherContext) SetCounterBits(bits int) error {
+	if bits < 0 || bits > ctx.blockSize*8 {
+		return fmt.Errorf("counter bits must be between 0 and %d, got %d", ctx.blockSize*8, bits)
+	}
+	ctx.counterBits = bits
+	return nil
+}
+
+// Раскладка IV для SetNonceCounter: префикс || номер сообщения || счетчик блоков
+const (
+	nonceCounterSize      = 8  // номер сообщения, big-endian
+	nonceBlockCounterBits = 32 // счетчик блоков внутри сообщения
+)
+
+// SetNonceCounter задает IV режима CTR по номеру сообщения n для протоколов, где
+// нонсом служит порядковый номер: IV = префикс || n (8 байт) || 0 (4 байта).
+// Префикс - старшие байты текущего IV (заданного при создании или через SetIV),
+// поэтому повторные вызовы меняют только номер. Счетчик блоков ограничивается
+// 32 битами, так что потоки разных n не пересекаются, а сообщение длиннее 2^32
+// блоков отклоняется с ErrCounterOverflow. Требует блока не меньше 12 байт;
+// при включенном AutoIV заданный IV не используется.
+func (ctx *CipherContext) SetNonceCounter(n uint64) error {
+	if ctx.mode != CipherModeCTR {
+		return fmt.Errorf("nonce counter requires CTR mode, got mode %d", ctx.mode)
+	}
+	prefixSize := ctx.blockSize - nonceCounterSize - nonceBlockCounterBits/8
+	if prefixSize < 0 {
+		return fmt.Errorf("nonce counter requires at least %d-byte blocks, got %d",
+			nonceCounterSize+nonceBlockCounterBits/8, ctx.blockSize)
+	}
+
+	iv := make([]uint8, ctx.blockSize)
+	copy(iv[:prefixSize], ctx.iv)
+	binary.BigEndian.PutUint64(iv[prefixSize:], n)
+
+	ctx.SetIV(iv)
+	ctx.counterBits = nonceBlockCounterBits
+	return nil
+}
+
+// SetRounds меняет число раундов шифра, если он реализует RoundConfigurable
+func (ctx *CipherContext) SetRounds(n int) error {
+	configurable, ok := ctx.cipher.(RoundConfigurable)
+	if !ok {
+		return fmt.Errorf("cipher %s does not support configurable rounds", ctx.cipher.Name())
+	}
+	return configurable.SetRounds(n)
+}
+
+// checkParallel проверяет, что запрошенная параллельная обработка поддерживается режимом
+func (ctx *CipherContext) checkParallel() error {
+	if ctx.parallel && !ctx.mode.SupportsParallel() && !ctx.fallback {
+		return fmt.Errorf("%w: mode %d", ErrParallelUnsupported, ctx.mode)
+	}
+	return nil
+}
+
+func (ctx *CipherContext) usesAutoIV() bool {
+	return ctx.autoIV && ctx.mode.RequiresIV()
+}
+
+// nextIV получает очередной IV из источника и проверяет его длину
+func (ctx *CipherContext) nextIV() ([]uint8, error) {
+	source := ctx.ivSource
+	if source == nil {
+		source = RandomIVSource{}
+	}
+
+	// В CTR младшие байты IV занимает счетчик блоков: без них номера сообщений
+	// CounterIVSource совпали бы со значениями счетчика соседних сообщений
+	if _, ok := source.(*CounterIVSource); ok && ctx.mode == CipherModeCTR && ctx.blockSize < 2*counterIVSize {
+		return nil, fmt.Errorf("counter IV source leaves no room for the CTR block counter in %d-byte blocks", ctx.blockSize)
+	}
+
+	iv, err := source.NextIV(ctx.blockSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain IV: %w", err)
+	}
+	if len(iv) != ctx.blockSize {
+		return nil, fmt.Errorf("IV source returned %d bytes, need %d", len(iv), ctx.blockSize)
+	}
+
+	return iv, nil
+}
+
+// Reset начинает новую последовательность независимых сообщений: забывает историю
+// использованных IV (обнаружение повторов, если включено, остается включенным).
+// Другого состояния между сообщениями контекст не накапливает: сцепление и счетчик
+// блоков локальны для каждого вызова, IV при шифровании не меняется, а GCM и SIV
+// реализованы отдельными типами без состояния. Ключ, режим, IV и источник IV
+// сохраняются, поэтому после Reset без AutoIV следующее сообщение снова шифруется
+// на том же IV, что допустимо лишь для сообщений под разными ключами.
+func (ctx *CipherContext) Reset() {
+	ctx.ClearIVHistory()
+}
+
 func (ctx *CipherContext) GetMode() CipherMode {
 	return ctx.mode
 }
@@ -629,5 +1567,5 @@ func (ctx *CipherContext) GetBlockSize() int {
 }
 
 func GenerateRandomBytes(data []byte) (int, error) {
-	return rand.Read(data)
+	return io.ReadFull(RandReader, data)
 }
\ No newline at end of file
@@ -0,0 +1,313 @@
+package cripta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// containerMagic сигнатура контейнера, containerVersion текущая версия формата.
+// Версия 1 отличается от текущей только отсутствием поля expires и по-прежнему открывается.
+const (
+	containerMagic        = "OKLC"
+	containerVersion      = 2
+	containerVersionNoTTL = 1
+)
+
+// ErrKeyExpired возвращается Open, если срок действия ключа, записанный в контейнер, истек
+var ErrKeyExpired = errors.New("key expired: container was sealed with a key past its expiry time")
+
+// Формат контейнера (длины полей в байтах, числа в big-endian):
+//
+//	magic(4) | version(1) | algo(1) | mode(1) | padding(1) |
+//	ivlen(1) | iv | saltlen(1) | salt | expires(8) | ctlen(8) | ciphertext | taglen(1) | tag
+//
+// expires - срок действия ключа в секундах Unix (0 - без срока), заданный SetKeyExpiry.
+// Это только метаданные политики: Open отказывается расшифровывать контейнер после
+// этого момента, но криптографическую стойкость поле не повышает.
+//
+// Тег HMAC-SHA256 вычисляется над всеми байтами до taglen на ключе, выведенном
+// из ключа шифрования: заголовок (алгоритм, режим, набивка, IV) служит ассоциированными
+// данными, и его подмена обнаруживается так же, как подмена шифртекста. Open проверяет
+// тег до того, как доверять полям заголовка.
+// Соль зарезервирована для ключей, выведенных из пароля; Seal записывает пустую соль.
+
+// algorithmIDOf возвращает идентификатор алгоритма для шифра по его имени.
+// Имя не отражает модуль поля Rijndael и число раундов DES и DEAL, а OpenAuto
+// восстанавливает шифр со стандартными параметрами, поэтому шифры с нестандартными
+// параметрами отклоняются: иначе контейнер открывался бы в мусор без ошибки.
+func algorithmIDOf(cipher ISymmetricCipher) (AlgorithmID, error) {
+	switch c := cipher.(type) {
+	case *RijndaelCipher:
+		if c.modulus != 0x1B {
+			return 0, fmt.Errorf("cipher %s uses non-standard modulus 0x%02x and has no container algorithm id",
+				c.Name(), c.modulus)
+		}
+	case *DESCipher:
+		if c.Rounds() != 16 {
+			return 0, fmt.Errorf("cipher %s uses %d rounds instead of 16 and has no container algorithm id",
+				c.Name(), c.Rounds())
+		}
+	case *DEALCipher:
+		if c.Rounds() != dealRounds(c.keyLength) {
+			return 0, fmt.Errorf("cipher %s uses %d rounds instead of %d and has no container algorithm id",
+				c.Name(), c.Rounds(), dealRounds(c.keyLength))
+		}
+	}
+
+	switch name := cipher.Name(); name {
+	case "DES":
+		return AlgorithmDES, nil
+	case "DEAL-128":
+		return AlgorithmDEAL128, nil
+	case "DEAL-192":
+		return AlgorithmDEAL192, nil
+	case "DEAL-256":
+		return AlgorithmDEAL256, nil
+	case "Rijndael-128/128":
+		return AlgorithmAES128, nil
+	case "Rijndael-128/192":
+		return AlgorithmAES192, nil
+	case "Rijndael-128/256":
+		return AlgorithmAES256, nil
+	default:
+		return 0, fmt.Errorf("cipher %s has no container algorithm id", name)
+	}
+}
+
+// Seal шифрует данные и упаковывает их вместе с параметрами шифрования в контейнер.
+// Для режимов с IV при включенном AutoIV берется новый IV, иначе IV контекста.
+func (ctx *CipherContext) Seal(plaintext []uint8) ([]uint8, error) {
+	if plaintext == nil {
+		return nil, fmt.Errorf("plaintext cannot be nil")
+	}
+
+	algorithm, err := algorithmIDOf(ctx.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	var iv []uint8
+	if ctx.mode.RequiresIV() {
+		iv = ctx.iv
+		if ctx.usesAutoIV() {
+			if iv, err = ctx.nextIV(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(iv) > 255 {
+		return nil, fmt.Errorf("IV is too long for container: %d bytes", len(iv))
+	}
+	if err := ctx.recordIV(iv); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := ctx.encryptWithIV(plaintext, iv)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.verify {
+		if err := ctx.verifyRoundTrip(plaintext, ciphertext, iv); err != nil {
+			return nil, err
+		}
+	}
+
+	var salt []uint8
+
+	var expires int64
+	if !ctx.keyExpiry.IsZero() {
+		expires = ctx.keyExpiry.Unix()
+	}
+
+	data := make([]uint8, 0, len(containerMagic)+23+len(iv)+len(salt)+len(ciphertext)+sha256.Size)
+	data = append(data, containerMagic...)
+	data = append(data, containerVersion, uint8(algorithm), uint8(ctx.mode), uint8(ctx.effectivePaddingMode()))
+	data = append(data, uint8(len(iv)))
+	data = append(data, iv...)
+	data = append(data, uint8(len(salt)))
+	data = append(data, salt...)
+	data = binary.BigEndian.AppendUint64(data, uint64(expires))
+	data = binary.BigEndian.AppendUint64(data, uint64(len(ciphertext)))
+	data = append(data, ciphertext...)
+
+	tag := containerTag(ctx.key, data)
+	data = append(data, uint8(len(tag)))
+	data = append(data, tag...)
+
+	return data, nil
+}
+
+// Open разбирает контейнер, проверяет тег и расшифровывает данные. Алгоритм, режим
+// и набивка контейнера должны совпадать с настройками контекста; при изменении
+// любого байта заголовка возвращается ErrAuthenticationFailed. Если в контейнере
+// записан срок действия ключа и он уже прошел, возвращается ErrKeyExpired.
+func (ctx *CipherContext) Open(container []uint8) ([]uint8, error) {
+	r := &containerReader{data: container}
+
+	magic, err := r.next(len(containerMagic), "magic")
+	if err != nil {
+		return nil, err
+	}
+	if string(magic) != containerMagic {
+		return nil, fmt.Errorf("invalid container magic")
+	}
+
+	fields, err := r.next(4, "header")
+	if err != nil {
+		return nil, err
+	}
+	if fields[0] != containerVersion && fields[0] != containerVersionNoTTL {
+		return nil, fmt.Errorf("unsupported container version %d", fields[0])
+	}
+
+	iv, err := r.lengthPrefixed("IV")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.lengthPrefixed("salt"); err != nil {
+		return nil, err
+	}
+
+	var expires int64
+	if fields[0] == containerVersion {
+		expiresBytes, err := r.next(8, "expiry")
+		if err != nil {
+			return nil, err
+		}
+		expires = int64(binary.BigEndian.Uint64(expiresBytes))
+	}
+
+	ctLenBytes, err := r.next(8, "ciphertext length")
+	if err != nil {
+		return nil, err
+	}
+	ctLen := binary.BigEndian.Uint64(ctLenBytes)
+	if ctLen > uint64(len(container)) {
+		return nil, fmt.Errorf("container is truncated: ciphertext needs %d bytes", ctLen)
+	}
+	ciphertext, err := r.next(int(ctLen), "ciphertext")
+	if err != nil {
+		return nil, err
+	}
+	authenticated := container[:r.offset]
+
+	tag, err := r.lengthPrefixed("tag")
+	if err != nil {
+		return nil, err
+	}
+	if r.offset != len(container) {
+		return nil, fmt.Errorf("container has %d unexpected trailing bytes", len(container)-r.offset)
+	}
+
+	if !hmac.Equal(containerTag(ctx.key, authenticated), tag) {
+		return nil, ErrAuthenticationFailed
+	}
+
+	// Заголовок подлинный: теперь его параметры можно сравнивать с контекстом
+	if expires != 0 && time.Now().After(time.Unix(expires, 0)) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrKeyExpired, time.Unix(expires, 0).UTC().Format(time.RFC3339))
+	}
+	algorithm, err := algorithmIDOf(ctx.cipher)
+	if err != nil {
+		return nil, err
+	}
+	if AlgorithmID(fields[1]) != algorithm {
+		return nil, fmt.Errorf("container algorithm id %d does not match context algorithm id %d", fields[1], algorithm)
+	}
+	if CipherMode(fields[2]) != ctx.mode {
+		return nil, fmt.Errorf("container mode %d does not match context mode %d", fields[2], ctx.mode)
+	}
+	if PaddingMode(fields[3]) != ctx.effectivePaddingMode() {
+		return nil, fmt.Errorf("container padding %d does not match context padding %d",
+			fields[3], ctx.effectivePaddingMode())
+	}
+	if ctx.mode.RequiresIV() && len(iv) != ctx.blockSize {
+		return nil, fmt.Errorf("container IV must be %d bytes, got %d", ctx.blockSize, len(iv))
+	}
+
+	return ctx.decryptWithIV(ciphertext, iv)
+}
+
+// OpenAuto открывает контейнер, не зная заранее его параметров: алгоритм, режим,
+// набивка и IV берутся из заголовка, шифр создается через реестр, и вызывающему
+// достаточно передать ключ. Заголовок до проверки тега не считается подлинным,
+// поэтому его подмена обнаруживается в Open так же, как в Open контекста.
+func OpenAuto(container, key []uint8) ([]uint8, error) {
+	r := &containerReader{data: container}
+
+	magic, err := r.next(len(containerMagic), "magic")
+	if err != nil {
+		return nil, err
+	}
+	if string(magic) != containerMagic {
+		return nil, fmt.Errorf("invalid container magic")
+	}
+	fields, err := r.next(4, "header")
+	if err != nil {
+		return nil, err
+	}
+	if fields[0] != containerVersion && fields[0] != containerVersionNoTTL {
+		return nil, fmt.Errorf("unsupported container version %d", fields[0])
+	}
+	iv, err := r.lengthPrefixed("IV")
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := AlgorithmID(fields[1])
+	info, ok := algorithmsByID[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm id %d", algorithm)
+	}
+	if len(key) != info.keySize {
+		return nil, fmt.Errorf("key size must be %d bytes for algorithm id %d, got %d",
+			info.keySize, algorithm, len(key))
+	}
+
+	cipher, _, err := NewCipherByName(info.name, info.keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	ctx, err := NewCipherContext(cipher, key, CipherMode(fields[2]), PaddingMode(fields[3]), iv, info.blockSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.Open(container)
+}
+
+// containerTag вычисляет HMAC-SHA256 над заголовком и шифртекстом контейнера
+func containerTag(key []uint8, data []uint8) []uint8 {
+	mac := hmac.New(sha256.New, macKey(key))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// containerReader последовательно читает поля контейнера с проверкой длины
+type containerReader struct {
+	data   []uint8
+	offset int
+}
+
+// next возвращает следующие n байт или ошибку, если контейнер обрезан
+func (r *containerReader) next(n int, field string) ([]uint8, error) {
+	if n < 0 || len(r.data)-r.offset < n {
+		return nil, fmt.Errorf("container is truncated: %s needs %d bytes", field, n)
+	}
+	value := r.data[r.offset : r.offset+n : r.offset+n]
+	r.offset += n
+	return value, nil
+}
+
+// lengthPrefixed читает поле с однобайтовой длиной
+func (r *containerReader) lengthPrefixed(field string) ([]uint8, error) {
+	length, err := r.next(1, field+" length")
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(length[0]), field)
+}
@@ -8,6 +8,16 @@ type DEALCipher struct {
 	feistel    *FeistelNetwork
 	currentKey []uint8
 	keyLength  int
+	blockSize  int
+}
+
+// dealRounds возвращает стандартное число раундов DEAL для длины ключа в байтах:
+// 6 для 128- и 192-битных ключей, 8 для 256-битных
+func dealRounds(keyLength int) int {
+	if keyLength == 32 {
+		return 8
+	}
+	return 6
 }
 
 func NewDEALCipher(keyLength int) (*DEALCipher, error) {
@@ -15,10 +25,7 @@ func NewDEALCipher(keyLength int) (*DEALCipher, error) {
 		return nil, fmt.Errorf("DEAL key length must be 128, 192, or 256 bits (16, 24, or 32 bytes)")
 	}
 
-	numRounds := 6
-	if keyLength == 32 {
-		numRounds = 8
-	}
+	numRounds := dealRounds(keyLength)
 
 	keySchedule, err := NewDEALKeySchedule(keyLength)
 	if err != nil {
@@ -42,9 +49,62 @@ func NewDEALCipher(keyLength int) (*DEALCipher, error) {
 	return &DEALCipher{
 		feistel:   feistel,
 		keyLength: keyLength,
+		blockSize: 16,
 	}, nil
 }
 
+// NewDEALCipher64 создает ЭКСПЕРИМЕНТАЛЬНЫЙ нестандартный вариант DEAL с 64-битным блоком.
+// Сеть Фейстеля работает с 32-битными половинами, а функция раунда делает один вызов DES
+// (см. DEAL64RoundFunction). Предназначен только для исследований, не для защиты данных.
+func NewDEALCipher64(keyLength int) (*DEALCipher, error) {
+	if keyLength != 16 && keyLength != 24 && keyLength != 32 {
+		return nil, fmt.Errorf("DEAL key length must be 128, 192, or 256 bits (16, 24, or 32 bytes)")
+	}
+
+	numRounds := dealRounds(keyLength)
+
+	keySchedule, err := NewDEALKeySchedule(keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create round keys: %w", err)
+	}
+	roundFunction, err := NewDEAL64RoundFunction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create round function: %w", err)
+	}
+
+	feistel, err := NewFeistelNetwork(
+		keySchedule,
+		roundFunction,
+		8,
+		numRounds,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Feistel network: %w", err)
+	}
+
+	return &DEALCipher{
+		feistel:   feistel,
+		keyLength: keyLength,
+		blockSize: 8,
+	}, nil
+}
+
+// SetRounds задает число раундов DEAL; оно не может превышать число
+// раундовых ключей расписания (6 или 8 в зависимости от длины ключа)
+func (deal *DEALCipher) SetRounds(n int) error {
+	if n < 1 || n > dealRounds(deal.keyLength) {
+		return fmt.Errorf("DEAL-%d rounds must be between 1 and %d, got %d",
+			deal.keyLength*8, dealRounds(deal.keyLength), n)
+	}
+	return deal.feistel.SetRoundsCount(n)
+}
+
+// Rounds возвращает текущее число раундов сети Фейстеля
+func (deal *DEALCipher) Rounds() int {
+	rounds, _ := deal.feistel.GetRoundsCount()
+	return rounds
+}
+
 func (deal *DEALCipher) SetKey(key []uint8) error {
 	if len(key) != deal.keyLength {
 		return fmt.Errorf("key size must match configured DEAL key length: got %d, need %d", len(key), deal.keyLength)
@@ -61,9 +121,11 @@ func (deal *DEALCipher) SetKey(key []uint8) error {
 	return nil
 }
 
+// EncryptBlock шифрует ровно один блок; буферы из нескольких блоков
+// разбивает на блоки CipherContext в соответствии с режимом шифрования
 func (deal *DEALCipher) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
-    if len(plainBlock) != 16 {
-        return nil, fmt.Errorf("DEAL block must be 16 bytes (128 bits), got %d", len(plainBlock))
+    if len(plainBlock) != deal.blockSize {
+        return nil, fmt.Errorf("DEAL block must be %d bytes, got %d", deal.blockSize, len(plainBlock))
     }
 
     cipherBlock, err := deal.feistel.EncryptBlock(plainBlock)
@@ -75,8 +137,8 @@ func (deal *DEALCipher) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 }
 
 func (deal *DEALCipher) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
-	if len(cipherBlock) != 16 {
-		return nil, fmt.Errorf("DEAL block must be 16 bytes (128 bits), got %d", len(cipherBlock))
+	if len(cipherBlock) != deal.blockSize {
+		return nil, fmt.Errorf("DEAL block must be %d bytes, got %d", deal.blockSize, len(cipherBlock))
 	}
 
 	plainBlock, err := deal.feistel.DecryptBlock(cipherBlock)
@@ -87,6 +149,22 @@ func (deal *DEALCipher) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
 	return plainBlock, nil
 }
 
+// Name возвращает "DEAL-<длина ключа в битах>", для экспериментального 64-битного варианта "DEAL64-<...>"
+func (deal *DEALCipher) Name() string {
+	if deal.blockSize == 8 {
+		return fmt.Sprintf("DEAL64-%d", deal.keyLength*8)
+	}
+	return fmt.Sprintf("DEAL-%d", deal.keyLength*8)
+}
+
 func (deal *DEALCipher) GetKeyLength() (int, error) {
 	return deal.keyLength, nil
-}
\ No newline at end of file
+}
+
+func (deal *DEALCipher) RequiredKeySize() int {
+	return deal.keyLength
+}
+
+func (deal *DEALCipher) GetBlockSize() int {
+	return deal.blockSize
+}
@@ -1,12 +1,15 @@
 package cripta
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 )
 
 type DEALRoundFunction struct {
-	desPool sync.Pool
+	desPool      sync.Pool
+	roundKeys    [][]uint8    // ключи раундов, переданные PrepareRoundKeys
+	roundCiphers []*DESCipher // экземпляры DES с этими ключами
 }
 
 func NewDEALRoundFunction() (*DEALRoundFunction, error) {
@@ -29,7 +32,9 @@ func NewDEALRoundFunction() (*DEALRoundFunction, error) {
 	return drf, nil
 }
 
-func (drf *DEALRoundFunction) Apply(inputBlock []uint8, roundKey []uint8) ([]uint8, error) {
+// Apply шифрует блок DES на ключе раунда. Если для раунда round подготовлен экземпляр
+// DES с тем же ключом, используется он, иначе ключ DES устанавливается при каждом вызове.
+func (drf *DEALRoundFunction) Apply(inputBlock []uint8, roundKey []uint8, round int) ([]uint8, error) {
 	if inputBlock == nil {
 		return nil, fmt.Errorf("input block cannot be nil")
 	}
@@ -44,6 +49,14 @@ func (drf *DEALRoundFunction) Apply(inputBlock []uint8, roundKey []uint8) ([]uin
 		return nil, fmt.Errorf("DEAL round key must be 8 bytes, got %d", len(roundKey))
 	}
 
+	if round >= 0 && round < len(drf.roundCiphers) && bytes.Equal(drf.roundKeys[round], roundKey) {
+		output, err := drf.roundCiphers[round].EncryptBlock(inputBlock)
+		if err != nil {
+			return nil, fmt.Errorf("DES encryption failed: %w", err)
+		}
+		return output, nil
+	}
+
 	des := drf.desPool.Get().(*DESCipher)
 	defer drf.desPool.Put(des)
 
@@ -58,4 +71,76 @@ func (drf *DEALRoundFunction) Apply(inputBlock []uint8, roundKey []uint8) ([]uin
 	}
 
 	return output, nil
-}
\ No newline at end of file
+}
+
+// PrepareRoundKeys создает по экземпляру DES с ключом каждого раунда, чтобы
+// Apply не выполнял расписание ключей DES для каждого блока
+func (drf *DEALRoundFunction) PrepareRoundKeys(roundKeys [][]uint8) error {
+	keys := make([][]uint8, len(roundKeys))
+	roundCiphers := make([]*DESCipher, len(roundKeys))
+	for round, roundKey := range roundKeys {
+		keys[round] = append([]uint8{}, roundKey...)
+		des, err := NewDESCipher()
+		if err != nil {
+			return fmt.Errorf("failed to create DES cipher: %w", err)
+		}
+		if err := des.SetKey(roundKey); err != nil {
+			return fmt.Errorf("failed to set key for round %d: %w", round, err)
+		}
+		roundCiphers[round] = des
+	}
+
+	drf.roundKeys = keys
+	drf.roundCiphers = roundCiphers
+	return nil
+}
+
+// DEAL64RoundFunction функция раунда экспериментального 64-битного DEAL:
+// 32-битная половина x дублируется в блок x || x для DES, а 64-битный результат
+// сворачивается в 32 бита сложением его половин по модулю 2
+type DEAL64RoundFunction struct {
+	inner *DEALRoundFunction
+}
+
+func NewDEAL64RoundFunction() (*DEAL64RoundFunction, error) {
+	inner, err := NewDEALRoundFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DEAL64RoundFunction{inner: inner}, nil
+}
+
+func (drf *DEAL64RoundFunction) Apply(inputBlock []uint8, roundKey []uint8, round int) ([]uint8, error) {
+	if len(inputBlock) != 4 {
+		return nil, fmt.Errorf("DEAL-64 round function input must be 4 bytes, got %d", len(inputBlock))
+	}
+
+	output, err := drf.inner.Apply(drf.expand(inputBlock), roundKey, round)
+	if err != nil {
+		return nil, err
+	}
+
+	return drf.fold(output), nil
+}
+
+func (drf *DEAL64RoundFunction) PrepareRoundKeys(roundKeys [][]uint8) error {
+	return drf.inner.PrepareRoundKeys(roundKeys)
+}
+
+// expand дублирует 32-битную половину в 64-битный блок x || x
+func (drf *DEAL64RoundFunction) expand(inputBlock []uint8) []uint8 {
+	expanded := make([]uint8, 8)
+	copy(expanded, inputBlock)
+	copy(expanded[4:], inputBlock)
+	return expanded
+}
+
+// fold сворачивает 64-битный выход DES в 32 бита сложением половин
+func (drf *DEAL64RoundFunction) fold(output []uint8) []uint8 {
+	folded := make([]uint8, 4)
+	for i := range folded {
+		folded[i] = output[i] ^ output[i+4]
+	}
+	return folded
+}
@@ -30,8 +30,37 @@ var FP = []int{
 }
 
 func NewDESCipher() (*DESCipher, error) {
+	return newDESCipher(&DESRoundFunction{})
+}
+
+// NewDESCipherWithSBoxes создает DES с собственным набором S-блоков для экспериментов.
+// Каждый S-блок задается 64 значениями: 4 строки по 16, как в стандарте (строка
+// выбирается крайними битами 6-битного входа, столбец - средними). Каждая строка
+// должна быть перестановкой чисел 0..15, как у стандартных S-блоков DES.
+func NewDESCipherWithSBoxes(sBoxes [8][64]byte) (*DESCipher, error) {
+	var boxes [8][4][16]uint8
+	for box := range sBoxes {
+		for row := 0; row < 4; row++ {
+			var seen [16]bool
+			for col := 0; col < 16; col++ {
+				value := sBoxes[box][row*16+col]
+				if value > 15 {
+					return nil, fmt.Errorf("S-box %d row %d: value %d does not fit in 4 bits", box+1, row, value)
+				}
+				if seen[value] {
+					return nil, fmt.Errorf("S-box %d row %d is not a permutation of 0..15: value %d repeats", box+1, row, value)
+				}
+				seen[value] = true
+				boxes[box][row][col] = value
+			}
+		}
+	}
+
+	return newDESCipher(&DESRoundFunction{sBoxes: &boxes})
+}
+
+func newDESCipher(roundFunction *DESRoundFunction) (*DESCipher, error) {
 	keySchedule := &DESKeySchedule{}
-	roundFunction := &DESRoundFunction{}
 
 	feistel, err := NewFeistelNetwork(
 		keySchedule,
@@ -64,6 +93,15 @@ func (des *DESCipher) SetKey(key []uint8) error {
 	return nil
 }
 
+// После 16-го раунда DES половины не меняются местами (выход R16 L16),
+// а сеть Фейстеля возвращает L16 R16, поэтому половины разворачиваются
+func (des *DESCipher) swapHalves(block []uint8) []uint8 {
+	swapped := make([]uint8, 8)
+	copy(swapped, block[4:])
+	copy(swapped[4:], block[:4])
+	return swapped
+}
+
 func (des *DESCipher) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 	if len(plainBlock) != 8 {
 		return nil, fmt.Errorf("DES block must be 8 bytes (64 bits)")
@@ -79,7 +117,7 @@ func (des *DESCipher) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 		return nil, fmt.Errorf("feistel encryption failed: %w", err)
 	}
 
-	cipherBlock, err := PermuteBits(feistelOutput, FP, false, 1)
+	cipherBlock, err := PermuteBits(des.swapHalves(feistelOutput), FP, false, 1)
 	if err != nil {
 		return nil, fmt.Errorf("FP permutation failed: %w", err)
 	}
@@ -97,7 +135,7 @@ func (des *DESCipher) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
 		return nil, fmt.Errorf("IP permutation failed: %w", err)
 	}
 
-	feistelOutput, err := des.feistel.DecryptBlock(permuted)
+	feistelOutput, err := des.feistel.DecryptBlock(des.swapHalves(permuted))
 	if err != nil {
 		return nil, fmt.Errorf("feistel decryption failed: %w", err)
 	}
@@ -108,4 +146,35 @@ func (des *DESCipher) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
 	}
 
 	return plainBlock, nil
-}
\ No newline at end of file
+}
+
+// RoundKeysDistinct сообщает, различны ли все 16 раундовых ключей текущего ключа
+func (des *DESCipher) RoundKeysDistinct() bool {
+	return des.feistel.RoundKeysDistinct()
+}
+
+// SetRounds задает число раундов от 1 до 16 для экспериментов с ослабленным DES
+func (des *DESCipher) SetRounds(n int) error {
+	if n < 1 || n > 16 {
+		return fmt.Errorf("DES rounds must be between 1 and 16, got %d", n)
+	}
+	return des.feistel.SetRoundsCount(n)
+}
+
+// Rounds возвращает текущее число раундов сети Фейстеля
+func (des *DESCipher) Rounds() int {
+	rounds, _ := des.feistel.GetRoundsCount()
+	return rounds
+}
+
+func (des *DESCipher) Name() string {
+	return "DES"
+}
+
+func (des *DESCipher) RequiredKeySize() int {
+	return 8
+}
+
+func (des *DESCipher) GetBlockSize() int {
+	return 8
+}
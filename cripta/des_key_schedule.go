@@ -44,10 +44,12 @@ func (dks *DESKeySchedule) leftShift28(data []uint8, shifts int) ([]uint8, error
 	value |= (uint32(data[2]) << 8)
 	value |= uint32(data[3])
 
+	// 28 бит половины ключа выровнены по старшему биту
 	mask28 := uint32(0x0FFFFFFF)
-	value &= mask28
+	value = (value >> 4) & mask28
 
 	value = ((value << shifts) | (value >> (28 - shifts))) & mask28
+	value <<= 4
 
 	result := make([]uint8, 4)
 	result[0] = uint8((value >> 24) & 0xFF)
@@ -4,7 +4,9 @@ import (
 	"fmt"
 )
 
-type DESRoundFunction struct{}
+type DESRoundFunction struct {
+	sBoxes *[8][4][16]uint8 // nil - стандартные S_BOXES
+}
 
 var E_TABLE = []int{
 	32, 1, 2, 3, 4, 5,
@@ -119,7 +121,11 @@ func (drf *DESRoundFunction) applySBoxes(input []uint8) ([]uint8, error) {
 		row := ((sixBits & 0x20) >> 4) | (sixBits & 0x01)
 		col := (sixBits >> 1) & 0x0F
 
-		sboxValue := S_BOXES[i][row][col]
+		boxes := &S_BOXES
+		if drf.sBoxes != nil {
+			boxes = drf.sBoxes
+		}
+		sboxValue := boxes[i][row][col]
 
 		outBitPos := i * 4
 		outByteIdx := outBitPos / 8
@@ -138,7 +144,7 @@ func (drf *DESRoundFunction) applySBoxes(input []uint8) ([]uint8, error) {
 	return output, nil
 }
 
-func (drf *DESRoundFunction) Apply(inputBlock []uint8, roundKey []uint8) ([]uint8, error) {
+func (drf *DESRoundFunction) Apply(inputBlock []uint8, roundKey []uint8, round int) ([]uint8, error) {
 	if inputBlock == nil {
 		return nil, fmt.Errorf("input block cannot be nil")
 	}
@@ -0,0 +1,27 @@
+package cripta
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// IsDeterministic дважды шифрует plaintext одним контекстом и сообщает, совпали ли
+// шифртексты. ECB и режимы с фиксированным IV детерминированы; AutoIV, RandomDelta
+// и набивка ISO 10126 вносят случайность, и результаты различаются.
+func IsDeterministic(ctx *CipherContext, plaintext []uint8) (bool, error) {
+	if ctx == nil {
+		return false, fmt.Errorf("cipher context cannot be nil")
+	}
+
+	first, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		return false, fmt.Errorf("first encryption failed: %w", err)
+	}
+
+	second, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		return false, fmt.Errorf("second encryption failed: %w", err)
+	}
+
+	return bytes.Equal(first, second), nil
+}
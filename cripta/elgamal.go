@@ -0,0 +1,197 @@
+package cripta
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// ElGamalPublicKey открытый ключ Эль-Гамаля
+type ElGamalPublicKey struct {
+	P *big.Int // безопасное простое p = 2q + 1
+	G *big.Int // порождающий элемент группы Z_p*
+	Y *big.Int // y = g^x mod p
+}
+
+// ElGamalKey пара ключей Эль-Гамаля
+type ElGamalKey struct {
+	PublicKey ElGamalPublicKey
+	X         *big.Int // закрытый ключ
+}
+
+// ElGamalService сервис для шифрования/дешифрования Эль-Гамаля
+type ElGamalService struct {
+	testType       PrimalityTestType
+	minProbability float64
+	bitLength      int
+	currentKey     *ElGamalKey
+}
+
+// NewElGamalService создает новый сервис Эль-Гамаля
+func NewElGamalService(testType PrimalityTestType, minProbability float64, bitLength int) *ElGamalService {
+	if minProbability < 0.5 || minProbability >= 1 {
+		minProbability = 0.999
+	}
+	if bitLength < 64 {
+		bitLength = 64
+	}
+
+	return &ElGamalService{
+		testType:       testType,
+		minProbability: minProbability,
+		bitLength:      bitLength,
+	}
+}
+
+// GenerateNewKey генерирует параметры p, g и пару ключей
+func (es *ElGamalService) GenerateNewKey() error {
+	p, q, err := es.generateSafePrime()
+	if err != nil {
+		return err
+	}
+
+	g := es.findGenerator(p, q)
+
+	// Закрытый ключ x выбирается из [2, p-2]
+	x, err := randomInRange(p)
+	if err != nil {
+		return err
+	}
+
+	es.currentKey = &ElGamalKey{
+		PublicKey: ElGamalPublicKey{
+			P: p,
+			G: g,
+			Y: BigModExp(g, x, p),
+		},
+		X: x,
+	}
+	return nil
+}
+
+// GetPublicKey возвращает текущий открытый ключ
+func (es *ElGamalService) GetPublicKey() (*ElGamalPublicKey, error) {
+	if es.currentKey == nil {
+		return nil, errors.New("ключи не сгенерированы")
+	}
+
+	return &es.currentKey.PublicKey, nil
+}
+
+// elGamalMarker байт перед сообщением: без него ведущие нулевые байты сообщения
+// терялись бы при переводе в число и обратно
+const elGamalMarker = 0x01
+
+// Encrypt шифрует сообщение: c1 = g^k mod p, c2 = m * y^k mod p со случайным k.
+// Число m - сообщение с байтом-маркером 0x01 впереди, так что ведущие нули
+// сообщения сохраняются, а максимальная длина на байт меньше длины p.
+func (es *ElGamalService) Encrypt(message []byte) (*big.Int, *big.Int, error) {
+	if es.currentKey == nil {
+		return nil, nil, errors.New("ключи не сгенерированы")
+	}
+
+	pub := es.currentKey.PublicKey
+	m := new(big.Int).SetBytes(append([]byte{elGamalMarker}, message...))
+	if m.Cmp(pub.P) >= 0 {
+		return nil, nil, errors.New("сообщение слишком велико для модуля p")
+	}
+
+	k, err := randomInRange(pub.P)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c1 := BigModExp(pub.G, k, pub.P)
+	c2 := new(big.Int).Mul(m, BigModExp(pub.Y, k, pub.P))
+	c2.Mod(c2, pub.P)
+
+	return c1, c2, nil
+}
+
+// Decrypt дешифрует сообщение: m = c2 * (c1^x)^(-1) mod p
+func (es *ElGamalService) Decrypt(c1, c2 *big.Int) ([]byte, error) {
+	if es.currentKey == nil {
+		return nil, errors.New("ключи не сгенерированы")
+	}
+
+	p := es.currentKey.PublicKey.P
+	s := BigModExp(c1, es.currentKey.X, p)
+	sInv, ok := BigModularInverse(s, p)
+	if !ok {
+		return nil, errors.New("некорректный шифртекст: c1 не обратим по модулю p")
+	}
+
+	m := new(big.Int).Mul(c2, sInv)
+	m.Mod(m, p)
+
+	encoded := m.Bytes()
+	if len(encoded) == 0 || encoded[0] != elGamalMarker {
+		return nil, errors.New("некорректный шифртекст: нет маркера сообщения")
+	}
+	return encoded[1:], nil
+}
+
+// safePrimeSieve малые простые для отсева кандидатов до вероятностного теста
+var safePrimeSieve = []int64{3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+
+// generateSafePrime ищет безопасное простое p = 2q + 1 длиной bitLength бит: случайные
+// нечетные q отсеиваются делением на малые простые (q и 2q + 1 не должны на них
+// делиться), а оставшиеся q и p проверяются выбранным тестом простоты
+func (es *ElGamalService) generateSafePrime() (*big.Int, *big.Int, error) {
+	test := CreatePrimalityTest(es.testType)
+	// Доля безопасных простых среди кандидатов порядка 1/bitLength^2, с запасом
+	maxAttempts := 1000 * es.bitLength
+
+	// q из [2^(bitLength-2), 2^(bitLength-1)), тогда p имеет ровно bitLength бит
+	low := new(big.Int).Lsh(big.NewInt(1), uint(es.bitLength-2))
+	remainder := new(big.Int)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		q, err := rand.Int(RandReader, low)
+		if err != nil {
+			return nil, nil, err
+		}
+		q.Add(q, low)
+		q.SetBit(q, 0, 1)
+
+		sieved := true
+		for _, small := range safePrimeSieve {
+			r := remainder.Mod(q, big.NewInt(small)).Int64()
+			if r == 0 || r == (small-1)/2 {
+				sieved = false
+				break
+			}
+		}
+		if !sieved || !test.IsPrime(q, es.minProbability) {
+			continue
+		}
+
+		p := new(big.Int).Lsh(q, 1)
+		p.Add(p, big.NewInt(1))
+		if test.IsPrime(p, es.minProbability) {
+			return p, q, nil
+		}
+	}
+
+	return nil, nil, errors.New("не удалось сгенерировать безопасное простое число")
+}
+
+// findGenerator ищет порождающий элемент: для p = 2q + 1 достаточно g^2 != 1 и g^q != 1
+func (es *ElGamalService) findGenerator(p, q *big.Int) *big.Int {
+	one := big.NewInt(1)
+	for g := big.NewInt(2); ; g.Add(g, one) {
+		if BigModExp(g, big.NewInt(2), p).Cmp(one) != 0 && BigModExp(g, q, p).Cmp(one) != 0 {
+			return g
+		}
+	}
+}
+
+// randomInRange возвращает случайное число из [2, p-2]
+func randomInRange(p *big.Int) (*big.Int, error) {
+	limit := new(big.Int).Sub(p, big.NewInt(3))
+	n, err := rand.Int(RandReader, limit)
+	if err != nil {
+		return nil, err
+	}
+	return n.Add(n, big.NewInt(2)), nil
+}
@@ -15,6 +15,15 @@ type FeistelNetwork struct {
 	roundKeys  [][]uint8
 }
 
+// Значения по умолчанию для NewFeistelNetwork при нулевых параметрах (как в DES)
+const (
+	DefaultFeistelBlockSize = 8
+	DefaultFeistelRounds    = 16
+)
+
+// NewFeistelNetwork создает сеть Фейстеля. Нулевой blockSize означает
+// DefaultFeistelBlockSize, нулевой roundsCount - DefaultFeistelRounds;
+// отрицательные значения и нечетный размер блока считаются ошибкой.
 func NewFeistelNetwork(
 	keyScheduleImpl IKeySchedule,
 	roundFunctionImpl IRoundFunction,
@@ -28,18 +37,24 @@ func NewFeistelNetwork(
 	if roundFunctionImpl == nil {
 		return nil, fmt.Errorf("round function implementation cannot be nil")
 	}
+	if blockSize < 0 {
+		return nil, fmt.Errorf("block size must be positive for Feistel network, got %d", blockSize)
+	}
 	if blockSize%2 != 0 {
-		return nil, fmt.Errorf("block size must be even for Feistel network")
+		return nil, fmt.Errorf("block size must be even for Feistel network, got %d", blockSize)
+	}
+	if roundsCount < 0 {
+		return nil, fmt.Errorf("rounds count must be positive for Feistel network, got %d", roundsCount)
 	}
 
 	fBlockSize := blockSize
 	if fBlockSize == 0 {
-		fBlockSize = 8
+		fBlockSize = DefaultFeistelBlockSize
 	}
 
 	fRoundsCount := roundsCount
 	if fRoundsCount == 0 {
-		fRoundsCount = 16
+		fRoundsCount = DefaultFeistelRounds
 	}
 
 	return &FeistelNetwork{
@@ -58,6 +73,21 @@ func (fn *FeistelNetwork) GetRoundsCount() (int, error) {
 	return fn.roundsCount, nil
 }
 
+// SetRoundsCount меняет число раундов. Раундовых ключей текущего ключа
+// должно хватать на новое число раундов.
+func (fn *FeistelNetwork) SetRoundsCount(roundsCount int) error {
+	if roundsCount <= 0 {
+		return fmt.Errorf("rounds count must be positive for Feistel network, got %d", roundsCount)
+	}
+	if len(fn.roundKeys) > 0 && len(fn.roundKeys) < roundsCount {
+		return fmt.Errorf("key schedule generated insufficient round keys: got %d, need %d",
+			len(fn.roundKeys), roundsCount)
+	}
+
+	fn.roundsCount = roundsCount
+	return nil
+}
+
 func (fn *FeistelNetwork) splitBlock(block []uint8) ([]uint8, []uint8, error) {
 	if len(block) == 0 {
 		return nil, nil, fmt.Errorf("block cannot be empty")
@@ -114,24 +144,50 @@ func (fn *FeistelNetwork) SetKey(key []uint8) error {
 		return fmt.Errorf("key cannot be empty")
 	}
 
-	fn.currentKey = make([]uint8, len(key))
-	copy(fn.currentKey, key)
-
 	roundKeys, err := fn.keySchedule.GenerateRoundKeys(key)
 	if err != nil {
 		return fmt.Errorf("failed to generate round keys: %w", err)
 	}
 
-	fn.roundKeys = roundKeys
+	// Расписание сохраняется только после проверки: иначе при ошибке сеть осталась
+	// бы с коротким расписанием, и шифрование вышло бы за его пределы
+	if len(roundKeys) < fn.roundsCount {
+		return fmt.Errorf("key schedule generated insufficient round keys: got %d, need %d",
+			len(roundKeys), fn.roundsCount)
+	}
 
-	if len(fn.roundKeys) < fn.roundsCount {
-		return fmt.Errorf("key schedule generated insufficient round keys: got %d, need %d", 
-			len(fn.roundKeys), fn.roundsCount)
+	if prepared, ok := fn.roundFunction.(IPreparedRoundFunction); ok {
+		if err := prepared.PrepareRoundKeys(roundKeys); err != nil {
+			return fmt.Errorf("failed to prepare round keys: %w", err)
+		}
 	}
 
+	fn.currentKey = make([]uint8, len(key))
+	copy(fn.currentKey, key)
+	fn.roundKeys = roundKeys
+
 	return nil
 }
 
+// RoundKeysDistinct проверяет, что все раундовые ключи попарно различны.
+// Повторяющиеся ключи указывают на ошибку расписания или слабый ключ.
+// До установки ключа возвращает false.
+func (fn *FeistelNetwork) RoundKeysDistinct() bool {
+	if len(fn.roundKeys) == 0 {
+		return false
+	}
+
+	seen := make(map[string]struct{}, len(fn.roundKeys))
+	for _, roundKey := range fn.roundKeys[:fn.roundsCount] {
+		if _, exists := seen[string(roundKey)]; exists {
+			return false
+		}
+		seen[string(roundKey)] = struct{}{}
+	}
+
+	return true
+}
+
 func (fn *FeistelNetwork) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 	if plainBlock == nil {
 		return nil, fmt.Errorf("plain block cannot be nil")
@@ -144,6 +200,9 @@ func (fn *FeistelNetwork) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 	if len(fn.roundKeys) == 0 {
 		return nil, fmt.Errorf("key not set. Call SetKey() before encryption")
 	}
+	if len(fn.roundKeys) < fn.roundsCount {
+		return nil, fmt.Errorf("key schedule has %d round keys, need %d", len(fn.roundKeys), fn.roundsCount)
+	}
 
 	left, right, err := fn.splitBlock(plainBlock)
 	if err != nil {
@@ -154,7 +213,7 @@ func (fn *FeistelNetwork) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
 		newLeft := make([]uint8, len(right))
 		copy(newLeft, right)
 
-		functionOutput, err := fn.roundFunction.Apply(right, fn.roundKeys[round])
+		functionOutput, err := fn.roundFunction.Apply(right, fn.roundKeys[round], round)
 		if err != nil {
 			return nil, fmt.Errorf("round function error in round %d: %w", round, err)
 		}
@@ -188,6 +247,9 @@ func (fn *FeistelNetwork) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
 	if len(fn.roundKeys) == 0 {
 		return nil, fmt.Errorf("key not set. Call SetKey() before decryption")
 	}
+	if len(fn.roundKeys) < fn.roundsCount {
+		return nil, fmt.Errorf("key schedule has %d round keys, need %d", len(fn.roundKeys), fn.roundsCount)
+	}
 
 	left, right, err := fn.splitBlock(cipherBlock)
 	if err != nil {
@@ -198,7 +260,7 @@ func (fn *FeistelNetwork) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
 		newRight := make([]uint8, len(left))
 		copy(newRight, left)
 
-		functionOutput, err := fn.roundFunction.Apply(left, fn.roundKeys[round])
+		functionOutput, err := fn.roundFunction.Apply(left, fn.roundKeys[round], round)
 		if err != nil {
 			return nil, fmt.Errorf("round function error in round %d: %w", round, err)
 		}
@@ -0,0 +1,193 @@
+package cripta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// fpeAlphabet символы строк для оснований до 36: цифры, затем строчные латинские буквы
+const fpeAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// fpeRounds число раундов Фейстеля в FF1, fpeMinDomain минимальный размер
+// множества входов radix^n (NIST SP 800-38G, ред. 1)
+const (
+	fpeRounds    = 10
+	fpeMinDomain = 1000000
+)
+
+// FPECipher шифрование с сохранением формата по схеме FF1 (NIST SP 800-38G):
+// строка из n символов алфавита radix переходит в строку той же длины и алфавита.
+// Блочный шифр со 128-битным блоком используется как PRF (CBC-MAC) в сети Фейстеля.
+type FPECipher struct {
+	cipher ISymmetricCipher
+	radix  int
+}
+
+// NewFPECipher создает FF1 поверх шифра с уже установленным ключом; radix от 2 до 36
+func NewFPECipher(cipher ISymmetricCipher, radix int) (*FPECipher, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("cipher implementation cannot be nil")
+	}
+	if sized, ok := cipher.(IBlockSizeProvider); ok && sized.GetBlockSize() != 16 {
+		return nil, fmt.Errorf("FF1 requires a 16-byte block cipher, got %d", sized.GetBlockSize())
+	}
+	if radix < 2 || radix > len(fpeAlphabet) {
+		return nil, fmt.Errorf("radix must be between 2 and %d, got %d", len(fpeAlphabet), radix)
+	}
+
+	return &FPECipher{cipher: cipher, radix: radix}, nil
+}
+
+// Encrypt шифрует строку input с настройкой (tweak), которая может быть пустой
+func (f *FPECipher) Encrypt(input string, tweak []uint8) (string, error) {
+	return f.process(input, tweak, true)
+}
+
+// Decrypt расшифровывает строку, зашифрованную Encrypt с той же настройкой
+func (f *FPECipher) Decrypt(input string, tweak []uint8) (string, error) {
+	return f.process(input, tweak, false)
+}
+
+func (f *FPECipher) process(input string, tweak []uint8, encrypt bool) (string, error) {
+	numerals, err := f.parse(input)
+	if err != nil {
+		return "", err
+	}
+
+	n := len(numerals)
+	if n < 2 || math.Pow(float64(f.radix), float64(n)) < fpeMinDomain {
+		return "", fmt.Errorf("input of %d symbols is too short for radix %d", n, f.radix)
+	}
+
+	u := n / 2
+	v := n - u
+	a, b := numerals[:u], numerals[u:]
+
+	// b байт хватает на radix^v, d байт PRF берется с запасом в 4 байта
+	byteLen := (int(math.Ceil(float64(v)*math.Log2(float64(f.radix)))) + 7) / 8
+	outLen := 4*((byteLen+3)/4) + 4
+
+	p := make([]uint8, 16)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = uint8(f.radix>>16), uint8(f.radix>>8), uint8(f.radix)
+	p[6] = fpeRounds
+	p[7] = uint8(u)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(len(tweak)))
+
+	radix := big.NewInt(int64(f.radix))
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	for step := 0; step < fpeRounds; step++ {
+		round := step
+		if !encrypt {
+			round = fpeRounds - 1 - step
+		}
+
+		m, mod := u, modU
+		if round%2 == 1 {
+			m, mod = v, modV
+		}
+
+		// Вход PRF строится из половины, которая в этом раунде не меняется
+		source := b
+		if !encrypt {
+			source = a
+		}
+		y, err := f.roundValue(p, tweak, round, f.num(source), byteLen, outLen)
+		if err != nil {
+			return "", err
+		}
+
+		if encrypt {
+			c := new(big.Int).Add(f.num(a), y)
+			a, b = b, f.str(c.Mod(c, mod), m)
+		} else {
+			c := new(big.Int).Sub(f.num(b), y)
+			b, a = a, f.str(c.Mod(c, mod), m)
+		}
+	}
+
+	return f.format(append(append([]int{}, a...), b...)), nil
+}
+
+// roundValue вычисляет y = NUM(S): PRF(P || Q) с расширением до outLen байт
+func (f *FPECipher) roundValue(p, tweak []uint8, round int, half *big.Int, byteLen, outLen int) (*big.Int, error) {
+	padding := (16 - (len(tweak)+byteLen+1)%16) % 16
+
+	q := make([]uint8, 0, len(tweak)+padding+1+byteLen)
+	q = append(q, tweak...)
+	q = append(q, make([]uint8, padding)...)
+	q = append(q, uint8(round))
+	q = append(q, BytesFixedLen(half, byteLen)...)
+
+	r, err := CBCMAC(f.cipher, 16, append(append([]uint8{}, p...), q...))
+	if err != nil {
+		return nil, err
+	}
+
+	s := append([]uint8{}, r...)
+	for j := 1; len(s) < outLen; j++ {
+		block := make([]uint8, 16)
+		binary.BigEndian.PutUint64(block[8:], uint64(j))
+		for i := range block {
+			block[i] ^= r[i]
+		}
+
+		encrypted, err := f.cipher.EncryptBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt block: %w", err)
+		}
+		s = append(s, encrypted...)
+	}
+
+	return new(big.Int).SetBytes(s[:outLen]), nil
+}
+
+// num переводит последовательность цифр по основанию radix в число (старшая цифра первой)
+func (f *FPECipher) num(numerals []int) *big.Int {
+	result := new(big.Int)
+	radix := big.NewInt(int64(f.radix))
+	for _, digit := range numerals {
+		result.Mul(result, radix)
+		result.Add(result, big.NewInt(int64(digit)))
+	}
+	return result
+}
+
+// str переводит число в последовательность из length цифр по основанию radix
+func (f *FPECipher) str(value *big.Int, length int) []int {
+	numerals := make([]int, length)
+	x := new(big.Int).Set(value)
+	radix := big.NewInt(int64(f.radix))
+	digit := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		x.DivMod(x, radix, digit)
+		numerals[i] = int(digit.Int64())
+	}
+	return numerals
+}
+
+func (f *FPECipher) parse(input string) ([]int, error) {
+	numerals := make([]int, len(input))
+	for i, symbol := range []byte(input) {
+		digit := strings.IndexByte(fpeAlphabet[:f.radix], symbol)
+		if digit < 0 {
+			return nil, fmt.Errorf("symbol %q at position %d is not valid for radix %d", symbol, i, f.radix)
+		}
+		numerals[i] = digit
+	}
+	return numerals, nil
+}
+
+func (f *FPECipher) format(numerals []int) string {
+	var builder strings.Builder
+	for _, digit := range numerals {
+		builder.WriteByte(fpeAlphabet[digit])
+	}
+	return builder.String()
+}
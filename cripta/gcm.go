@@ -0,0 +1,200 @@
+package cripta
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+// gcmBlockSize GCM определен только для шифров со 128-битным блоком
+const (
+	gcmBlockSize     = 16
+	gcmTagSize       = 16
+	gcmStandardIVLen = 12
+)
+
+// GCM режим Galois/Counter Mode (NIST SP 800-38D) поверх блочного шифра с 16-байтным блоком.
+// Поддерживает IV произвольной ненулевой длины: для IV, отличного от 96 бит,
+// начальный счетчик J0 вычисляется через GHASH, как того требует стандарт.
+type GCM struct {
+	cipher  ISymmetricCipher
+	hashKey [2]uint64 // H = E(0^128) в виде двух big-endian половин
+}
+
+// NewGCM создает GCM поверх шифра с уже установленным ключом
+func NewGCM(cipher ISymmetricCipher) (*GCM, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("cipher implementation cannot be nil")
+	}
+	if sized, ok := cipher.(IBlockSizeProvider); ok && sized.GetBlockSize() != gcmBlockSize {
+		return nil, fmt.Errorf("GCM requires a %d-byte block cipher, got %d", gcmBlockSize, sized.GetBlockSize())
+	}
+
+	h, err := cipher.EncryptBlock(make([]uint8, gcmBlockSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive hash key: %w", err)
+	}
+
+	return &GCM{
+		cipher:  cipher,
+		hashKey: [2]uint64{binary.BigEndian.Uint64(h[:8]), binary.BigEndian.Uint64(h[8:])},
+	}, nil
+}
+
+// Seal шифрует plaintext и возвращает ciphertext || tag (16 байт); aad аутентифицируется, но не шифруется
+func (g *GCM) Seal(iv, plaintext, aad []uint8) ([]uint8, error) {
+	j0, err := g.counterBlock(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := g.ctr(j0, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := g.tag(j0, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ciphertext, tag...), nil
+}
+
+// Open проверяет тег ciphertext || tag и только после этого расшифровывает данные
+func (g *GCM) Open(iv, ciphertext, aad []uint8) ([]uint8, error) {
+	if len(ciphertext) < gcmTagSize {
+		return nil, fmt.Errorf("ciphertext is too short to contain tag: %d bytes", len(ciphertext))
+	}
+
+	j0, err := g.counterBlock(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	body := ciphertext[:len(ciphertext)-gcmTagSize]
+	expected, err := g.tag(j0, body, aad)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(expected, ciphertext[len(body):]) != 1 {
+		return nil, ErrAuthenticationFailed
+	}
+
+	return g.ctr(j0, body)
+}
+
+// counterBlock вычисляет J0: IV || 0^31 || 1 для 96-битного IV, иначе
+// GHASH(IV || 0^s || 0^64 || [len(IV)]_64)
+func (g *GCM) counterBlock(iv []uint8) ([]uint8, error) {
+	if len(iv) == 0 {
+		return nil, fmt.Errorf("GCM IV cannot be empty")
+	}
+
+	j0 := make([]uint8, gcmBlockSize)
+	if len(iv) == gcmStandardIVLen {
+		copy(j0, iv)
+		j0[gcmBlockSize-1] = 1
+		return j0, nil
+	}
+
+	var y [2]uint64
+	g.ghashUpdate(&y, iv)
+	g.ghashLengths(&y, 0, len(iv))
+	binary.BigEndian.PutUint64(j0[:8], y[0])
+	binary.BigEndian.PutUint64(j0[8:], y[1])
+	return j0, nil
+}
+
+// ctr шифрует данные в режиме GCTR, начиная со счетчика inc32(J0)
+func (g *GCM) ctr(j0, data []uint8) ([]uint8, error) {
+	counter := make([]uint8, gcmBlockSize)
+	copy(counter, j0)
+
+	result := make([]uint8, len(data))
+	for offset := 0; offset < len(data); offset += gcmBlockSize {
+		increment32(counter)
+
+		keystream, err := g.cipher.EncryptBlock(counter)
+		if err != nil {
+			return nil, fmt.Errorf("encryption failed for block %d: %w", offset/gcmBlockSize, err)
+		}
+
+		end := min(offset+gcmBlockSize, len(data))
+		for i := offset; i < end; i++ {
+			result[i] = data[i] ^ keystream[i-offset]
+		}
+	}
+
+	return result, nil
+}
+
+// tag вычисляет E(J0) xor GHASH(A || C || [len(A)]_64 || [len(C)]_64)
+func (g *GCM) tag(j0, ciphertext, aad []uint8) ([]uint8, error) {
+	var y [2]uint64
+	g.ghashUpdate(&y, aad)
+	g.ghashUpdate(&y, ciphertext)
+	g.ghashLengths(&y, len(aad), len(ciphertext))
+
+	mask, err := g.cipher.EncryptBlock(j0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt J0: %w", err)
+	}
+
+	tag := make([]uint8, gcmTagSize)
+	binary.BigEndian.PutUint64(tag[:8], y[0])
+	binary.BigEndian.PutUint64(tag[8:], y[1])
+	for i := range tag {
+		tag[i] ^= mask[i]
+	}
+	return tag, nil
+}
+
+// ghashUpdate добавляет данные в GHASH, дополняя последний блок нулями
+func (g *GCM) ghashUpdate(y *[2]uint64, data []uint8) {
+	for offset := 0; offset < len(data); offset += gcmBlockSize {
+		var block [gcmBlockSize]uint8
+		copy(block[:], data[offset:])
+
+		y[0] ^= binary.BigEndian.Uint64(block[:8])
+		y[1] ^= binary.BigEndian.Uint64(block[8:])
+		*y = gfMul128(*y, g.hashKey)
+	}
+}
+
+// ghashLengths добавляет в GHASH завершающий блок с длинами в битах
+func (g *GCM) ghashLengths(y *[2]uint64, aadLen, dataLen int) {
+	y[0] ^= uint64(aadLen) * 8
+	y[1] ^= uint64(dataLen) * 8
+	*y = gfMul128(*y, g.hashKey)
+}
+
+// gfMul128 умножение в GF(2^128) с многочленом x^128 + x^7 + x^2 + x + 1
+// в отраженном порядке битов GCM (алгоритм 1 из SP 800-38D)
+func gfMul128(x, y [2]uint64) [2]uint64 {
+	var z [2]uint64
+	v := y
+
+	for i := 0; i < 128; i++ {
+		word := x[i/64]
+		if word>>(63-uint(i%64))&1 == 1 {
+			z[0] ^= v[0]
+			z[1] ^= v[1]
+		}
+
+		lsb := v[1] & 1
+		v[1] = v[1]>>1 | v[0]<<63
+		v[0] >>= 1
+		if lsb == 1 {
+			v[0] ^= 0xe1 << 56
+		}
+	}
+
+	return z
+}
+
+// increment32 увеличивает младшие 32 бита счетчика по модулю 2^32
+func increment32(counter []uint8) {
+	tail := counter[len(counter)-4:]
+	binary.BigEndian.PutUint32(tail, binary.BigEndian.Uint32(tail)+1)
+}
@@ -5,13 +5,56 @@ import (
 )
 
 // GF28Service предоставляет функционал для работы с полем GF(2⁸)
-type GF28Service struct{}
+type GF28Service struct {
+	tables *gf28Tables // таблицы логарифмов, если сервис создан NewGF28ServiceWithTables
+}
+
+// gf28Tables таблицы степеней и логарифмов по порождающему элементу для одного модуля
+type gf28Tables struct {
+	modulus byte
+	exp     [510]byte // exp[i] = g^i, удвоена, чтобы не брать остаток при сложении логарифмов
+	log     [256]byte // log[g^i] = i, log[0] не используется
+}
 
 // NewGF28Service создает новый сервис для работы с GF(2⁸)
 func NewGF28Service() *GF28Service {
 	return &GF28Service{}
 }
 
+// NewGF28ServiceWithTables создает сервис с таблицами логарифмов для модуля modulus
+// по порождающему элементу generator (для AES с модулем 0x1B это 0x03). Проверяет,
+// что модуль неприводим, а generator примитивен, то есть его степени пробегают
+// все 255 ненулевых элементов поля. Multiply, Inverse и Divide по этому модулю
+// после этого выполняются через таблицы.
+func NewGF28ServiceWithTables(modulus, generator byte) (*GF28Service, error) {
+	s := &GF28Service{}
+	if !s.IsIrreducible(modulus) {
+		return nil, fmt.Errorf("modulus 0x%02x is reducible", modulus)
+	}
+
+	order, err := s.MultiplicativeOrder(generator, modulus)
+	if err != nil {
+		return nil, err
+	}
+	if order != 255 {
+		return nil, fmt.Errorf("0x%02x is not a primitive element modulo 0x%02x: its order is %d, not 255",
+			generator, modulus, order)
+	}
+
+	tables := &gf28Tables{modulus: modulus}
+	element := byte(1)
+	for i := 0; i < 255; i++ {
+		tables.exp[i] = element
+		tables.exp[i+255] = element
+		tables.log[element] = byte(i)
+
+		element, _ = s.Multiply(element, generator, modulus)
+	}
+
+	s.tables = tables
+	return s, nil
+}
+
 // Add складывает два элемента из GF(2⁸) (побитовое XOR)
 func (s *GF28Service) Add(a, b byte) byte {
 	return a ^ b
@@ -19,6 +62,13 @@ func (s *GF28Service) Add(a, b byte) byte {
 
 // Multiply умножает два элемента из GF(2⁸) по заданному модулю
 func (s *GF28Service) Multiply(a, b byte, modulus byte) (byte, error) {
+	if s.tables != nil && s.tables.modulus == modulus {
+		if a == 0 || b == 0 {
+			return 0, nil
+		}
+		return s.tables.exp[int(s.tables.log[a])+int(s.tables.log[b])], nil
+	}
+
 	var result byte = 0
 	var highBit byte = 0x80
 
@@ -46,6 +96,10 @@ func (s *GF28Service) Inverse(a byte, modulus byte) (byte, error) {
 		return 0, fmt.Errorf("zero element has no inverse")
 	}
 
+	if s.tables != nil && s.tables.modulus == modulus {
+		return s.tables.exp[255-int(s.tables.log[a])], nil
+	}
+
 	// Простой перебор для тестов
 	for i := 1; i < 256; i++ {
 		test := byte(i)
@@ -57,31 +111,81 @@ func (s *GF28Service) Inverse(a byte, modulus byte) (byte, error) {
 	return 0, fmt.Errorf("inverse not found for 0x%02x", a)
 }
 
+// Divide делит a на b в GF(2⁸) по заданному модулю: a * b⁻¹
+func (s *GF28Service) Divide(a, b byte, modulus byte) (byte, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("division by zero element")
+	}
+
+	inverse, err := s.Inverse(b, modulus)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Multiply(a, inverse, modulus)
+}
+
+// MultiplicativeOrder возвращает порядок элемента a по заданному модулю:
+// наименьшее k > 0, при котором a^k = 1. Для неприводимого модуля порядок делит 255,
+// элемент порядка 255 примитивен.
+func (s *GF28Service) MultiplicativeOrder(a byte, modulus byte) (int, error) {
+	if a == 0 {
+		return 0, fmt.Errorf("zero element has no multiplicative order")
+	}
+
+	element := a
+	for k := 1; k <= 255; k++ {
+		if element == 1 {
+			return k, nil
+		}
+		element, _ = s.Multiply(element, a, modulus)
+	}
+	return 0, fmt.Errorf("0x%02x is not invertible modulo 0x%02x", a, modulus)
+}
+
+// IsIrreducible проверяет неприводимость полинома x⁸ + poly над GF(2)
 func (s *GF28Service) IsIrreducible(poly byte) bool {
-    // Простая проверка по списку для тестирования
-    irreduciblePolys := []byte{
-        0x1B, 0x1D, 0x2B, 0x4D, 0x5F, 0x63, 0x65, 0x69, 0x71, 0x87,
-        0x8D, 0x9B, 0x9D, 0xA9, 0xB1, 0xBF, 0xC7, 0xDD, 0xE7, 0xF5,
-        0xF9, 0xFD, 0x11, 0x13, 0x19, 0x25, 0x31, 0x37, 0x3D, 0x47,
-    }
-    
-    for _, p := range irreduciblePolys {
-        if poly == p {
-            return true
-        }
-    }
-    
-    return false
+	full := uint16(0x100) | uint16(poly)
+
+	// Достаточно проверить делители степени от 1 до 4
+	for divisor := uint16(0x02); divisor < 0x20; divisor++ {
+		if polyMod(full, divisor) == 0 {
+			return false
+		}
+	}
+
+	return true
 }
 
 // GetAllIrreduciblePolynomials возвращает все неприводимые полиномы степени 8
 func (s *GF28Service) GetAllIrreduciblePolynomials() []byte {
-	// Все 30 неприводимых полиномов степени 8 над GF(2)
-	return []byte{
-		0x1B, 0x1D, 0x2B, 0x4D, 0x5F, 0x63, 0x65, 0x69, 0x71, 0x87,
-		0x8D, 0x9B, 0x9D, 0xA9, 0xB1, 0xBF, 0xC7, 0xDD, 0xE7, 0xF5,
-		0xF9, 0xFD, 0x11, 0x13, 0x19, 0x25, 0x31, 0x37, 0x3D, 0x47,
+	// Полиномов x⁸ + ... ровно 30, возвращаем младшие 8 бит
+	polys := make([]byte, 0, 30)
+	for i := 0; i < 256; i++ {
+		if s.IsIrreducible(byte(i)) {
+			polys = append(polys, byte(i))
+		}
+	}
+	return polys
+}
+
+// polyDegree возвращает степень полинома над GF(2)
+func polyDegree(p uint16) int {
+	degree := -1
+	for p != 0 {
+		p >>= 1
+		degree++
+	}
+	return degree
+}
+
+// polyMod вычисляет остаток от деления полинома a на b над GF(2)
+func polyMod(a, b uint16) uint16 {
+	degB := polyDegree(b)
+	for degA := polyDegree(a); degA >= degB; degA = polyDegree(a) {
+		a ^= b << uint(degA-degB)
 	}
+	return a
 }
 
 // Factorize разлагает полином на неприводимые множители в GF(2ⁿ)
@@ -0,0 +1,87 @@
+package cripta
+
+import "fmt"
+
+type GOSTCipher struct {
+	feistel    *FeistelNetwork
+	currentKey []uint8
+}
+
+func NewGOSTCipher() (*GOSTCipher, error) {
+	feistel, err := NewFeistelNetwork(
+		&GOSTKeySchedule{},
+		&GOSTRoundFunction{},
+		8,
+		32,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GOSTCipher{
+		feistel: feistel,
+	}, nil
+}
+
+func (gost *GOSTCipher) SetKey(key []uint8) error {
+	if len(key) != 32 {
+		return fmt.Errorf("GOST key must be 32 bytes (256 bits)")
+	}
+
+	gost.currentKey = make([]uint8, len(key))
+	copy(gost.currentKey, key)
+
+	err := gost.feistel.SetKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to set key in feistel network: %w", err)
+	}
+
+	return nil
+}
+
+// В последнем раунде ГОСТ половины не меняются местами,
+// поэтому результат сети Фейстеля разворачивается обратно
+func (gost *GOSTCipher) swapHalves(block []uint8) []uint8 {
+	swapped := make([]uint8, 8)
+	copy(swapped, block[4:])
+	copy(swapped[4:], block[:4])
+	return swapped
+}
+
+func (gost *GOSTCipher) EncryptBlock(plainBlock []uint8) ([]uint8, error) {
+	if len(plainBlock) != 8 {
+		return nil, fmt.Errorf("GOST block must be 8 bytes (64 bits)")
+	}
+
+	feistelOutput, err := gost.feistel.EncryptBlock(plainBlock)
+	if err != nil {
+		return nil, fmt.Errorf("feistel encryption failed: %w", err)
+	}
+
+	return gost.swapHalves(feistelOutput), nil
+}
+
+func (gost *GOSTCipher) DecryptBlock(cipherBlock []uint8) ([]uint8, error) {
+	if len(cipherBlock) != 8 {
+		return nil, fmt.Errorf("GOST block must be 8 bytes (64 bits)")
+	}
+
+	plainBlock, err := gost.feistel.DecryptBlock(gost.swapHalves(cipherBlock))
+	if err != nil {
+		return nil, fmt.Errorf("feistel decryption failed: %w", err)
+	}
+
+	return plainBlock, nil
+}
+
+func (gost *GOSTCipher) Name() string {
+	return "GOST 28147-89"
+}
+
+func (gost *GOSTCipher) RequiredKeySize() int {
+	return 32
+}
+
+func (gost *GOSTCipher) GetBlockSize() int {
+	return 8
+}
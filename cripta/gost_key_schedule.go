@@ -0,0 +1,35 @@
+package cripta
+
+import (
+	"fmt"
+)
+
+type GOSTKeySchedule struct{}
+
+var GOST_KEY_ORDER = []int{
+	0, 1, 2, 3, 4, 5, 6, 7,
+	0, 1, 2, 3, 4, 5, 6, 7,
+	0, 1, 2, 3, 4, 5, 6, 7,
+	7, 6, 5, 4, 3, 2, 1, 0,
+}
+
+func (gks *GOSTKeySchedule) GenerateRoundKeys(masterKey []uint8) ([][]uint8, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("GOST key must be 32 bytes (256 bits)")
+	}
+
+	subkeys := make([][]uint8, 8)
+	for i := 0; i < 8; i++ {
+		subkeys[i] = make([]uint8, 4)
+		copy(subkeys[i], masterKey[i*4:(i+1)*4])
+	}
+
+	roundKeys := make([][]uint8, 0, len(GOST_KEY_ORDER))
+	for _, idx := range GOST_KEY_ORDER {
+		roundKey := make([]uint8, 4)
+		copy(roundKey, subkeys[idx])
+		roundKeys = append(roundKeys, roundKey)
+	}
+
+	return roundKeys, nil
+}
@@ -0,0 +1,49 @@
+package cripta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+type GOSTRoundFunction struct{}
+
+var GOST_S_BOXES = [8][16]uint8{
+	{12, 4, 6, 2, 10, 5, 11, 9, 14, 8, 13, 7, 0, 3, 15, 1},
+	{6, 8, 2, 3, 9, 10, 5, 12, 1, 14, 4, 7, 11, 13, 0, 15},
+	{11, 3, 5, 8, 2, 15, 10, 13, 14, 1, 7, 4, 12, 9, 6, 0},
+	{12, 8, 2, 1, 13, 4, 15, 6, 7, 0, 10, 5, 3, 14, 9, 11},
+	{7, 15, 5, 10, 8, 1, 6, 13, 0, 9, 3, 14, 11, 4, 2, 12},
+	{5, 13, 15, 6, 9, 2, 12, 10, 11, 7, 8, 1, 4, 3, 14, 0},
+	{8, 14, 2, 5, 6, 9, 1, 12, 15, 4, 11, 0, 13, 10, 3, 7},
+	{1, 7, 14, 13, 0, 5, 8, 3, 4, 15, 10, 6, 9, 12, 11, 2},
+}
+
+func (grf *GOSTRoundFunction) Apply(inputBlock []uint8, roundKey []uint8, round int) ([]uint8, error) {
+	if inputBlock == nil {
+		return nil, fmt.Errorf("input block cannot be nil")
+	}
+	if len(inputBlock) != 4 {
+		return nil, fmt.Errorf("input block must be 4 bytes (32 bits)")
+	}
+
+	if roundKey == nil {
+		return nil, fmt.Errorf("round key cannot be nil")
+	}
+	if len(roundKey) != 4 {
+		return nil, fmt.Errorf("round key must be 4 bytes (32 bits)")
+	}
+
+	value := binary.BigEndian.Uint32(inputBlock) + binary.BigEndian.Uint32(roundKey)
+
+	var substituted uint32
+	for i := 0; i < 8; i++ {
+		nibble := (value >> (4 * i)) & 0x0F
+		substituted |= uint32(GOST_S_BOXES[i][nibble]) << (4 * i)
+	}
+
+	result := make([]uint8, 4)
+	binary.BigEndian.PutUint32(result, bits.RotateLeft32(substituted, 11))
+
+	return result, nil
+}
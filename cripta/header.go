@@ -0,0 +1,233 @@
+package cripta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrWrongKey возвращается NewCipherContextFromHeader, если контрольное значение
+// ключа в заголовке не совпало с переданным ключом
+var ErrWrongKey = errors.New("wrong key: key check value does not match")
+
+// AlgorithmID идентификатор алгоритма в заголовке зашифрованных данных
+type AlgorithmID uint8
+
+const (
+	AlgorithmDES AlgorithmID = iota + 1
+	AlgorithmDEAL128
+	AlgorithmDEAL192
+	AlgorithmDEAL256
+	AlgorithmAES128
+	AlgorithmAES192
+	AlgorithmAES256
+)
+
+// headerMagic сигнатура заголовка. Версия 1 без контрольного значения ключа,
+// версия 2 содержит его сразу после IV.
+const (
+	headerMagic           = "OKLB"
+	headerVersion         = 1
+	headerVersionKeyCheck = 2
+)
+
+// KeyCheckSize длина контрольного значения ключа в заголовке
+const KeyCheckSize = 4
+
+// headerFixedSize magic(4) | version(1) | algorithm(1) | mode(1) | padding(1) | ivLen(1)
+const headerFixedSize = len(headerMagic) + 5
+
+// algorithmInfo описание алгоритма для восстановления шифра по заголовку
+type algorithmInfo struct {
+	name      string // имя в реестре шифров
+	keySize   int
+	blockSize int
+}
+
+var algorithmsByID = map[AlgorithmID]algorithmInfo{
+	AlgorithmDES:     {"des", 8, 8},
+	AlgorithmDEAL128: {"deal128", 16, 16},
+	AlgorithmDEAL192: {"deal192", 24, 16},
+	AlgorithmDEAL256: {"deal256", 32, 16},
+	AlgorithmAES128:  {"aes128", 16, 16},
+	AlgorithmAES192:  {"aes192", 24, 16},
+	AlgorithmAES256:  {"aes256", 32, 16},
+}
+
+// CipherHeader параметры шифрования, записанные перед шифртекстом
+type CipherHeader struct {
+	Algorithm AlgorithmID
+	Mode      CipherMode
+	Padding   PaddingMode
+	IV        []uint8
+	KeyCheck  []uint8 // KeyCheckValue(key) или nil, если заголовок без проверки ключа
+}
+
+// KeyCheckValue вычисляет контрольное значение ключа HMAC-SHA256(key, "keycheck")[:4].
+// Оно позволяет обнаружить неверный ключ по заголовку, не расшифровывая данные.
+func KeyCheckValue(key []uint8) []uint8 {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("keycheck"))
+	return mac.Sum(nil)[:KeyCheckSize]
+}
+
+// Marshal сериализует заголовок
+func (h *CipherHeader) Marshal() ([]uint8, error) {
+	if _, ok := algorithmsByID[h.Algorithm]; !ok {
+		return nil, fmt.Errorf("unknown algorithm id %d", h.Algorithm)
+	}
+	if len(h.IV) > 255 {
+		return nil, fmt.Errorf("IV is too long for header: %d bytes", len(h.IV))
+	}
+	version := uint8(headerVersion)
+	if h.KeyCheck != nil {
+		if len(h.KeyCheck) != KeyCheckSize {
+			return nil, fmt.Errorf("key check value must be %d bytes, got %d", KeyCheckSize, len(h.KeyCheck))
+		}
+		version = headerVersionKeyCheck
+	}
+
+	data := make([]uint8, 0, headerFixedSize+len(h.IV)+len(h.KeyCheck))
+	data = append(data, headerMagic...)
+	data = append(data, version, uint8(h.Algorithm), uint8(h.Mode), uint8(h.Padding), uint8(len(h.IV)))
+	data = append(data, h.IV...)
+	data = append(data, h.KeyCheck...)
+
+	return data, nil
+}
+
+// ParseHeader разбирает заголовок и возвращает его вместе с оставшимся шифртекстом
+func ParseHeader(data []uint8) (*CipherHeader, []uint8, error) {
+	if len(data) < headerFixedSize {
+		return nil, nil, fmt.Errorf("data is too short for header: %d bytes", len(data))
+	}
+	if string(data[:len(headerMagic)]) != headerMagic {
+		return nil, nil, fmt.Errorf("invalid header magic")
+	}
+
+	fields := data[len(headerMagic):headerFixedSize]
+	if fields[0] != headerVersion && fields[0] != headerVersionKeyCheck {
+		return nil, nil, fmt.Errorf("unsupported header version %d", fields[0])
+	}
+
+	header := &CipherHeader{
+		Algorithm: AlgorithmID(fields[1]),
+		Mode:      CipherMode(fields[2]),
+		Padding:   PaddingMode(fields[3]),
+	}
+	if _, ok := algorithmsByID[header.Algorithm]; !ok {
+		return nil, nil, fmt.Errorf("unknown algorithm id %d", header.Algorithm)
+	}
+
+	ivLen := int(fields[4])
+	if len(data) < headerFixedSize+ivLen {
+		return nil, nil, fmt.Errorf("header is truncated: IV needs %d bytes", ivLen)
+	}
+	header.IV = make([]uint8, ivLen)
+	copy(header.IV, data[headerFixedSize:headerFixedSize+ivLen])
+	offset := headerFixedSize + ivLen
+
+	if fields[0] == headerVersionKeyCheck {
+		if len(data) < offset+KeyCheckSize {
+			return nil, nil, fmt.Errorf("header is truncated: key check value needs %d bytes", KeyCheckSize)
+		}
+		header.KeyCheck = make([]uint8, KeyCheckSize)
+		copy(header.KeyCheck, data[offset:offset+KeyCheckSize])
+		offset += KeyCheckSize
+	}
+
+	return header, data[offset:], nil
+}
+
+// NewCipherContextFromHeader создает контекст по заголовку и возвращает оставшийся шифртекст.
+// Если заголовок содержит контрольное значение ключа, неверный ключ отклоняется
+// сразу с ErrWrongKey.
+func NewCipherContextFromHeader(header []uint8, key []uint8) (*CipherContext, []uint8, error) {
+	parsed, rest, err := ParseHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := algorithmsByID[parsed.Algorithm]
+	if len(key) != info.keySize {
+		return nil, nil, fmt.Errorf("key size must be %d bytes for algorithm id %d, got %d",
+			info.keySize, parsed.Algorithm, len(key))
+	}
+	if parsed.KeyCheck != nil && !hmac.Equal(parsed.KeyCheck, KeyCheckValue(key)) {
+		return nil, nil, ErrWrongKey
+	}
+
+	cipher, _, err := NewCipherByName(info.name, info.keySize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	ctx, err := NewCipherContext(cipher, key, parsed.Mode, parsed.Padding, parsed.IV, info.blockSize, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, rest, nil
+}
+
+// EncryptWithHeader шифрует данные и добавляет перед ними заголовок
+// с контрольным значением ключа. При включенном AutoIV новый IV сообщения
+// хранится в заголовке, и шифртекст после заголовка IV не содержит.
+func (ctx *CipherContext) EncryptWithHeader(algorithm AlgorithmID, plaintext []uint8) ([]uint8, error) {
+	info, ok := algorithmsByID[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm id %d", algorithm)
+	}
+	if info.blockSize != ctx.blockSize {
+		return nil, fmt.Errorf("algorithm id %d uses %d-byte blocks, context uses %d",
+			algorithm, info.blockSize, ctx.blockSize)
+	}
+
+	// С AutoIV IV нового сообщения записывается в заголовок, а не перед шифртекстом:
+	// NewCipherContextFromHeader создает контекст без AutoIV с IV из заголовка
+	iv, ciphertext := ctx.iv, []uint8(nil)
+	var err error
+	if ctx.usesAutoIV() {
+		ciphertext, iv, err = ctx.EncryptDetached(plaintext)
+	} else {
+		ciphertext, err = ctx.Encrypt(plaintext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := &CipherHeader{
+		Algorithm: algorithm,
+		Mode:      ctx.mode,
+		Padding:   ctx.effectivePaddingMode(),
+		IV:        iv,
+		KeyCheck:  KeyCheckValue(ctx.key),
+	}
+	data, err := header.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, ciphertext...), nil
+}
+
+// EncryptFileWithHeader шифрует файл, записывая заголовок в начало выходного файла
+func (ctx *CipherContext) EncryptFileWithHeader(algorithm AlgorithmID, inputPath, outputPath string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	encrypted, err := ctx.EncryptWithHeader(algorithm, data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, encrypted, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
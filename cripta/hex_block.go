@@ -0,0 +1,54 @@
+package cripta
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EncryptHexBlock устанавливает ключ keyHex и шифрует один блок blockHex, возвращая
+// шифртекст в hex. Предназначена для быстрой проверки тестовых векторов; пробелы
+// в hex-строках игнорируются, регистр не важен.
+func EncryptHexBlock(cipher ISymmetricCipher, keyHex, blockHex string) (string, error) {
+	return processHexBlock(cipher, keyHex, blockHex, cipher.EncryptBlock)
+}
+
+// DecryptHexBlock то же, что EncryptHexBlock, но расшифровывает блок
+func DecryptHexBlock(cipher ISymmetricCipher, keyHex, blockHex string) (string, error) {
+	return processHexBlock(cipher, keyHex, blockHex, cipher.DecryptBlock)
+}
+
+// processHexBlock разбирает ключ и блок, проверяет длину блока и применяет process
+func processHexBlock(cipher ISymmetricCipher, keyHex, blockHex string, process func([]uint8) ([]uint8, error)) (string, error) {
+	key, err := decodeHexField(keyHex, "key")
+	if err != nil {
+		return "", err
+	}
+	block, err := decodeHexField(blockHex, "block")
+	if err != nil {
+		return "", err
+	}
+
+	if sized, ok := cipher.(IBlockSizeProvider); ok && len(block) != sized.GetBlockSize() {
+		return "", fmt.Errorf("block must be %d bytes, got %d", sized.GetBlockSize(), len(block))
+	}
+
+	if err := cipher.SetKey(key); err != nil {
+		return "", fmt.Errorf("failed to set key: %w", err)
+	}
+
+	result, err := process(block)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(result), nil
+}
+
+// decodeHexField декодирует hex-строку, допуская пробелы между байтами
+func decodeHexField(value, field string) ([]uint8, error) {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s hex: %w", field, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,38 @@
+package cripta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// HKDF выводит length байтов ключевого материала из секрета по RFC 5869
+// (HMAC-SHA256, извлечение и расширение). Пустая соль заменяется нулевым блоком
+// длины хэша. При length вне диапазона 0..255*32 возвращает nil.
+func HKDF(secret, salt, info []uint8, length int) []uint8 {
+	if length < 0 || length > 255*sha256.Size {
+		return nil
+	}
+
+	if len(salt) == 0 {
+		salt = make([]uint8, sha256.Size)
+	}
+
+	// Извлечение: PRK = HMAC(salt, secret)
+	extractor := hmac.New(sha256.New, salt)
+	extractor.Write(secret)
+	prk := extractor.Sum(nil)
+
+	// Расширение: T(i) = HMAC(PRK, T(i-1) || info || i)
+	okm := make([]uint8, 0, length+sha256.Size)
+	var previous []uint8
+	for counter := uint8(1); len(okm) < length; counter++ {
+		expander := hmac.New(sha256.New, prk)
+		expander.Write(previous)
+		expander.Write(info)
+		expander.Write([]uint8{counter})
+		previous = expander.Sum(nil)
+		okm = append(okm, previous...)
+	}
+
+	return okm[:length]
+}
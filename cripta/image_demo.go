@@ -0,0 +1,61 @@
+package cripta
+
+import (
+	"fmt"
+)
+
+// EncryptImageECB шифрует сырые пиксели изображения AES-128 в режиме ECB на случайном
+// ключе для демонстрации утечки структуры («ECB-пингвин»): одинаковые блоки пикселей
+// дают одинаковые блоки шифртекста, и контуры изображения остаются видны.
+// Результат имеет ту же длину, что и pixels, и может быть сохранен как изображение
+// того же размера; последний неполный блок дополняется нулями, а лишние байты
+// шифртекста отбрасываются, поэтому результат не предназначен для дешифрования.
+func EncryptImageECB(pixels []byte, width, height int) ([]byte, error) {
+	return encryptImage(pixels, width, height, CipherModeECB)
+}
+
+// EncryptImageCBC то же, что EncryptImageECB, но в режиме CBC со случайным IV:
+// сцепление блоков скрывает повторы, и результат выглядит как шум
+func EncryptImageCBC(pixels []byte, width, height int) ([]byte, error) {
+	return encryptImage(pixels, width, height, CipherModeCBC)
+}
+
+// encryptImage шифрует пиксели в заданном режиме, сохраняя длину данных
+func encryptImage(pixels []byte, width, height int, mode CipherMode) ([]byte, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("image dimensions must be positive, got %dx%d", width, height)
+	}
+	if len(pixels) == 0 || len(pixels)%(width*height) != 0 {
+		return nil, fmt.Errorf("pixel buffer of %d bytes does not match %dx%d image", len(pixels), width, height)
+	}
+
+	const blockSize, keySize = 16, 16
+
+	key := make([]uint8, keySize)
+	if _, err := GenerateRandomBytes(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	var iv []uint8
+	if mode.RequiresIV() {
+		iv = make([]uint8, blockSize)
+		if _, err := GenerateRandomBytes(iv); err != nil {
+			return nil, fmt.Errorf("failed to generate IV: %w", err)
+		}
+	}
+
+	cipher, err := NewRijndaelCipher(blockSize, keySize, 0x1B)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := NewCipherContext(cipher, key, mode, PaddingModeZeros, iv, blockSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := ctx.Encrypt(pixels)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypted[:len(pixels)], nil
+}
@@ -4,12 +4,39 @@ type IKeySchedule interface {
 	GenerateRoundKeys(masterKey []uint8) ([][]uint8, error)
 }
 
+// IRoundFunction функция раунда. Номер раунда round (с нуля) позволяет функции
+// использовать подготовленное для раунда состояние; функции без такого состояния
+// его игнорируют.
 type IRoundFunction interface {
-	Apply(inputBlock []uint8, roundKey []uint8) ([]uint8, error)
+	Apply(inputBlock []uint8, roundKey []uint8, round int) ([]uint8, error)
+}
+
+// IPreparedRoundFunction реализуют функции раунда, которые один раз подготавливают
+// раундовые ключи при установке ключа сети Фейстеля (например, DEAL создает по
+// экземпляру DES на раунд) и затем находят их в Apply по номеру раунда
+type IPreparedRoundFunction interface {
+	IRoundFunction
+	PrepareRoundKeys(roundKeys [][]uint8) error
 }
 
 type ISymmetricCipher interface {
+	// Name возвращает идентификатор алгоритма с параметрами, например "DES", "DEAL-256", "Rijndael-128/128"
+	Name() string
 	SetKey(key []uint8) error
 	EncryptBlock(plainBlock []uint8) ([]uint8, error)
 	DecryptBlock(cipherBlock []uint8) ([]uint8, error)
-}
\ No newline at end of file
+}
+
+type IKeySizeProvider interface {
+	RequiredKeySize() int
+}
+
+type IBlockSizeProvider interface {
+	GetBlockSize() int
+}
+
+// RoundConfigurable реализуют шифры, допускающие изменение числа раундов
+// (например, для экспериментов с ослабленными DES и DEAL)
+type RoundConfigurable interface {
+	SetRounds(n int) error
+}
@@ -0,0 +1,47 @@
+package cripta
+
+import (
+	"crypto/sha256"
+	"errors"
+)
+
+// ErrIVReuse возвращается при включенном обнаружении повтора IV, если сообщение
+// шифруется на IV, который этот контекст уже использовал
+var ErrIVReuse = errors.New("IV reuse detected: this IV was already used for encryption")
+
+// SetIVReuseDetection включает запоминание IV зашифрованных сообщений: повторное
+// шифрование на том же IV возвращает ErrIVReuse. Хранятся SHA-256 от IV, поэтому
+// запись занимает фиксированный объем независимо от размера блока. Выключение
+// очищает историю. Для режимов без IV проверка не выполняется.
+func (ctx *CipherContext) SetIVReuseDetection(enabled bool) {
+	if !enabled {
+		ctx.ivHistory = nil
+		return
+	}
+	if ctx.ivHistory == nil {
+		ctx.ivHistory = make(map[[sha256.Size]uint8]struct{})
+	}
+}
+
+// ClearIVHistory забывает все использованные IV, не выключая обнаружение повторов.
+// Нужна долгоживущим контекстам, чтобы история не росла неограниченно.
+func (ctx *CipherContext) ClearIVHistory() {
+	if ctx.ivHistory != nil {
+		ctx.ivHistory = make(map[[sha256.Size]uint8]struct{})
+	}
+}
+
+// recordIV запоминает IV очередного сообщения и сообщает о повторе
+func (ctx *CipherContext) recordIV(iv []uint8) error {
+	if ctx.ivHistory == nil || !ctx.mode.RequiresIV() {
+		return nil
+	}
+
+	digest := sha256.Sum256(iv)
+	if _, seen := ctx.ivHistory[digest]; seen {
+		return ErrIVReuse
+	}
+	ctx.ivHistory[digest] = struct{}{}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package cripta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// IVSource источник векторов инициализации для режима AutoIV
+type IVSource interface {
+	NextIV(blockSize int) ([]uint8, error)
+}
+
+// RandomIVSource выдает случайные IV из RandReader
+type RandomIVSource struct{}
+
+func (RandomIVSource) NextIV(blockSize int) ([]uint8, error) {
+	iv := make([]uint8, blockSize)
+	if _, err := io.ReadFull(RandReader, iv); err != nil {
+		return nil, fmt.Errorf("failed to generate random IV: %w", err)
+	}
+	return iv, nil
+}
+
+// counterIVSize длина номера сообщения в IV от CounterIVSource
+const counterIVSize = 8
+
+// CounterIVSource выдает монотонно возрастающие IV для схем, где IV должен быть
+// уникальным, но не обязательно случайным. Номер сообщения записывается big-endian
+// в старшие 8 байт IV, остальные байты нулевые: в режиме CTR их увеличивает счетчик
+// блоков, и потоки ключа соседних сообщений не пересекаются. Для 8-байтовых блоков
+// места под счетчик блоков не остается, поэтому в CTR такой источник отклоняется.
+type CounterIVSource struct {
+	mutex     sync.Mutex
+	next      uint64
+	exhausted bool
+}
+
+// NewCounterIVSource создает счетчик, начинающийся с заданного значения
+func NewCounterIVSource(start uint64) *CounterIVSource {
+	return &CounterIVSource{next: start}
+}
+
+func (source *CounterIVSource) NextIV(blockSize int) ([]uint8, error) {
+	if blockSize < counterIVSize {
+		return nil, fmt.Errorf("counter IV requires at least %d-byte blocks, got %d", counterIVSize, blockSize)
+	}
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	// После переполнения счетчика IV начали бы повторяться
+	if source.exhausted {
+		return nil, fmt.Errorf("counter IV source is exhausted")
+	}
+
+	iv := make([]uint8, blockSize)
+	binary.BigEndian.PutUint64(iv[:counterIVSize], source.next)
+
+	source.next++
+	if source.next == 0 {
+		source.exhausted = true
+	}
+
+	return iv, nil
+}
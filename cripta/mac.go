@@ -0,0 +1,163 @@
+package cripta
+
+import (
+	"fmt"
+	"io"
+)
+
+// MACWriter потоковое вычисление MAC: данные подаются через Write порциями
+// произвольного размера, Sum возвращает MAC всех записанных к этому моменту данных
+type MACWriter interface {
+	io.Writer
+	Sum() []uint8
+}
+
+// macWriter общая реализация CBC-MAC и CMAC. Последний (возможно неполный) блок
+// держится в буфере, так как в обоих алгоритмах он обрабатывается особо.
+type macWriter struct {
+	cipher    ISymmetricCipher
+	blockSize int
+	state     []uint8 // текущее значение цепочки CBC
+	buffer    []uint8 // необработанный хвост, не более blockSize байт
+	cmac      bool
+	k1, k2    []uint8 // подключи CMAC
+}
+
+// NewCBCMACWriter создает потоковый CBC-MAC (ISO/IEC 9797-1, метод набивки 1:
+// последний блок дополняется нулями). Безопасен только для сообщений фиксированной длины.
+func NewCBCMACWriter(cipher ISymmetricCipher, blockSize int) (MACWriter, error) {
+	return newMACWriter(cipher, blockSize, false)
+}
+
+// NewCMACWriter создает потоковый CMAC (NIST SP 800-38B, RFC 4493) для блоков 8 и 16 байт
+func NewCMACWriter(cipher ISymmetricCipher, blockSize int) (MACWriter, error) {
+	return newMACWriter(cipher, blockSize, true)
+}
+
+// CBCMAC вычисляет CBC-MAC сообщения целиком
+func CBCMAC(cipher ISymmetricCipher, blockSize int, data []uint8) ([]uint8, error) {
+	return computeMAC(cipher, blockSize, data, false)
+}
+
+// CMAC вычисляет CMAC сообщения целиком
+func CMAC(cipher ISymmetricCipher, blockSize int, data []uint8) ([]uint8, error) {
+	return computeMAC(cipher, blockSize, data, true)
+}
+
+func computeMAC(cipher ISymmetricCipher, blockSize int, data []uint8, cmac bool) ([]uint8, error) {
+	writer, err := newMACWriter(cipher, blockSize, cmac)
+	if err != nil {
+		return nil, err
+	}
+	writer.Write(data)
+	return writer.Sum(), nil
+}
+
+func newMACWriter(cipher ISymmetricCipher, blockSize int, cmac bool) (*macWriter, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("cipher implementation cannot be nil")
+	}
+	if blockSize != 8 && blockSize != 16 {
+		return nil, fmt.Errorf("MAC supports 8 or 16-byte blocks, got %d", blockSize)
+	}
+
+	// Шифрование нулевого блока заодно проверяет, что ключ установлен
+	zero, err := cipher.EncryptBlock(make([]uint8, blockSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt block: %w", err)
+	}
+
+	writer := &macWriter{
+		cipher:    cipher,
+		blockSize: blockSize,
+		state:     make([]uint8, blockSize),
+		buffer:    make([]uint8, 0, blockSize),
+		cmac:      cmac,
+	}
+	if cmac {
+		writer.k1 = cmacSubkey(zero)
+		writer.k2 = cmacSubkey(writer.k1)
+	}
+
+	return writer, nil
+}
+
+// Write добавляет данные; полный блок обрабатывается только тогда, когда за ним
+// приходят новые данные, иначе он может оказаться последним
+func (w *macWriter) Write(data []uint8) (int, error) {
+	written := len(data)
+
+	for len(data) > 0 {
+		if len(w.buffer) == w.blockSize {
+			if err := w.chain(w.state, w.buffer); err != nil {
+				return written - len(data), err
+			}
+			w.buffer = w.buffer[:0]
+		}
+
+		take := min(w.blockSize-len(w.buffer), len(data))
+		w.buffer = append(w.buffer, data[:take]...)
+		data = data[take:]
+	}
+
+	return written, nil
+}
+
+// Sum возвращает MAC записанных данных, не изменяя состояние: запись можно продолжить
+func (w *macWriter) Sum() []uint8 {
+	last := make([]uint8, w.blockSize)
+	copy(last, w.buffer)
+
+	if w.cmac {
+		subkey := w.k1
+		if len(w.buffer) < w.blockSize {
+			last[len(w.buffer)] = 0x80
+			subkey = w.k2
+		}
+		for i := range last {
+			last[i] ^= subkey[i]
+		}
+	}
+
+	state := make([]uint8, w.blockSize)
+	copy(state, w.state)
+	if err := w.chain(state, last); err != nil {
+		return nil
+	}
+
+	return state
+}
+
+// chain выполняет шаг CBC: state = E(state xor block)
+func (w *macWriter) chain(state, block []uint8) error {
+	for i := range state {
+		state[i] ^= block[i]
+	}
+
+	encrypted, err := w.cipher.EncryptBlock(state)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt block: %w", err)
+	}
+
+	copy(state, encrypted)
+	return nil
+}
+
+// cmacSubkey сдвигает блок на бит влево и при переносе добавляет константу Rb
+func cmacSubkey(block []uint8) []uint8 {
+	rb := uint8(0x87)
+	if len(block) == 8 {
+		rb = 0x1B
+	}
+
+	subkey := make([]uint8, len(block))
+	for i := 0; i < len(block)-1; i++ {
+		subkey[i] = block[i]<<1 | block[i+1]>>7
+	}
+	subkey[len(block)-1] = block[len(block)-1] << 1
+
+	if block[0]&0x80 != 0 {
+		subkey[len(block)-1] ^= rb
+	}
+	return subkey
+}
@@ -53,6 +53,7 @@ func BigJacobiSymbol(a, n *big.Int) *big.Int {
 			temp.Rsh(temp, 1)
 			trailingZeros++
 		}
+		a = temp
 		
 		if trailingZeros%2 == 1 {
 			nMod8 := new(big.Int).And(n, big.NewInt(7))
@@ -178,12 +179,93 @@ func ModularInverse(a, m int64) (int64, bool) {
 	return result, true
 }
 
+// EulerTotient вычисляет функцию Эйлера φ(n) разложением n пробным делением.
+// Подходит для небольших учебных модулей; для n <= 0 возвращает 0.
+func EulerTotient(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+
+	result := n
+	for p := int64(2); p*p <= n; p++ {
+		if n%p != 0 {
+			continue
+		}
+		for n%p == 0 {
+			n /= p
+		}
+		result -= result / p
+	}
+	if n > 1 {
+		result -= result / n
+	}
+
+	return result
+}
+
+// BigEulerTotientFromFactors вычисляет φ(n) = (p-1)*(q-1) для n = p*q
+// с различными простыми p и q
+func BigEulerTotientFromFactors(p, q *big.Int) *big.Int {
+	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
+	qMinus1 := new(big.Int).Sub(q, big.NewInt(1))
+	return pMinus1.Mul(pMinus1, qMinus1)
+}
+
 // BigModExp вычисляет a^b mod m для big.Int
 func BigModExp(a, b, m *big.Int) *big.Int {
 	result := new(big.Int).Exp(a, b, m)
 	return result
 }
 
+// MaxFixedWindowBits наибольшая ширина окна FixedWindowModExp (таблица из 256 степеней)
+const MaxFixedWindowBits = 8
+
+// FixedWindowModExp вычисляет base^exp mod mod методом фиксированного окна.
+// Число возведений в квадрат и умножений зависит только от длины модуля
+// и ширины окна, но не от значений битов показателя: умножение выполняется
+// для каждого окна, в том числе нулевого. Возвращает nil при mod <= 0, exp < 0
+// или ширине окна вне диапазона 1..MaxFixedWindowBits.
+func FixedWindowModExp(base, exp, mod *big.Int, windowBits int) *big.Int {
+	if mod.Sign() <= 0 || exp.Sign() < 0 {
+		return nil
+	}
+	if windowBits <= 0 || windowBits > MaxFixedWindowBits {
+		return nil
+	}
+
+	// Таблица base^0 .. base^(2^w - 1)
+	table := make([]*big.Int, 1<<windowBits)
+	table[0] = new(big.Int).Mod(big.NewInt(1), mod)
+	reduced := new(big.Int).Mod(base, mod)
+	for i := 1; i < len(table); i++ {
+		table[i] = new(big.Int).Mul(table[i-1], reduced)
+		table[i].Mod(table[i], mod)
+	}
+
+	bits := mod.BitLen()
+	if exp.BitLen() > bits {
+		bits = exp.BitLen()
+	}
+	windows := (bits + windowBits - 1) / windowBits
+
+	result := new(big.Int).Set(table[0])
+	for w := windows - 1; w >= 0; w-- {
+		for i := 0; i < windowBits; i++ {
+			result.Mul(result, result)
+			result.Mod(result, mod)
+		}
+
+		index := 0
+		for i := windowBits - 1; i >= 0; i-- {
+			index = index<<1 | int(exp.Bit(w*windowBits+i))
+		}
+		result.Mul(result, table[index])
+		result.Mod(result, mod)
+	}
+
+	return result
+}
+
 // BigGCD вычисляет НОД для big.Int
 func BigGCD(a, b *big.Int) *big.Int {
 	result := new(big.Int).GCD(nil, nil, a, b)
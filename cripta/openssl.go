@@ -0,0 +1,70 @@
+package cripta
+
+import (
+	"crypto/md5"
+	"fmt"
+)
+
+// opensslMagic сигнатура файлов openssl enc с солью
+const opensslMagic = "Salted__"
+
+// DecryptOpenSSL расшифровывает данные, полученные командой
+// openssl enc -aes-128-cbc -md md5 -pass pass:<password>.
+// Ключ и IV выводятся из пароля и соли функцией EVP_BytesToKey (MD5, одна итерация).
+func DecryptOpenSSL(data []uint8, password string) ([]uint8, error) {
+	const keySize, blockSize, saltSize = 16, 16, 8
+
+	headerSize := len(opensslMagic) + saltSize
+	if len(data) < headerSize || string(data[:len(opensslMagic)]) != opensslMagic {
+		return nil, fmt.Errorf("data is not in OpenSSL salted format")
+	}
+	salt := data[len(opensslMagic):headerSize]
+	ciphertext := data[headerSize:]
+
+	if len(ciphertext) == 0 || len(ciphertext)%blockSize != 0 {
+		return nil, fmt.Errorf("ciphertext length %d is not a positive multiple of %d", len(ciphertext), blockSize)
+	}
+
+	derived := evpBytesToKey([]uint8(password), salt, keySize+blockSize)
+	key, iv := derived[:keySize], derived[keySize:]
+
+	cipher, err := NewRijndaelCipher(blockSize, keySize, 0x1B)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := NewCipherContext(cipher, key, CipherModeCBC, PaddingModePKCS7, iv, blockSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := ctx.Decrypt(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	// При корректной набивке PKCS7 всегда снимается хотя бы один байт,
+	// иначе пароль неверен или данные повреждены
+	if len(plaintext) == len(ciphertext) {
+		return nil, fmt.Errorf("bad decrypt: wrong password or corrupted data")
+	}
+
+	return plaintext, nil
+}
+
+// evpBytesToKey реализует EVP_BytesToKey: D_i = MD5(D_{i-1} || password || salt)
+func evpBytesToKey(password, salt []uint8, length int) []uint8 {
+	derived := make([]uint8, 0, length+md5.Size)
+	var prev []uint8
+
+	for len(derived) < length {
+		h := md5.New()
+		h.Write(prev)
+		h.Write(password)
+		h.Write(salt)
+		prev = h.Sum(nil)
+		derived = append(derived, prev...)
+	}
+
+	return derived[:length]
+}
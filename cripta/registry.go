@@ -0,0 +1,144 @@
+package cripta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CipherFactory создает шифр для заданной длины ключа и возвращает фактическую длину ключа.
+// Нулевая длина означает длину по умолчанию для алгоритма.
+type CipherFactory func(keyLen int) (ISymmetricCipher, int, error)
+
+var (
+	registryMutex   sync.RWMutex
+	cipherFactories = make(map[string]CipherFactory)
+)
+
+func init() {
+	RegisterCipher("des", func(keyLen int) (ISymmetricCipher, int, error) {
+		if keyLen != 0 && keyLen != 8 {
+			return nil, 0, fmt.Errorf("DES key must be 8 bytes, got %d", keyLen)
+		}
+		cipher, err := NewDESCipher()
+		return cipher, 8, err
+	})
+	RegisterCipher("gost", func(keyLen int) (ISymmetricCipher, int, error) {
+		if keyLen != 0 && keyLen != 32 {
+			return nil, 0, fmt.Errorf("GOST key must be 32 bytes, got %d", keyLen)
+		}
+		cipher, err := NewGOSTCipher()
+		return cipher, 32, err
+	})
+	RegisterCipher("deal", newDEALFactory(0))
+	RegisterCipher("deal128", newDEALFactory(16))
+	RegisterCipher("deal192", newDEALFactory(24))
+	RegisterCipher("deal256", newDEALFactory(32))
+	RegisterCipher("rijndael", newRijndaelFactory(0))
+	RegisterCipher("aes128", newRijndaelFactory(16))
+	RegisterCipher("aes192", newRijndaelFactory(24))
+	RegisterCipher("aes256", newRijndaelFactory(32))
+}
+
+// newDEALFactory возвращает фабрику DEAL; fixedKeyLen != 0 фиксирует длину ключа
+func newDEALFactory(fixedKeyLen int) CipherFactory {
+	return func(keyLen int) (ISymmetricCipher, int, error) {
+		keyLen, err := resolveKeyLen(keyLen, fixedKeyLen, 16)
+		if err != nil {
+			return nil, 0, err
+		}
+		cipher, err := NewDEALCipher(keyLen)
+		return cipher, keyLen, err
+	}
+}
+
+// newRijndaelFactory возвращает фабрику Rijndael со 128-битным блоком и модулем AES
+func newRijndaelFactory(fixedKeyLen int) CipherFactory {
+	return func(keyLen int) (ISymmetricCipher, int, error) {
+		keyLen, err := resolveKeyLen(keyLen, fixedKeyLen, 16)
+		if err != nil {
+			return nil, 0, err
+		}
+		cipher, err := NewRijndaelCipher(16, keyLen, 0x1B)
+		return cipher, keyLen, err
+	}
+}
+
+// resolveKeyLen выбирает длину ключа с учетом фиксированной и длины по умолчанию
+func resolveKeyLen(keyLen, fixedKeyLen, defaultKeyLen int) (int, error) {
+	if fixedKeyLen != 0 {
+		if keyLen != 0 && keyLen != fixedKeyLen {
+			return 0, fmt.Errorf("key must be %d bytes, got %d", fixedKeyLen, keyLen)
+		}
+		return fixedKeyLen, nil
+	}
+	if keyLen == 0 {
+		return defaultKeyLen, nil
+	}
+	return keyLen, nil
+}
+
+// RegisterCipher регистрирует фабрику шифра под заданным именем
+func RegisterCipher(name string, factory CipherFactory) error {
+	if name == "" {
+		return fmt.Errorf("cipher name cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("cipher factory cannot be nil")
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	key := strings.ToLower(name)
+	if _, exists := cipherFactories[key]; exists {
+		return fmt.Errorf("cipher %q is already registered", name)
+	}
+	cipherFactories[key] = factory
+
+	return nil
+}
+
+// UnregisterCipher удаляет шифр из реестра и сообщает, был ли он зарегистрирован.
+// Нужна прежде всего тестам, регистрирующим временные шифры.
+func UnregisterCipher(name string) bool {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	key := strings.ToLower(name)
+	if _, exists := cipherFactories[key]; !exists {
+		return false
+	}
+	delete(cipherFactories, key)
+
+	return true
+}
+
+// NewCipherByName создает зарегистрированный шифр по имени
+func NewCipherByName(name string, keyLen int) (ISymmetricCipher, int, error) {
+	registryMutex.RLock()
+	factory, ok := cipherFactories[strings.ToLower(name)]
+	registryMutex.RUnlock()
+
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown algorithm: %s", name)
+	}
+
+	return factory(keyLen)
+}
+
+// SupportedAlgorithms возвращает отсортированные имена всех зарегистрированных шифров,
+// включая добавленные через RegisterCipher
+func SupportedAlgorithms() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(cipherFactories))
+	for name := range cipherFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
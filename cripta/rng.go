@@ -0,0 +1,70 @@
+package cripta
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// cipherRNG детерминированный генератор: блочный шифр, зашифровывающий счетчик
+type cipherRNG struct {
+	cipher  ISymmetricCipher
+	counter []uint8
+	buffer  []uint8
+}
+
+// NewCipherRNG создает генератор псевдослучайных байтов на основе блочного шифра в режиме CTR.
+// Ключ шифра выводится из seed, поэтому одинаковые seed дают одинаковые потоки.
+// Переданный шифр используется генератором и не должен применяться параллельно.
+func NewCipherRNG(cipher ISymmetricCipher, seed []uint8) (io.Reader, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("cipher implementation cannot be nil")
+	}
+	if len(seed) == 0 {
+		return nil, fmt.Errorf("seed cannot be empty")
+	}
+
+	sized, ok := cipher.(IBlockSizeProvider)
+	if !ok {
+		return nil, fmt.Errorf("cipher must report its block size")
+	}
+
+	key := seed
+	if keySized, ok := cipher.(IKeySizeProvider); ok {
+		digest := sha256.Sum256(seed)
+		if keySized.RequiredKeySize() > len(digest) {
+			return nil, fmt.Errorf("key size %d is too large for seed derivation", keySized.RequiredKeySize())
+		}
+		key = digest[:keySized.RequiredKeySize()]
+	}
+
+	if err := cipher.SetKey(key); err != nil {
+		return nil, fmt.Errorf("failed to set key: %w", err)
+	}
+
+	return &cipherRNG{
+		cipher:  cipher,
+		counter: make([]uint8, sized.GetBlockSize()),
+	}, nil
+}
+
+// Read заполняет p очередными байтами потока
+func (rng *cipherRNG) Read(p []uint8) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(rng.buffer) == 0 {
+			block, err := rng.cipher.EncryptBlock(rng.counter)
+			if err != nil {
+				return n, fmt.Errorf("counter encryption failed: %w", err)
+			}
+			rng.buffer = block
+			incrementCounter(rng.counter)
+		}
+
+		copied := copy(p[n:], rng.buffer)
+		rng.buffer = rng.buffer[copied:]
+		n += copied
+	}
+
+	return n, nil
+}
@@ -2,8 +2,12 @@ package cripta
 
 import (
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
+	"sync"
 )
 
 // RSATestType перечисление для типа теста простоты
@@ -30,6 +34,7 @@ type RSAPublicKey struct {
 // RSAPrivateKey закрытый ключ RSA
 type RSAPrivateKey struct {
 	N *big.Int // модуль
+	E *big.Int // открытая экспонента (нужна для проверки ключа)
 	D *big.Int // закрытая экспонента
 	P *big.Int // простое число p
 	Q *big.Int // простое число q
@@ -73,13 +78,14 @@ func (gen *RSAKeyGenerator) GenerateKeyPair() (*RSAKey, error) {
 		primalityTest = NewMillerRabinTest()
 	}
 	
-	// Генерируем простые числа p и q
-	p, err := gen.generatePrime(primalityTest)
+	// Генерируем простые числа p и q; при нечетной длине p на бит длиннее,
+	// чтобы n = p * q имело ровно bitLength бит
+	p, err := gen.generatePrime(primalityTest, (gen.bitLength+1)/2)
 	if err != nil {
 		return nil, err
 	}
 	
-	q, err := gen.generatePrime(primalityTest)
+	q, err := gen.generatePrime(primalityTest, gen.bitLength/2)
 	if err != nil {
 		return nil, err
 	}
@@ -108,9 +114,9 @@ func (gen *RSAKeyGenerator) GenerateKeyPair() (*RSAKey, error) {
 	}
 	
 	// Вычисляем закрытую экспоненту d = e^(-1) mod φ(n)
-	d := new(big.Int).ModInverse(e, phi)
-	if d == nil {
-		return nil, errors.New("не удалось вычислить обратный элемент для e")
+	d, err := privateExponent(e, phi)
+	if err != nil {
+		return nil, err
 	}
 	
 	// Проверяем на атаку Винера (d не должно быть слишком маленьким)
@@ -125,6 +131,7 @@ func (gen *RSAKeyGenerator) GenerateKeyPair() (*RSAKey, error) {
 		},
 		PrivateKey: RSAPrivateKey{
 			N: n,
+			E: e,
 			D: d,
 			P: p,
 			Q: q,
@@ -132,17 +139,82 @@ func (gen *RSAKeyGenerator) GenerateKeyPair() (*RSAKey, error) {
 	}, nil
 }
 
-// generatePrime генерирует простое число заданной длины
-func (gen *RSAKeyGenerator) generatePrime(test PrimalityTest) (*big.Int, error) {
+// privateExponent вычисляет d = e^(-1) mod φ(n); если e и φ(n) не взаимно просты,
+// обратного элемента нет, и возвращается ошибка вместо nil
+func privateExponent(e, phi *big.Int) (*big.Int, error) {
+	d, ok := BigModularInverse(e, phi)
+	if !ok {
+		return nil, fmt.Errorf("e = %v не обратимо по модулю φ(n): НОД(e, φ(n)) = %v", e, BigGCD(e, phi))
+	}
+	return d, nil
+}
+
+// NewRSAPrivateKey собирает закрытый ключ из простых p, q и открытой экспоненты e,
+// вычисляя d = e^(-1) mod φ(n). Возвращает ошибку, если e не обратимо по модулю φ(n).
+func NewRSAPrivateKey(p, q, e *big.Int) (*RSAPrivateKey, error) {
+	if p == nil || q == nil || e == nil {
+		return nil, errors.New("p, q и e должны быть заданы")
+	}
+	if p.Cmp(big.NewInt(1)) <= 0 || q.Cmp(big.NewInt(1)) <= 0 {
+		return nil, errors.New("p и q должны быть больше 1")
+	}
+
+	key := &RSAPrivateKey{
+		N: new(big.Int).Mul(p, q),
+		E: new(big.Int).Set(e),
+		P: new(big.Int).Set(p),
+		Q: new(big.Int).Set(q),
+	}
+
+	d, err := privateExponent(key.E, key.Phi())
+	if err != nil {
+		return nil, err
+	}
+	key.D = d
+
+	return key, nil
+}
+
+// Phi вычисляет φ(n) = (p-1)*(q-1)
+func (k *RSAPrivateKey) Phi() *big.Int {
+	return BigEulerTotientFromFactors(k.P, k.Q)
+}
+
+// Validate проверяет согласованность закрытого ключа: N = P*Q и e*d ≡ 1 (mod φ(n))
+func (k *RSAPrivateKey) Validate() error {
+	if k.N == nil || k.E == nil || k.D == nil || k.P == nil || k.Q == nil {
+		return errors.New("закрытый ключ заполнен не полностью")
+	}
+
+	if new(big.Int).Mul(k.P, k.Q).Cmp(k.N) != 0 {
+		return errors.New("модуль N не равен произведению P*Q")
+	}
+
+	ed := new(big.Int).Mul(k.E, k.D)
+	if ed.Mod(ed, k.Phi()).Cmp(big.NewInt(1)) != 0 {
+		return errors.New("e*d не сравнимо с 1 по модулю φ(n)")
+	}
+
+	return nil
+}
+
+// generatePrime генерирует простое число длиной bits бит с двумя установленными старшими битами,
+// так что произведение двух таких чисел всегда имеет длину, равную сумме их длин
+func (gen *RSAKeyGenerator) generatePrime(test PrimalityTest, bits int) (*big.Int, error) {
 	maxAttempts := 100
 	
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		// Генерируем случайное число нужной длины
-		num, err := rand.Prime(rand.Reader, gen.bitLength/2)
+		num, err := rand.Prime(rand.Reader, bits)
 		if err != nil {
 			return nil, err
 		}
 		
+		// Явно требуем второй старший бит: p, q >= 1.5 * 2^(bits-1)
+		if num.Bit(bits-2) == 0 {
+			continue
+		}
+		
 		// Проверяем на простоту
 		if test.IsPrime(num, gen.minProbability) {
 			return num, nil
@@ -231,32 +303,140 @@ func (gen *RSAKeyGenerator) isVulnerableToWiener(d, n *big.Int) bool {
 	return cmpResult <= 0
 }
 
-// RSAService сервис для шифрования/дешифрования RSA
+// ErrWeakRSAKey запрошенная длина модуля меньше минимальной по политике ключей
+var ErrWeakRSAKey = errors.New("длина ключа RSA меньше допустимой")
+
+// RSAKeyPolicy политика минимальной длины модуля RSA для GenerateNewKey.
+// Модуль 512 бит факторизуется за часы на облачных ресурсах, 1024 бита считаются
+// достижимыми для крупных вычислительных мощностей, поэтому NIST SP 800-57
+// рекомендует не менее 2048 бит. Нулевое значение порога отключает проверку.
+type RSAKeyPolicy struct {
+	MinKeyBits    int  // короче - ErrWeakRSAKey
+	WarnKeyBits   int  // короче - ключ создается, но KeyWarning сообщает о слабом ключе
+	AllowWeakKeys bool // не возвращать ошибку для ключей короче MinKeyBits (учебные примеры, тесты)
+}
+
+// DefaultRSAKeyPolicy политика по умолчанию: ошибка короче 1024 бит, предупреждение короче 2048
+var DefaultRSAKeyPolicy = RSAKeyPolicy{MinKeyBits: 1024, WarnKeyBits: 2048}
+
+// Check проверяет длину модуля до генерации ключа: слишком короткий ключ дает
+// ErrWeakRSAKey, слабый, но допустимый - непустое предупреждение
+func (policy RSAKeyPolicy) Check(bits int) (string, error) {
+	if bits < policy.MinKeyBits && !policy.AllowWeakKeys {
+		return "", fmt.Errorf("%w: %d бит, минимум %d (разрешить: AllowWeakKeys)", ErrWeakRSAKey, bits, policy.MinKeyBits)
+	}
+	if bits < policy.WarnKeyBits || bits < policy.MinKeyBits {
+		return fmt.Sprintf("ключ RSA длиной %d бит небезопасен, рекомендуется не менее %d бит",
+			bits, max(policy.WarnKeyBits, policy.MinKeyBits)), nil
+	}
+	return "", nil
+}
+
+// RSAService сервис для шифрования/дешифрования RSA. Безопасен для конкурентного
+// использования: Encrypt, Decrypt и другие операции с ключом выполняются под
+// блокировкой на чтение и могут идти параллельно, а GenerateNewKey и сеттеры
+// берут блокировку на запись. Новый ключ генерируется вне блокировки и подменяется
+// атомарно, поэтому каждая операция целиком выполняется одним ключом.
 type RSAService struct {
+	mutex        sync.RWMutex // защищает все поля ниже
 	keyGenerator *RSAKeyGenerator
 	currentKey   *RSAKey
+	windowBits   int // ширина окна FixedWindowModExp при дешифровании, 0 - big.Int.Exp
+	padding      RSAPadding
+	keyPolicy    RSAKeyPolicy
+	keyWarning   string // предупреждение политики для последнего сгенерированного ключа
 }
 
-// NewRSAService создает новый сервис RSA
+// NewRSAService создает новый сервис RSA с набивкой OAEP и политикой DefaultRSAKeyPolicy
 func NewRSAService(testType RSATestType, minProbability float64, bitLength int) *RSAService {
 	return &RSAService{
 		keyGenerator: NewRSAKeyGenerator(testType, minProbability, bitLength),
+		padding:      RSAPaddingOAEP,
+		keyPolicy:    DefaultRSAKeyPolicy,
+	}
+}
+
+// SetKeyPolicy задает политику длины ключа для следующих вызовов GenerateNewKey
+func (rs *RSAService) SetKeyPolicy(policy RSAKeyPolicy) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.keyPolicy = policy
+}
+
+// KeyWarning возвращает предупреждение политики о последнем сгенерированном ключе
+// или пустую строку, если ключ достаточно длинный
+func (rs *RSAService) KeyWarning() string {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+	return rs.keyWarning
+}
+
+// checkKeyPolicy проверяет длину модуля по политике сервиса; вызывается под блокировкой
+func (rs *RSAService) checkKeyPolicy(bits int) (string, error) {
+	return rs.keyPolicy.Check(bits)
+}
+
+// SetPadding задает схему набивки для Encrypt и Decrypt;
+// RSAPaddingNone соответствует «учебному» RSA без набивки (последний блок
+// лишь помечается байтом rsaLastBlockMarker, см. encryptBlockByBlock)
+func (rs *RSAService) SetPadding(padding RSAPadding) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.padding = padding
+}
+
+// SetFixedWindowDecryption включает дешифрование через FixedWindowModExp
+// с заданной шириной окна (1..MaxFixedWindowBits); 0 возвращает стандартное
+// возведение в степень
+func (rs *RSAService) SetFixedWindowDecryption(windowBits int) error {
+	if windowBits < 0 || windowBits > MaxFixedWindowBits {
+		return fmt.Errorf("ширина окна должна быть от 0 до %d, получено %d", MaxFixedWindowBits, windowBits)
 	}
+
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.windowBits = windowBits
+	return nil
 }
 
-// GenerateNewKey генерирует новую пару ключей
+// decryptExp вычисляет c^d mod n выбранным способом; вызывается под блокировкой
+func (rs *RSAService) decryptExp(c, d, n *big.Int) *big.Int {
+	if rs.windowBits > 0 {
+		return FixedWindowModExp(c, d, n, rs.windowBits)
+	}
+	return new(big.Int).Exp(c, d, n)
+}
+
+// GenerateNewKey генерирует новую пару ключей. Длина модуля проверяется по политике
+// ключей до генерации: слишком короткий ключ дает ErrWeakRSAKey, а слабый, но
+// допустимый ключ создается с предупреждением, доступным через KeyWarning.
+// Пока идет генерация, операции продолжают использовать прежний ключ.
 func (rs *RSAService) GenerateNewKey() error {
-	key, err := rs.keyGenerator.GenerateKeyPair()
+	rs.mutex.RLock()
+	generator := rs.keyGenerator
+	warning, err := rs.checkKeyPolicy(generator.bitLength)
+	rs.mutex.RUnlock()
 	if err != nil {
 		return err
 	}
-	
+
+	key, err := generator.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
 	rs.currentKey = key
+	rs.keyWarning = warning
 	return nil
 }
 
 // GetPublicKey возвращает текущий открытый ключ
 func (rs *RSAService) GetPublicKey() (*RSAPublicKey, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
 	if rs.currentKey == nil {
 		return nil, errors.New("ключи не сгенерированы")
 	}
@@ -264,24 +444,93 @@ func (rs *RSAService) GetPublicKey() (*RSAPublicKey, error) {
 	return &rs.currentKey.PublicKey, nil
 }
 
-// Encrypt шифрует сообщение
+// ExportPublicKeySSH возвращает открытый ключ строкой authorized_keys "ssh-rsa AAAA...":
+// base64 от строки "ssh-rsa", e и n в формате SSH (RFC 4253, раздел 6.6)
+func (rs *RSAService) ExportPublicKeySSH() (string, error) {
+	publicKey, err := rs.GetPublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	var blob []byte
+	blob = appendSSHString(blob, []byte("ssh-rsa"))
+	blob = appendSSHString(blob, sshMPInt(publicKey.E))
+	blob = appendSSHString(blob, sshMPInt(publicKey.N))
+
+	return "ssh-rsa " + base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// appendSSHString добавляет данные с 4-байтовым big-endian префиксом длины
+func appendSSHString(buf, data []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	return append(buf, data...)
+}
+
+// sshMPInt кодирует неотрицательное число как mpint: при установленном старшем бите
+// добавляется ведущий нулевой байт, чтобы число не читалось как отрицательное
+func sshMPInt(x *big.Int) []byte {
+	b := x.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		return append([]byte{0}, b...)
+	}
+	return b
+}
+
+// rsaPaddingOverhead резерв байтов блока открытого текста под набивку (как в PKCS#1 v1.5)
+const rsaPaddingOverhead = 11
+
+// MaxPlaintextBlockSize возвращает максимальный размер блока открытого текста:
+// длина модуля в байтах за вычетом резерва под набивку (для OAEP резерв больше)
+func (rs *RSAService) MaxPlaintextBlockSize() (int, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+	return rs.maxPlaintextBlockSize()
+}
+
+// maxPlaintextBlockSize то же, что MaxPlaintextBlockSize, но вызывается под блокировкой
+func (rs *RSAService) maxPlaintextBlockSize() (int, error) {
+	if rs.currentKey == nil {
+		return 0, errors.New("ключи не сгенерированы")
+	}
+
+	overhead := rsaPaddingOverhead
+	if rs.padding == RSAPaddingOAEP {
+		overhead = rsaOAEPOverhead
+	}
+
+	size := len(rs.currentKey.PublicKey.N.Bytes()) - overhead
+	if size <= 0 {
+		return 0, errors.New("ключ слишком мал для шифрования")
+	}
+	return size, nil
+}
+
+// CiphertextBlockSize возвращает размер блока шифртекста (длина модуля в байтах)
+func (rs *RSAService) CiphertextBlockSize() (int, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	if rs.currentKey == nil {
+		return 0, errors.New("ключи не сгенерированы")
+	}
+
+	return len(rs.currentKey.PublicKey.N.Bytes()), nil
+}
+
+// Encrypt шифрует сообщение с выбранной набивкой
 func (rs *RSAService) Encrypt(message []byte) ([]byte, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
 	if rs.currentKey == nil {
 		return nil, errors.New("ключи не сгенерированы")
 	}
-	
-	n := rs.currentKey.PublicKey.N
-	msgInt := new(big.Int).SetBytes(message)
-	
-	if msgInt.Cmp(n) >= 0 {
-		// Если сообщение слишком большое, разбиваем на блоки
-		return rs.encryptBlockByBlock(message)
+
+	if rs.padding != RSAPaddingNone {
+		return rs.encryptPadded(message)
 	}
-	
-	// Шифрование: c = m^e mod n
-	cipherInt := new(big.Int).Exp(msgInt, rs.currentKey.PublicKey.E, n)
-	
-	return cipherInt.Bytes(), nil
+
+	return rs.encryptBlockByBlock(message)
 }
 
 // EncryptString шифрует строку
@@ -289,84 +538,109 @@ func (rs *RSAService) EncryptString(message string) ([]byte, error) {
 	return rs.Encrypt([]byte(message))
 }
 
-// encryptBlockByBlock шифрует большие сообщения по блокам
+// rsaLastBlockMarker байт перед последним (или единственным) блоком без набивки.
+// Промежуточные блоки имеют фиксированную длину maxBlockSize, а длина последнего
+// заранее неизвестна: без маркера его ведущие нулевые байты терялись бы при
+// переводе в big.Int. Блок с маркером не длиннее maxBlockSize+1 и меньше модуля.
+const rsaLastBlockMarker = 0x01
+
+// encryptBlockByBlock шифрует сообщение по блокам без набивки: c = m^e mod n,
+// где последний блок m начинается с rsaLastBlockMarker
 func (rs *RSAService) encryptBlockByBlock(message []byte) ([]byte, error) {
 	n := rs.currentKey.PublicKey.N
 	e := rs.currentKey.PublicKey.E
 	
 	// Определяем максимальный размер блока
 	nBytes := len(n.Bytes())
-	maxBlockSize := nBytes - 11 // оставляем место для padding
-	
-	if maxBlockSize <= 0 {
-		return nil, errors.New("ключ слишком мал для шифрования")
+	maxBlockSize, err := rs.maxPlaintextBlockSize()
+	if err != nil {
+		return nil, err
 	}
 	
 	var encrypted []byte
 	
-	// Шифруем по блокам
-	for i := 0; i < len(message); i += maxBlockSize {
+	// Шифруем по блокам; пустое сообщение дает один блок из маркера
+	for i := 0; i == 0 || i < len(message); i += maxBlockSize {
 		end := i + maxBlockSize
 		if end > len(message) {
 			end = len(message)
 		}
 		
 		block := message[i:end]
+		if end == len(message) {
+			block = append([]byte{rsaLastBlockMarker}, block...)
+		}
 		blockInt := new(big.Int).SetBytes(block)
 		
 		// Шифруем блок
 		cipherInt := new(big.Int).Exp(blockInt, e, n)
 		
-		// Добавляем к результату
-		encrypted = append(encrypted, cipherInt.Bytes()...)
+		// Добавляем к результату блок фиксированной длины, чтобы дешифрование
+		// могло разбить шифртекст по nBytes
+		encrypted = append(encrypted, BytesFixedLen(cipherInt, nBytes)...)
 	}
 	
 	return encrypted, nil
 }
 
-// Decrypt дешифрует сообщение
+// Decrypt дешифрует сообщение и снимает выбранную набивку
 func (rs *RSAService) Decrypt(ciphertext []byte) ([]byte, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
 	if rs.currentKey == nil {
 		return nil, errors.New("ключи не сгенерированы")
 	}
-	
-	cipherInt := new(big.Int).SetBytes(ciphertext)
-	
-	// Проверяем размер
-	if cipherInt.Cmp(rs.currentKey.PrivateKey.N) >= 0 {
-		// Если шифртекст слишком большой, дешифруем по блокам
-		return rs.decryptBlockByBlock(ciphertext)
+
+	if rs.padding != RSAPaddingNone {
+		return rs.decryptPadded(ciphertext)
 	}
-	
-	msgInt := new(big.Int).Exp(cipherInt, rs.currentKey.PrivateKey.D, rs.currentKey.PrivateKey.N)
-	
-	return msgInt.Bytes(), nil
+
+	return rs.decryptBlockByBlock(ciphertext)
 }
 
-// decryptBlockByBlock дешифрует по блокам
+// decryptBlockByBlock дешифрует по блокам шифртекст encryptBlockByBlock
 func (rs *RSAService) decryptBlockByBlock(ciphertext []byte) ([]byte, error) {
 	n := rs.currentKey.PrivateKey.N
 	d := rs.currentKey.PrivateKey.D
 	
 	nBytes := len(n.Bytes())
+	maxBlockSize, err := rs.maxPlaintextBlockSize()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%nBytes != 0 {
+		return nil, fmt.Errorf("длина шифртекста %d не кратна размеру блока %d", len(ciphertext), nBytes)
+	}
 	
 	var decrypted []byte
 	
 	// Дешифруем по блокам
 	for i := 0; i < len(ciphertext); i += nBytes {
-		end := i + nBytes
-		if end > len(ciphertext) {
-			end = len(ciphertext)
+		blockInt := new(big.Int).SetBytes(ciphertext[i : i+nBytes])
+		if blockInt.Cmp(n) >= 0 {
+			return nil, errors.New("некорректный шифртекст: блок не меньше модуля")
 		}
 		
-		block := ciphertext[i:end]
-		blockInt := new(big.Int).SetBytes(block)
-		
 		// Дешифруем блок
-		msgInt := new(big.Int).Exp(blockInt, d, n)
+		msgInt := rs.decryptExp(blockInt, d, n)
 		
-		// Добавляем к результату
-		decrypted = append(decrypted, msgInt.Bytes()...)
+		if i+nBytes == len(ciphertext) {
+			lastBlock := msgInt.Bytes()
+			if len(lastBlock) == 0 || lastBlock[0] != rsaLastBlockMarker || len(lastBlock) > maxBlockSize+1 {
+				return nil, errors.New("некорректный шифртекст: нет маркера последнего блока")
+			}
+			decrypted = append(decrypted, lastBlock[1:]...)
+			break
+		}
+		
+		// Все блоки, кроме последнего, имеют длину maxBlockSize: восстанавливаем
+		// ведущие нулевые байты, которые теряются при переводе в big.Int
+		plainBlock := BytesFixedLen(msgInt, maxBlockSize)
+		if plainBlock == nil {
+			return nil, errors.New("некорректный шифртекст: блок превышает допустимый размер")
+		}
+		decrypted = append(decrypted, plainBlock...)
 	}
 	
 	return decrypted, nil
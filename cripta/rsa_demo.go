@@ -59,7 +59,11 @@ func Demo() {
 	fmt.Println("\n3. RSA Шифрование/Дешифрование:")
 	
 	// Создаем сервис RSA
+	// Короткий ключ ради скорости демонстрации; в реальном применении так нельзя
 	rsaService := NewRSAService(RSAMillerRabin, 0.999, 512)
+	weakPolicy := DefaultRSAKeyPolicy
+	weakPolicy.AllowWeakKeys = true
+	rsaService.SetKeyPolicy(weakPolicy)
 	
 	// Генерируем ключи
 	err := rsaService.GenerateNewKey()
@@ -67,6 +71,9 @@ func Demo() {
 		log.Printf("Ошибка генерации ключей RSA: %v", err)
 	} else {
 		fmt.Println("   Ключи RSA успешно сгенерированы")
+		if warning := rsaService.KeyWarning(); warning != "" {
+			fmt.Printf("   Предупреждение: %s\n", warning)
+		}
 		
 		// Получаем открытый ключ
 		publicKey, err := rsaService.GetPublicKey()
@@ -0,0 +1,79 @@
+package cripta
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Длины для гибридного шифрования: симметричный ключ выводится HKDF из случайного
+// секрета, который передается зашифрованным RSA-OAEP
+const (
+	hybridKeySize       = 32 // длина выводимого симметричного ключа (AES-256)
+	hybridMinSecretSize = 16 // минимальная длина секрета, помещающегося в блок OAEP
+	hybridMaxSecretSize = 32
+)
+
+// DeriveSymmetricKey генерирует случайный симметричный ключ для гибридного шифрования
+// и возвращает его вместе с обернутым ключом для получателя. Случайный секрет шифруется
+// открытым ключом RSA с набивкой OAEP, а симметричный ключ выводится из секрета
+// HKDF с info, так что один и тот же обернутый ключ для разных info дает разные ключи.
+// Данные затем шифруются симметричным ключом отдельно; получатель восстанавливает
+// ключ через RSAService.RecoverSymmetricKey.
+func DeriveSymmetricKey(pub *RSAPublicKey, info []byte) (symKey, wrappedKey []byte, err error) {
+	if pub == nil || pub.N == nil || pub.E == nil {
+		return nil, nil, errors.New("открытый ключ не задан")
+	}
+
+	k := len(pub.N.Bytes())
+	secretSize := min(hybridMaxSecretSize, k-rsaOAEPOverhead)
+	if secretSize < hybridMinSecretSize {
+		return nil, nil, fmt.Errorf("модуль %d бит слишком мал для передачи ключа", pub.N.BitLen())
+	}
+
+	secret := make([]byte, secretSize)
+	if _, err := io.ReadFull(RandReader, secret); err != nil {
+		return nil, nil, fmt.Errorf("ошибка генерации секрета: %w", err)
+	}
+
+	em, err := oaepPad(secret, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := new(big.Int).Exp(new(big.Int).SetBytes(em), pub.E, pub.N)
+
+	return HKDF(secret, nil, info, hybridKeySize), BytesFixedLen(c, k), nil
+}
+
+// RecoverSymmetricKey расшифровывает обернутый ключ текущим закрытым ключом и
+// выводит из секрета тот же симметричный ключ, что и DeriveSymmetricKey с тем же info
+func (rs *RSAService) RecoverSymmetricKey(wrappedKey, info []byte) ([]byte, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	if rs.currentKey == nil {
+		return nil, errors.New("ключи не сгенерированы")
+	}
+
+	n := rs.currentKey.PrivateKey.N
+	k := len(n.Bytes())
+	if len(wrappedKey) != k {
+		return nil, fmt.Errorf("обернутый ключ должен занимать %d байт, получено %d", k, len(wrappedKey))
+	}
+	c := new(big.Int).SetBytes(wrappedKey)
+	if c.Cmp(n) >= 0 {
+		return nil, errors.New("некорректный обернутый ключ: значение не меньше модуля")
+	}
+
+	em := BytesFixedLen(rs.decryptExp(c, rs.currentKey.PrivateKey.D, n), k)
+	secret, err := oaepUnpad(em)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) < hybridMinSecretSize {
+		return nil, errors.New("некорректный обернутый ключ: секрет слишком короткий")
+	}
+
+	return HKDF(secret, nil, info, hybridKeySize), nil
+}
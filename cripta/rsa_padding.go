@@ -0,0 +1,240 @@
+package cripta
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// RSAPadding схема набивки блоков открытого текста RSA
+type RSAPadding int
+
+const (
+	RSAPaddingNone     RSAPadding = iota // «учебный» RSA: блок шифруется как есть
+	RSAPaddingPKCS1v15                   // PKCS#1 v1.5, тип блока 2 (RFC 8017, раздел 7.2)
+	RSAPaddingOAEP                       // OAEP с SHA-1, MGF1-SHA-1 и пустой меткой (RFC 8017, раздел 7.1)
+)
+
+// rsaOAEPOverhead резерв блока под набивку OAEP: 2*hLen + 2 байта.
+// SHA-1 (хеш OAEP по умолчанию в RFC 8017) оставляет место для данных даже в 512-битном ключе.
+const rsaOAEPOverhead = 2*sha1.Size + 2
+
+// rsaPKCS1MinPadding минимальная длина случайной строки PS в PKCS#1 v1.5
+const rsaPKCS1MinPadding = 8
+
+// ErrRSADecryption общая ошибка снятия набивки PKCS#1 v1.5 и OAEP. Причина намеренно
+// не уточняется, чтобы ответ не служил оракулом набивки (атаки Блейхенбахера и Мангера).
+var ErrRSADecryption = errors.New("ошибка дешифрования RSA")
+
+// encryptPadded делит сообщение на блоки MaxPlaintextBlockSize, дополняет каждый
+// выбранной схемой до длины модуля и шифрует. Пустое сообщение дает один блок.
+func (rs *RSAService) encryptPadded(message []byte) ([]byte, error) {
+	n := rs.currentKey.PublicKey.N
+	e := rs.currentKey.PublicKey.E
+	k := len(n.Bytes())
+
+	maxBlockSize, err := rs.maxPlaintextBlockSize()
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted []byte
+	for i := 0; i == 0 || i < len(message); i += maxBlockSize {
+		end := min(i+maxBlockSize, len(message))
+
+		var em []byte
+		if rs.padding == RSAPaddingOAEP {
+			em, err = oaepPad(message[i:end], k)
+		} else {
+			em, err = pkcs1v15Pad(message[i:end], k)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cipherInt := new(big.Int).Exp(new(big.Int).SetBytes(em), e, n)
+		encrypted = append(encrypted, BytesFixedLen(cipherInt, k)...)
+	}
+
+	return encrypted, nil
+}
+
+// decryptPadded расшифровывает блоки длины модуля и снимает с каждого набивку
+func (rs *RSAService) decryptPadded(ciphertext []byte) ([]byte, error) {
+	n := rs.currentKey.PrivateKey.N
+	d := rs.currentKey.PrivateKey.D
+	k := len(n.Bytes())
+
+	if len(ciphertext) == 0 || len(ciphertext)%k != 0 {
+		return nil, fmt.Errorf("длина шифртекста %d байт не кратна размеру блока %d", len(ciphertext), k)
+	}
+
+	var decrypted []byte
+	for i := 0; i < len(ciphertext); i += k {
+		cipherInt := new(big.Int).SetBytes(ciphertext[i : i+k])
+		if cipherInt.Cmp(n) >= 0 {
+			return nil, errors.New("некорректный шифртекст: блок не меньше модуля")
+		}
+
+		em := BytesFixedLen(rs.decryptExp(cipherInt, d, n), k)
+
+		var block []byte
+		var err error
+		if rs.padding == RSAPaddingOAEP {
+			block, err = oaepUnpad(em)
+		} else {
+			block, err = pkcs1v15Unpad(em)
+		}
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, block...)
+	}
+
+	return decrypted, nil
+}
+
+// pkcs1v15Pad строит EM = 0x00 || 0x02 || PS || 0x00 || M, где PS из ненулевых случайных байтов
+func pkcs1v15Pad(message []byte, k int) ([]byte, error) {
+	psLen := k - len(message) - 3
+	if psLen < rsaPKCS1MinPadding {
+		return nil, errors.New("сообщение слишком длинное для набивки PKCS#1 v1.5")
+	}
+
+	em := make([]byte, k)
+	em[1] = 0x02
+	ps := em[2 : 2+psLen]
+	if _, err := io.ReadFull(RandReader, ps); err != nil {
+		return nil, fmt.Errorf("ошибка генерации набивки: %w", err)
+	}
+	for i := range ps {
+		for ps[i] == 0 {
+			if _, err := io.ReadFull(RandReader, ps[i:i+1]); err != nil {
+				return nil, fmt.Errorf("ошибка генерации набивки: %w", err)
+			}
+		}
+	}
+	copy(em[3+psLen:], message)
+
+	return em, nil
+}
+
+// pkcs1v15Unpad проверяет структуру EM и возвращает сообщение после разделителя.
+// Проверка выполняется за постоянное время: EM просматривается целиком независимо
+// от того, где нарушена набивка, а любая ошибка возвращается как ErrRSADecryption.
+func pkcs1v15Unpad(em []byte) ([]byte, error) {
+	// Длина EM равна длине модуля и не является секретом
+	if len(em) < 3+rsaPKCS1MinPadding {
+		return nil, ErrRSADecryption
+	}
+
+	valid := subtle.ConstantTimeByteEq(em[0], 0x00) & subtle.ConstantTimeByteEq(em[1], 0x02)
+
+	// Ищем первый нулевой байт без досрочного выхода из цикла
+	lookingForSeparator := 1
+	separator := 0
+	for i := 2; i < len(em); i++ {
+		isZero := subtle.ConstantTimeByteEq(em[i], 0x00)
+		separator = subtle.ConstantTimeSelect(lookingForSeparator&isZero, i, separator)
+		lookingForSeparator = subtle.ConstantTimeSelect(isZero, 0, lookingForSeparator)
+	}
+
+	// Разделитель найден, и PS не короче rsaPKCS1MinPadding байтов
+	valid &= subtle.ConstantTimeEq(int32(lookingForSeparator), 0)
+	valid &= subtle.ConstantTimeLessOrEq(2+rsaPKCS1MinPadding, separator)
+
+	if valid != 1 {
+		return nil, ErrRSADecryption
+	}
+	return em[separator+1:], nil
+}
+
+// oaepPad строит EM = 0x00 || maskedSeed || maskedDB, DB = lHash || PS || 0x01 || M
+func oaepPad(message []byte, k int) ([]byte, error) {
+	if len(message) > k-rsaOAEPOverhead {
+		return nil, errors.New("сообщение слишком длинное для набивки OAEP")
+	}
+
+	lHash := sha1.Sum(nil)
+
+	em := make([]byte, k)
+	seed := em[1 : 1+sha1.Size]
+	db := em[1+sha1.Size:]
+
+	copy(db, lHash[:])
+	db[len(db)-len(message)-1] = 0x01
+	copy(db[len(db)-len(message):], message)
+
+	if _, err := io.ReadFull(RandReader, seed); err != nil {
+		return nil, fmt.Errorf("ошибка генерации набивки: %w", err)
+	}
+
+	xorInto(db, mgf1(seed, len(db)))
+	xorInto(seed, mgf1(db, len(seed)))
+
+	return em, nil
+}
+
+// oaepUnpad снимает маски и проверяет lHash, разделитель 0x01 и ведущий нулевой байт.
+// Как и в pkcs1v15Unpad, DB просматривается целиком без досрочного выхода, а любая
+// ошибка возвращается как ErrRSADecryption.
+func oaepUnpad(em []byte) ([]byte, error) {
+	// Длина EM равна длине модуля и не является секретом
+	if len(em) < rsaOAEPOverhead {
+		return nil, ErrRSADecryption
+	}
+
+	em = append([]byte{}, em...)
+	seed := em[1 : 1+sha1.Size]
+	db := em[1+sha1.Size:]
+
+	xorInto(seed, mgf1(db, len(seed)))
+	xorInto(db, mgf1(seed, len(db)))
+
+	lHash := sha1.Sum(nil)
+	valid := subtle.ConstantTimeByteEq(em[0], 0) & subtle.ConstantTimeCompare(db[:sha1.Size], lHash[:])
+
+	// Ищем разделитель 0x01 после нулевых байтов PS; любой другой байт до него
+	// делает набивку некорректной
+	lookingForSeparator := 1
+	separator := 0
+	invalidPS := 0
+	for i := sha1.Size; i < len(db); i++ {
+		isZero := subtle.ConstantTimeByteEq(db[i], 0x00)
+		isOne := subtle.ConstantTimeByteEq(db[i], 0x01)
+		separator = subtle.ConstantTimeSelect(lookingForSeparator&isOne, i, separator)
+		lookingForSeparator = subtle.ConstantTimeSelect(isOne, 0, lookingForSeparator)
+		invalidPS = subtle.ConstantTimeSelect(lookingForSeparator&^isZero, 1, invalidPS)
+	}
+
+	valid &= subtle.ConstantTimeEq(int32(lookingForSeparator), 0)
+	valid &= subtle.ConstantTimeEq(int32(invalidPS), 0)
+
+	if valid != 1 {
+		return nil, ErrRSADecryption
+	}
+	return db[separator+1:], nil
+}
+
+// mgf1 функция генерации маски MGF1 на основе SHA-1
+func mgf1(seed []byte, length int) []byte {
+	mask := make([]byte, 0, length+sha1.Size)
+	counter := make([]byte, 4)
+	for i := uint32(0); len(mask) < length; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		digest := sha1.Sum(append(append([]byte{}, seed...), counter...))
+		mask = append(mask, digest[:]...)
+	}
+	return mask[:length]
+}
+
+// xorInto складывает mask с dst по модулю 2 на месте
+func xorInto(dst, mask []byte) {
+	for i := range dst {
+		dst[i] ^= mask[i]
+	}
+}
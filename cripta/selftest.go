@@ -0,0 +1,122 @@
+package cripta
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrSelfTest ошибка самопроверки: реализация дала неверный результат на известном векторе
+var ErrSelfTest = errors.New("self-test failed")
+
+// blockKAT известный ответ блочного шифра: ключ, открытый текст и шифртекст в hex
+type blockKAT struct {
+	algo       string
+	key        string
+	plaintext  string
+	ciphertext string
+}
+
+var selfTestBlockVectors = []blockKAT{
+	// Классический пример DES (J. Orlin Grabbe, «The DES Algorithm Illustrated»)
+	{"des", "133457799bbcdff1", "0123456789abcdef", "85e813540f0ab405"},
+	// FIPS-197, приложение C.1
+	{"aes128", "000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "69c4e0d86a7b0430d8cdb78070b4c55a"},
+}
+
+// SelfTest выполняет быструю самопроверку в духе power-on self-test FIPS 140:
+// известные векторы DES и AES-128 в обе стороны, тест простоты Миллера-Рабина
+// на известных простом и составном числах и RSA на фиксированном ключе.
+// Возвращает ошибку, оборачивающую ErrSelfTest, если хотя бы одна проверка не прошла.
+func SelfTest() error {
+	for _, kat := range selfTestBlockVectors {
+		if err := selfTestBlock(kat); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSelfTest, kat.algo, err)
+		}
+	}
+	if err := selfTestPrimality(); err != nil {
+		return fmt.Errorf("%w: primality: %v", ErrSelfTest, err)
+	}
+	if err := selfTestRSA(); err != nil {
+		return fmt.Errorf("%w: RSA: %v", ErrSelfTest, err)
+	}
+	return nil
+}
+
+// selfTestBlock шифрует и расшифровывает один блок и сверяет результат с вектором
+func selfTestBlock(kat blockKAT) error {
+	key, _ := hex.DecodeString(kat.key)
+	plaintext, _ := hex.DecodeString(kat.plaintext)
+	expected, _ := hex.DecodeString(kat.ciphertext)
+
+	cipher, _, err := NewCipherByName(kat.algo, len(key))
+	if err != nil {
+		return err
+	}
+	if err := cipher.SetKey(key); err != nil {
+		return err
+	}
+
+	ciphertext, err := cipher.EncryptBlock(plaintext)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(ciphertext, expected) {
+		return fmt.Errorf("encryption gave %x, expected %x", ciphertext, expected)
+	}
+
+	decrypted, err := cipher.DecryptBlock(ciphertext)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		return fmt.Errorf("decryption gave %x, expected %x", decrypted, plaintext)
+	}
+
+	return nil
+}
+
+// Простые Мерсенна 2^61 - 1 и 2^89 - 1 для проверок простоты и RSA
+var (
+	selfTestPrimeP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 61), big.NewInt(1))
+	selfTestPrimeQ = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 89), big.NewInt(1))
+)
+
+// selfTestPrimality проверяет, что простое число принимается, а произведение двух простых отклоняется
+func selfTestPrimality() error {
+	test := CreatePrimalityTest(MillerRabinTest)
+
+	if !test.IsPrime(selfTestPrimeP, 0.999999) {
+		return fmt.Errorf("prime %v rejected", selfTestPrimeP)
+	}
+	composite := new(big.Int).Mul(selfTestPrimeP, selfTestPrimeQ)
+	if test.IsPrime(composite, 0.999999) {
+		return fmt.Errorf("composite %v accepted", composite)
+	}
+
+	return nil
+}
+
+// selfTestRSA собирает ключ из фиксированных простых и проверяет m^(ed) ≡ m (mod n)
+func selfTestRSA() error {
+	key, err := NewRSAPrivateKey(selfTestPrimeP, selfTestPrimeQ, big.NewInt(65537))
+	if err != nil {
+		return err
+	}
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	message := big.NewInt(0x5e1f7e57)
+	ciphertext := BigModExp(message, key.E, key.N)
+	if ciphertext.Cmp(message) == 0 {
+		return fmt.Errorf("encryption left the message unchanged")
+	}
+	if decrypted := BigModExp(ciphertext, key.D, key.N); decrypted.Cmp(message) != 0 {
+		return fmt.Errorf("decryption gave %v, expected %v", decrypted, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package cripta
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// sivBlockSize SIV определен для шифров со 128-битным блоком (AES)
+const sivBlockSize = 16
+
+// SIV детерминированное аутентифицированное шифрование AES-SIV (RFC 5297).
+// Синтетический IV вычисляется как S2V (на основе CMAC) от связанных данных
+// и открытого текста, поэтому повтор nonce раскрывает лишь факт совпадения сообщений.
+type SIV struct {
+	macCipher ISymmetricCipher // K1: S2V
+	ctrCipher ISymmetricCipher // K2: CTR
+}
+
+// NewSIV создает AES-SIV; ключ из двух половин AES-ключа: 32, 48 или 64 байта
+func NewSIV(key []uint8) (*SIV, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, fmt.Errorf("%w: SIV requires 32, 48 or 64 bytes, got %d", ErrInvalidKeyLength, len(key))
+	}
+
+	k1, k2, err := SplitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	macCipher, err := NewRijndaelCipher(sivBlockSize, len(k1), 0x1B)
+	if err != nil {
+		return nil, err
+	}
+	if err := macCipher.SetKey(k1); err != nil {
+		return nil, err
+	}
+
+	ctrCipher, err := NewRijndaelCipher(sivBlockSize, len(k2), 0x1B)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctrCipher.SetKey(k2); err != nil {
+		return nil, err
+	}
+
+	return &SIV{macCipher: macCipher, ctrCipher: ctrCipher}, nil
+}
+
+// EncryptSIV шифрует plaintext и возвращает синтетический IV || шифртекст.
+// Элементы aad (включая nonce, если он используется) аутентифицируются по отдельности.
+func (s *SIV) EncryptSIV(plaintext []uint8, aad [][]uint8) ([]uint8, error) {
+	if plaintext == nil {
+		return nil, fmt.Errorf("plaintext cannot be nil")
+	}
+
+	v, err := s.s2v(aad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.ctr(v, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(v, ciphertext...), nil
+}
+
+// DecryptSIV расшифровывает IV || шифртекст и проверяет синтетический IV
+func (s *SIV) DecryptSIV(ciphertext []uint8, aad [][]uint8) ([]uint8, error) {
+	if len(ciphertext) < sivBlockSize {
+		return nil, fmt.Errorf("ciphertext is too short to contain synthetic IV: %d bytes", len(ciphertext))
+	}
+
+	v := ciphertext[:sivBlockSize]
+	plaintext, err := s.ctr(v, ciphertext[sivBlockSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := s.s2v(aad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(expected, v) != 1 {
+		return nil, ErrAuthenticationFailed
+	}
+
+	return plaintext, nil
+}
+
+// s2v вычисляет S2V(K1, aad..., plaintext) из раздела 2.4 RFC 5297
+func (s *SIV) s2v(aad [][]uint8, plaintext []uint8) ([]uint8, error) {
+	d, err := CMAC(s.macCipher, sivBlockSize, make([]uint8, sivBlockSize))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, component := range aad {
+		mac, err := CMAC(s.macCipher, sivBlockSize, component)
+		if err != nil {
+			return nil, err
+		}
+		d = cmacSubkey(d)
+		for i := range d {
+			d[i] ^= mac[i]
+		}
+	}
+
+	var t []uint8
+	if len(plaintext) >= sivBlockSize {
+		// xorend: D складывается с последними 16 байтами открытого текста
+		t = append([]uint8{}, plaintext...)
+		offset := len(t) - sivBlockSize
+		for i := range d {
+			t[offset+i] ^= d[i]
+		}
+	} else {
+		t = cmacSubkey(d)
+		for i := range plaintext {
+			t[i] ^= plaintext[i]
+		}
+		t[len(plaintext)] ^= 0x80
+	}
+
+	return CMAC(s.macCipher, sivBlockSize, t)
+}
+
+// ctr шифрует данные в режиме CTR; счетчик получается из V обнулением
+// 31-го и 63-го битов и увеличивается как 128-битное число
+func (s *SIV) ctr(v, data []uint8) ([]uint8, error) {
+	counter := make([]uint8, sivBlockSize)
+	copy(counter, v)
+	counter[8] &= 0x7f
+	counter[12] &= 0x7f
+
+	result := make([]uint8, len(data))
+	for offset := 0; offset < len(data); offset += sivBlockSize {
+		keystream, err := s.ctrCipher.EncryptBlock(counter)
+		if err != nil {
+			return nil, fmt.Errorf("encryption failed for block %d: %w", offset/sivBlockSize, err)
+		}
+
+		end := min(offset+sivBlockSize, len(data))
+		for i := offset; i < end; i++ {
+			result[i] = data[i] ^ keystream[i-offset]
+		}
+
+		for i := sivBlockSize - 1; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,194 @@
+package cripta
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// validateChunkSize проверяет, что размер порции кратен размеру блока
+func (ctx *CipherContext) validateChunkSize(chunkSize int) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", chunkSize)
+	}
+	if chunkSize%ctx.blockSize != 0 {
+		return fmt.Errorf("chunk size %d must be a multiple of block size %d", chunkSize, ctx.blockSize)
+	}
+	return nil
+}
+
+// EncryptStream шифрует данные из r в w порциями по chunkSize байт.
+// Результат совпадает с Encrypt для тех же данных, но память не зависит от их размера.
+func (ctx *CipherContext) EncryptStream(r io.Reader, w io.Writer, chunkSize int) error {
+	if err := ctx.validateChunkSize(chunkSize); err != nil {
+		return err
+	}
+
+	iv := ctx.iv
+	if ctx.usesAutoIV() {
+		var err error
+		if iv, err = ctx.nextIV(); err != nil {
+			return err
+		}
+	}
+	if err := ctx.recordIV(iv); err != nil {
+		return err
+	}
+
+	state := make([]uint8, ctx.blockSize)
+	copy(state, iv)
+
+	if ctx.usesAutoIV() {
+		if _, err := w.Write(iv); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	processed := 0
+	buf := make([]uint8, chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read input: %w", readErr)
+		}
+
+		chunk := buf[:n]
+		last := readErr != nil
+		if last {
+			// Набивка добавляется только к последней порции
+			padded, err := ctx.applyPadding(chunk)
+			if err != nil {
+				return fmt.Errorf("padding failed: %w", err)
+			}
+			chunk = padded
+		}
+
+		processed += len(chunk)
+		if err := ctx.checkCounterSpace(processed); err != nil {
+			return err
+		}
+
+		var encrypted []uint8
+		var err error
+		encrypted, state, err = ctx.encryptBlocks(chunk, state)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(encrypted); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream расшифровывает данные из r в w порциями по chunkSize байт открытого текста
+func (ctx *CipherContext) DecryptStream(r io.Reader, w io.Writer, chunkSize int) error {
+	if err := ctx.validateChunkSize(chunkSize); err != nil {
+		return err
+	}
+
+	// В RandomDelta каждый блок шифртекста предваряется блоком дельты
+	if ctx.mode == CipherModeRandomDelta {
+		chunkSize *= 2
+	}
+
+	state := make([]uint8, len(ctx.iv))
+	copy(state, ctx.iv)
+
+	if ctx.usesAutoIV() {
+		state = make([]uint8, ctx.blockSize)
+		if _, err := io.ReadFull(r, state); err != nil {
+			return fmt.Errorf("failed to read IV: %w", err)
+		}
+	}
+
+	// Последняя расшифрованная порция придерживается, пока не станет ясно,
+	// что она последняя и с нее нужно снять набивку
+	var pending []uint8
+	processed := 0
+	buf := make([]uint8, chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read input: %w", readErr)
+		}
+
+		if n > 0 {
+			processed += n
+			if err := ctx.checkCounterSpace(processed); err != nil {
+				return err
+			}
+
+			var decrypted []uint8
+			var err error
+			decrypted, state, err = ctx.decryptBlocks(buf[:n], state)
+			if err != nil {
+				return err
+			}
+
+			if _, err := w.Write(pending); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			pending = decrypted
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	unpadded, err := ctx.removePadding(pending)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(unpadded); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// macStreamChunkBlocks размер порции потокового шифрования с MAC в блоках шифра
+const macStreamChunkBlocks = 4096
+
+// EncryptStreamWithMAC шифрует данные из in в out за один проход и одновременно
+// вычисляет HMAC-SHA256 на ключе macKey над всем записанным шифртекстом (вместе с IV
+// в режиме AutoIV). Возвращает тег для DecryptStreamWithMAC. macKey должен быть
+// независим от ключа шифрования.
+func (ctx *CipherContext) EncryptStreamWithMAC(in io.Reader, out io.Writer, macKey []uint8) ([]uint8, error) {
+	if len(macKey) == 0 {
+		return nil, fmt.Errorf("MAC key cannot be empty")
+	}
+
+	mac := hmac.New(sha256.New, macKey)
+	if err := ctx.EncryptStream(in, io.MultiWriter(out, mac), ctx.blockSize*macStreamChunkBlocks); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+// DecryptStreamWithMAC расшифровывает данные из in в out за один проход, вычисляя
+// HMAC-SHA256 над прочитанным шифртекстом, и сравнивает его с tag. При несовпадении
+// возвращает ErrAuthenticationFailed. Тег можно проверить только после чтения всего
+// потока, поэтому открытый текст в out к этому моменту уже записан: при любой ошибке
+// вызывающий должен его отбросить (например, писать во временный файл и переименовывать
+// его только после успешной проверки).
+func (ctx *CipherContext) DecryptStreamWithMAC(in io.Reader, out io.Writer, macKey, tag []uint8) error {
+	if len(macKey) == 0 {
+		return fmt.Errorf("MAC key cannot be empty")
+	}
+
+	mac := hmac.New(sha256.New, macKey)
+	if err := ctx.DecryptStream(io.TeeReader(in, mac), out, ctx.blockSize*macStreamChunkBlocks); err != nil {
+		return err
+	}
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		return ErrAuthenticationFailed
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package cripta
+
+import (
+	"fmt"
+	"io"
+)
+
+// keystream побайтово накладывает гамму потокового режима (CFB, OFB, CTR),
+// сохраняя состояние между вызовами, поэтому данные можно подавать порциями
+// произвольной длины. Результат совпадает с Encrypt/Decrypt для тех же данных.
+type keystream struct {
+	ctx      *CipherContext
+	register []uint8 // счетчик CTR, состояние OFB или предыдущий блок шифртекста CFB
+	block    []uint8 // текущий блок гаммы
+	feedback []uint8 // CFB: байты шифртекста текущего блока
+	used     int     // сколько байтов текущего блока гаммы уже использовано
+	blocks   int     // сколько блоков гаммы выработано, для проверки переполнения счетчика CTR
+}
+
+func newKeystream(ctx *CipherContext, iv []uint8) (*keystream, error) {
+	if !isStreamMode(ctx.mode) {
+		return nil, fmt.Errorf("streaming wrappers require CFB, OFB or CTR mode")
+	}
+	if len(iv) != ctx.blockSize {
+		return nil, fmt.Errorf("IV must be %d bytes, got %d", ctx.blockSize, len(iv))
+	}
+
+	register := make([]uint8, len(iv))
+	copy(register, iv)
+
+	return &keystream{
+		ctx:      ctx,
+		register: register,
+		feedback: make([]uint8, ctx.blockSize),
+		used:     ctx.blockSize,
+	}, nil
+}
+
+// xor записывает в dst данные src, сложенные с гаммой; dst и src могут совпадать.
+// decrypt нужен только CFB, где обратная связь берется из шифртекста:
+// из dst при шифровании, из src при расшифровании.
+func (ks *keystream) xor(dst, src []uint8, decrypt bool) error {
+	for i := range src {
+		if ks.used == ks.ctx.blockSize {
+			if err := ks.nextBlock(); err != nil {
+				return err
+			}
+		}
+
+		in := src[i]
+		dst[i] = in ^ ks.block[ks.used]
+
+		if ks.ctx.mode == CipherModeCFB {
+			if decrypt {
+				ks.feedback[ks.used] = in
+			} else {
+				ks.feedback[ks.used] = dst[i]
+			}
+		}
+		ks.used++
+	}
+	return nil
+}
+
+func (ks *keystream) nextBlock() error {
+	var err error
+
+	switch ks.ctx.mode {
+	case CipherModeCTR:
+		if err := ks.ctx.checkCounterSpace((ks.blocks + 1) * ks.ctx.blockSize); err != nil {
+			return err
+		}
+		ks.block, err = ks.ctx.cipher.EncryptBlock(ks.register)
+		ks.ctx.incrementCounter(ks.register)
+	case CipherModeOFB:
+		ks.register, err = ks.ctx.cipher.EncryptBlock(ks.register)
+		ks.block = ks.register
+	case CipherModeCFB:
+		if ks.block != nil {
+			copy(ks.register, ks.feedback)
+		}
+		ks.block, err = ks.ctx.cipher.EncryptBlock(ks.register)
+	}
+	if err != nil {
+		return fmt.Errorf("keystream generation failed: %w", err)
+	}
+
+	ks.used = 0
+	ks.blocks++
+	return nil
+}
+
+// encryptingWriter аналог cipher.StreamWriter: шифрует каждую порцию сразу при записи
+type encryptingWriter struct {
+	stream *keystream
+	w      io.Writer
+}
+
+// NewEncryptingWriter возвращает io.WriteCloser, который шифрует записываемые данные
+// в потоковом режиме (CFB, OFB, CTR) и передает шифртекст в w. Данные не буферизуются,
+// поэтому обертку можно сочетать с gzip, tar и т. п. При включенном AutoIV новый IV
+// записывается в w сразу, как в Encrypt. Close закрывает w, если тот реализует io.Closer.
+func NewEncryptingWriter(ctx *CipherContext, w io.Writer) (io.WriteCloser, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("cipher context cannot be nil")
+	}
+
+	iv := ctx.iv
+	if ctx.usesAutoIV() {
+		var err error
+		if iv, err = ctx.nextIV(); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.recordIV(iv); err != nil {
+		return nil, err
+	}
+
+	stream, err := newKeystream(ctx, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.usesAutoIV() {
+		if _, err := w.Write(iv); err != nil {
+			return nil, fmt.Errorf("failed to write IV: %w", err)
+		}
+	}
+
+	return &encryptingWriter{stream: stream, w: w}, nil
+}
+
+func (ew *encryptingWriter) Write(p []uint8) (int, error) {
+	encrypted := make([]uint8, len(p))
+	if err := ew.stream.xor(encrypted, p, false); err != nil {
+		return 0, err
+	}
+
+	n, err := ew.w.Write(encrypted)
+	if n != len(p) && err == nil {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+func (ew *encryptingWriter) Close() error {
+	if closer, ok := ew.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// decryptingReader расшифровывает данные из r по мере чтения
+type decryptingReader struct {
+	ctx    *CipherContext
+	stream *keystream // nil, пока IV не прочитан из r (AutoIV)
+	r      io.Reader
+}
+
+// NewDecryptingReader возвращает io.Reader, который читает шифртекст потокового режима
+// (CFB, OFB, CTR) из r и отдает открытый текст. Вместе с NewEncryptingWriter позволяет
+// строить конвейеры с ограниченной памятью. При включенном AutoIV IV читается из начала r
+// при первом вызове Read.
+func NewDecryptingReader(ctx *CipherContext, r io.Reader) (io.Reader, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("cipher context cannot be nil")
+	}
+	if !isStreamMode(ctx.mode) {
+		return nil, fmt.Errorf("streaming wrappers require CFB, OFB or CTR mode")
+	}
+
+	reader := &decryptingReader{ctx: ctx, r: r}
+	if !ctx.usesAutoIV() {
+		stream, err := newKeystream(ctx, ctx.iv)
+		if err != nil {
+			return nil, err
+		}
+		reader.stream = stream
+	}
+
+	return reader, nil
+}
+
+func (dr *decryptingReader) Read(p []uint8) (int, error) {
+	if dr.stream == nil {
+		iv := make([]uint8, dr.ctx.blockSize)
+		if _, err := io.ReadFull(dr.r, iv); err != nil {
+			return 0, fmt.Errorf("failed to read IV: %w", err)
+		}
+
+		stream, err := newKeystream(dr.ctx, iv)
+		if err != nil {
+			return 0, err
+		}
+		dr.stream = stream
+	}
+
+	n, err := dr.r.Read(p)
+	if n > 0 {
+		if xorErr := dr.stream.xor(p[:n], p[:n], true); xorErr != nil {
+			return 0, xorErr
+		}
+	}
+	return n, err
+}
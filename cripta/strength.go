@@ -0,0 +1,62 @@
+package cripta
+
+// SecurityStrengthBits возвращает оценку стойкости шифра контекста в битах, то есть
+// двоичный логарифм числа операций лучшей известной общей атаки. Оценка грубая:
+//   - DES: 56 бит, биты четности ключа не участвуют в шифровании;
+//   - DEAL: 56 бит на каждые 8 байт ключа, так как раундовые ключи - ключи DES
+//     (атака Кнудсена на DEAL-128 требует около 2^121 операций и оценку не снижает);
+//   - Rijndael и ГОСТ 28147-89: длина ключа;
+//   - каскад: атака «встреча посередине» делит каскад на две части и перебирает
+//     ключи каждой части отдельно, поэтому двойной DES дает 56 бит, а тройной 112;
+//   - прочие шифры: длина ключа контекста.
+//
+// Сокращение числа раундов (SetRounds) и режим шифрования в оценке не учитываются.
+func (ctx *CipherContext) SecurityStrengthBits() int {
+	return cipherStrengthBits(ctx.cipher, len(ctx.key))
+}
+
+// cipherStrengthBits оценивает стойкость шифра с ключом длины keyLen байт
+func cipherStrengthBits(cipher ISymmetricCipher, keyLen int) int {
+	switch c := cipher.(type) {
+	case *DESCipher:
+		return 56
+	case *DEALCipher:
+		return c.keyLength / 8 * 56
+	case *RijndaelCipher:
+		return c.keySize * 8
+	case *CascadeCipher:
+		return cascadeStrengthBits(c)
+	default:
+		return keyLen * 8
+	}
+}
+
+// cascadeStrengthBits оценивает стойкость каскада с учетом атаки «встреча посередине»:
+// для каждого разбиения на две части стоимость равна стойкости более сильной части,
+// атакующий выбирает самое выгодное разбиение
+func cascadeStrengthBits(cascade *CascadeCipher) int {
+	strengths := make([]int, len(cascade.ciphers))
+	total := 0
+	for i, cipher := range cascade.ciphers {
+		strengths[i] = cipherStrengthBits(cipher, cascade.keySizes[i])
+		total += strengths[i]
+	}
+	if len(strengths) == 1 {
+		return total
+	}
+
+	best := total
+	prefix := 0
+	for i := 0; i < len(strengths)-1; i++ {
+		prefix += strengths[i]
+		cost := prefix
+		if total-prefix > cost {
+			cost = total - prefix
+		}
+		if cost < best {
+			best = cost
+		}
+	}
+
+	return best
+}
@@ -3,6 +3,7 @@ package cripta
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
 )
 
@@ -74,3 +75,32 @@ func (bnu *BigNumberUtils) ChineseRemainderTheorem(mp, mq, p, q, pinv, qinv *big
 	return diff.Mod(diff, new(big.Int).Mul(p, q))
 }
 
+
+// BytesFixedLen возвращает big-endian представление n длиной ровно length байт,
+// дополняя его ведущими нулями. Если n отрицательно или не помещается в length байт, возвращает nil.
+func BytesFixedLen(n *big.Int, length int) []byte {
+	if n.Sign() < 0 || length < 0 || (n.BitLen()+7)/8 > length {
+		return nil
+	}
+	return n.FillBytes(make([]byte, length))
+}
+
+// SplitKey делит ключевой материал двухключевых режимов (SIV, XTS) на две равные половины.
+// Половины являются копиями и не разделяют память с key.
+func SplitKey(key []byte) (k1, k2 []byte, err error) {
+	if len(key) == 0 || len(key)%2 != 0 {
+		return nil, nil, fmt.Errorf("%w: two-key material must have even non-zero length, got %d", ErrInvalidKeyLength, len(key))
+	}
+
+	half := len(key) / 2
+	k1 = append([]byte{}, key[:half]...)
+	k2 = append([]byte{}, key[half:]...)
+	return k1, k2, nil
+}
+
+// JoinKey объединяет две половины ключа в ключевой материал k1 || k2
+func JoinKey(k1, k2 []byte) []byte {
+	key := make([]byte, 0, len(k1)+len(k2))
+	key = append(key, k1...)
+	return append(key, k2...)
+}
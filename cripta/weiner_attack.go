@@ -11,6 +11,11 @@ type ContinuedFraction struct {
 	B *big.Int // знаменатель (d)
 }
 
+// String возвращает дробь в виде "A/B"
+func (cf ContinuedFraction) String() string {
+	return cf.A.String() + "/" + cf.B.String()
+}
+
 // WienerAttackResult результат атаки Винера
 type WienerAttackResult struct {
 	FoundD          *big.Int            // найденная закрытая экспонента
@@ -19,6 +24,16 @@ type WienerAttackResult struct {
 	Success         bool                // успешность атаки
 	Iterations      int                 // количество итераций
 	Message         string              // сообщение об ошибке/результате
+	Trace           []string            // ход атаки по шагам
+}
+
+// ConvergentsString возвращает подходящие дроби к e/n в виде "A/B" по порядку
+func (result *WienerAttackResult) ConvergentsString() []string {
+	lines := make([]string, len(result.Convergents))
+	for i, convergent := range result.Convergents {
+		lines[i] = convergent.String()
+	}
+	return lines
 }
 
 // WienerAttackService сервис для выполнения атаки Винера
@@ -41,14 +56,14 @@ func (was *WienerAttackService) Attack(publicKey *RSAPublicKey) *WienerAttackRes
 	n := publicKey.N
 	e := publicKey.E
 	
-	fmt.Printf("[DEBUG] Атака Винера для N=%s, e=%s\n", 
-		shortenDebug(n), e.String())
+	result.Trace = append(result.Trace, fmt.Sprintf("Атака Винера для N=%s, e=%s",
+		shortenDebug(n), e.String()))
 	
 	// Вычисляем непрерывную дробь для e/n
 	convergents := was.computeConvergents(e, n)
 	result.Convergents = convergents
 	
-	fmt.Printf("[DEBUG] Вычислено %d подходящих дробей\n", len(convergents))
+	result.Trace = append(result.Trace, fmt.Sprintf("Вычислено %d подходящих дробей", len(convergents)))
 	
 	// Проверяем каждую подходящую дробь
 	for i, conv := range convergents {
@@ -88,7 +103,7 @@ func (was *WienerAttackService) Attack(publicKey *RSAPublicKey) *WienerAttackRes
 			if was.verifyKey(e, d, n, phiCandidate) {
 				result.Success = true
 				result.Message = fmt.Sprintf("Атака успешна на итерации %d: d = %s", i+1, d.String())
-				fmt.Printf("[DEBUG] Успех! Найден d=%s, k=%s\n", d.String(), k.String())
+				result.Trace = append(result.Trace, fmt.Sprintf("Успех! Найден d=%s, k=%s", d.String(), k.String()))
 				return result
 			}
 		}
@@ -260,7 +275,7 @@ func (was *WienerAttackService) isPerfectSquare(n *big.Int) bool {
 	return square.Cmp(n) == 0
 }
 
-// shortenDebug вспомогательная функция для отладки
+// shortenDebug сокращает длинное число для трассировки
 func shortenDebug(n *big.Int) string {
 	s := n.String()
 	if len(s) > 10 {
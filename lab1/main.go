@@ -3,10 +3,16 @@ package main
 
 import (
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"OKLabs/cripta"
@@ -28,59 +34,133 @@ go run main.go -e -a=des -k="0123456789ABCDEF" -iv="FEDCBA9876543210" input.txt
 Шифрование с разными режимами набивки
 go run main.go -e -a=des -m=cbc -p=ansi input.txt output.enc
 
-Поддержка алгоритмов: DES, DEAL-128, DEAL-192, DEAL-256
-Режимы шифрования: ECB, CBC, PCBC, CFB, OFB, CTR, RANDOM_DELTA
-Режимы набивки: Zeros, PKCS7, ANSI X.923, ISO 10126
-Параллельная обработка: для ECB и CTR режимов
+Потоковое шифрование большого файла порциями по 64 КБ
+go run main.go -e -a=des -m=cbc -chunk=64KB input.bin output.enc
+
+Рекурсивное шифрование каталога (файлы получают расширение .enc)
+go run main.go -e -r -a=des -m=cbc -k="0123456789ABCDEF" input_dir output_dir
+
+Тихий режим для конвейеров: stdout пуст, сгенерированные ключ и IV выводятся в stderr
+go run main.go -e -quiet -print-key -a=des -m=cbc input.txt output.enc
+
+Шифрование одного блока в hex для проверки тестовых векторов (результат в stdout)
+go run main.go -e -a=des -k="133457799BBCDFF1" -block="0123456789ABCDEF"
+
+Генерация ключа RSA с выбранным тестом простоты (fermat, solovay, miller)
+go run main.go -genkey -bits=2048 -primetest=solovay
+
+Учебный короткий ключ RSA (ключи короче 1024 бит отклоняются без -allow-weak)
+go run main.go -genkey -bits=512 -allow-weak
+
+Замер скорости всех алгоритмов во всех режимах на 1 МБ случайных данных
+go run main.go -bench=1MB
+
+Поддержка алгоритмов: DES, ГОСТ 28147-89, DEAL-128, DEAL-192, DEAL-256, AES-128, AES-192, AES-256
+(а также любые шифры, зарегистрированные через cripta.RegisterCipher)
+Режимы шифрования: ECB, CBC, PCBC, CFB, OFB, CTR, CTS (CBC с перестановкой шифртекста), RANDOM_DELTA
+Режимы набивки: Zeros, PKCS7, ANSI X.923, ISO 10126, ISO/IEC 7816-4, None (данные кратны блоку)
+Параллельная обработка: для режимов ECB, CTR и RANDOM_DELTA
 */
 
 func main() {
-	encryptFlag := flag.Bool("e", false, "Режим шифрования")
-	decryptFlag := flag.Bool("d", false, "Режим дешифрования")
-	algorithmFlag := flag.String("a", "des", "Алгоритм шифрования: des, deal128, deal192, deal256")
-	modeFlag := flag.String("m", "cbc", "Режим шифрования: ecb, cbc, pcbc, cfb, ofb, ctr, random")
-	paddingFlag := flag.String("p", "pkcs7", "Режим набивки: zeros, pkcs7, ansi, iso")
-	parallelFlag := flag.Bool("parallel", false, "Использовать параллельную обработку (только для ECB/CTR)")
-	keyFlag := flag.String("k", "", "Ключ шифрования в hex")
-	ivFlag := flag.String("iv", "", "Вектор инициализации в hex")
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	flag.Parse()
+// run разбирает аргументы командной строки и выполняет шифрование или дешифрование.
+// Отчет о работе пишется в stdout, использование и диагностика - в stderr.
+func run(arguments []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("lab1", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	encryptFlag := flags.Bool("e", false, "Режим шифрования")
+	decryptFlag := flags.Bool("d", false, "Режим дешифрования")
+	algorithmFlag := flags.String("a", "des", "Алгоритм шифрования: "+strings.Join(cripta.SupportedAlgorithms(), ", "))
+	modeFlag := flags.String("m", "cbc", "Режим шифрования: ecb, cbc, pcbc, cfb, ofb, ctr, cts, random")
+	paddingFlag := flags.String("p", "pkcs7", "Режим набивки: zeros, pkcs7, ansi, iso, iso7816, none")
+	parallelFlag := flags.Bool("parallel", false, "Использовать параллельную обработку (только для ECB/CTR/RANDOM_DELTA)")
+	keyFlag := flags.String("k", "", "Ключ шифрования в hex")
+	ivFlag := flags.String("iv", "", "Вектор инициализации в hex")
+	chunkFlag := flags.String("chunk", "", "Потоковая обработка порциями заданного размера (например 64KB, 1MB)")
+	recursiveFlag := flags.Bool("r", false, "Рекурсивная обработка каталога с сохранением структуры")
+	quietFlag := flags.Bool("quiet", false, "Не выводить ничего, кроме ошибок (для использования в конвейерах)")
+	printKeyFlag := flags.Bool("print-key", false, "Вывести ключ и IV в stderr")
+	genKeyFlag := flags.Bool("genkey", false, "Сгенерировать пару ключей RSA и вывести ее")
+	bitsFlag := flags.Int("bits", 2048, "Длина модуля RSA в битах для -genkey")
+	primeTestFlag := flags.String("primetest", "miller", "Тест простоты для -genkey: fermat, solovay, miller")
+	allowWeakFlag := flags.Bool("allow-weak", false, "Разрешить -genkey ключи короче 1024 бит (только для учебных примеров)")
+	noAuthWarningFlag := flags.Bool("noauthwarning", false, "Не предупреждать о шифровании в режиме без аутентификации")
+	blockFlag := flags.String("block", "", "Зашифровать (-e) или расшифровать (-d) один блок в hex с ключом -k и вывести результат")
+	benchFlag := flags.String("bench", "", "Замерить скорость всех алгоритмов во всех режимах на данных заданного размера (например 1MB)")
+
+	if err := flags.Parse(arguments); err != nil {
+		return err
+	}
+
+	if *quietFlag {
+		stdout = io.Discard
+	}
+
+	if *genKeyFlag {
+		// Ключ - единственный результат генерации: в тихом режиме и с -print-key он
+		// выводится в stderr, а не теряется вместе с stdout
+		keyOut := stdout
+		if *quietFlag || *printKeyFlag {
+			keyOut = stderr
+		}
+		return generateRSAKey(stdout, keyOut, stderr, *bitsFlag, *primeTestFlag, *allowWeakFlag)
+	}
+
+	if *benchFlag != "" {
+		return runBenchmark(stdout, *benchFlag)
+	}
 
 	if (*encryptFlag && *decryptFlag) || (!*encryptFlag && !*decryptFlag) {
-		fmt.Println("Использование:")
-		fmt.Println("  Шифрование: go run main.go -e -a=des -m=cbc input.txt output.enc")
-		fmt.Println("  Дешифрование: go run main.go -d -a=des -m=cbc input.enc output.txt")
-		fmt.Println("\nФлаги:")
-		flag.PrintDefaults()
-		os.Exit(1)
+		fmt.Fprintln(stderr, "Использование:")
+		fmt.Fprintln(stderr, "  Шифрование: go run main.go -e -a=des -m=cbc input.txt output.enc")
+		fmt.Fprintln(stderr, "  Дешифрование: go run main.go -d -a=des -m=cbc input.enc output.txt")
+		fmt.Fprintln(stderr, "\nФлаги:")
+		flags.PrintDefaults()
+		return errors.New("Ошибка: необходимо указать ровно один из флагов -e или -d")
+	}
+
+	if *blockFlag != "" {
+		return processHexBlock(stdout, *encryptFlag, *algorithmFlag, *keyFlag, *blockFlag)
 	}
 
-	args := flag.Args()
+	args := flags.Args()
 	if len(args) != 2 {
-		fmt.Println("Ошибка: необходимо указать входной и выходной файлы")
-		os.Exit(1)
+		return errors.New("Ошибка: необходимо указать входной и выходной файлы")
 	}
 
 	inputFile := args[0]
 	outputFile := args[1]
 
-	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
-		log.Fatalf("Ошибка: входной файл '%s' не существует", inputFile)
+	inputInfo, err := os.Stat(inputFile)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("Ошибка: входной файл '%s' не существует", inputFile)
+	}
+	if inputInfo != nil && inputInfo.IsDir() != *recursiveFlag {
+		if *recursiveFlag {
+			return fmt.Errorf("Ошибка: с флагом -r входной путь '%s' должен быть каталогом", inputFile)
+		}
+		return fmt.Errorf("Ошибка: '%s' является каталогом, используйте флаг -r", inputFile)
 	}
 
 	cipher, keyLength, err := CreateCipher(*algorithmFlag)
 	if err != nil {
-		log.Fatalf("Ошибка создания шифра: %v", err)
+		return fmt.Errorf("Ошибка создания шифра: %v", err)
 	}
 
-	blockSize := 8
-	if *algorithmFlag != "des" {
-		blockSize = 16
+	blockSize := 16
+	if sized, ok := cipher.(cripta.IBlockSizeProvider); ok {
+		blockSize = sized.GetBlockSize()
 	}
 
 	key, err := getOrGenerateKey(*keyFlag, keyLength)
 	if err != nil {
-		log.Fatalf("Ошибка работы с ключом: %v", err)
+		return fmt.Errorf("Ошибка работы с ключом: %v", err)
 	}
 
 	cipherMode := parseCipherMode(*modeFlag)
@@ -88,66 +168,219 @@ func main() {
 
 	iv, err := getOrGenerateIV(*ivFlag, blockSize, cipherMode)
 	if err != nil {
-		log.Fatalf("Ошибка работы с IV: %v", err)
+		return fmt.Errorf("Ошибка работы с IV: %v", err)
+	}
+
+	// В тихом режиме отчет с ключом не выводится, поэтому сгенерированные ключ и IV
+	// пишутся в stderr и без -print-key: иначе зашифрованные данные не восстановить
+	generated := *keyFlag == "" || (cipherMode.RequiresIV() && *ivFlag == "")
+	if *printKeyFlag || (*quietFlag && generated) {
+		fmt.Fprintf(stderr, "Алгоритм: %s\n", cipher.Name())
+		fmt.Fprintf(stderr, "Ключ: %x\n", key)
+		if cipherMode.RequiresIV() {
+			fmt.Fprintf(stderr, "IV: %x\n", iv)
+		}
+	}
+
+	chunkSize := 0
+	if *chunkFlag != "" {
+		chunkSize, err = parseChunkSize(*chunkFlag, blockSize)
+		if err != nil {
+			return fmt.Errorf("Ошибка размера порции: %v", err)
+		}
 	}
 
-	ctx, err := cripta.NewCipherContext(cipher, key, cipherMode, paddingMode, iv, blockSize, *parallelFlag)
+	// Для режимов без параллельной реализации флаг -parallel игнорируется
+	parallel := *parallelFlag && cipherMode.SupportsParallel()
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cipherMode, paddingMode, iv, blockSize, parallel)
 	if err != nil {
-		log.Fatalf("Ошибка создания контекста шифрования: %v", err)
+		return fmt.Errorf("Ошибка создания контекста шифрования: %v", err)
+	}
+
+	if *encryptFlag && !cipherMode.IsAuthenticated() && !*noAuthWarningFlag && !*quietFlag {
+		fmt.Fprintf(stderr, "Предупреждение: режим %s не обеспечивает аутентификацию, "+
+			"изменение шифртекста не будет обнаружено (отключить: -noauthwarning)\n", *modeFlag)
 	}
 
 	startTime := time.Now()
 
-	if *encryptFlag {
-		err = encryptFile(ctx, inputFile, outputFile)
+	if *recursiveFlag {
+		count, err := processDirectory(ctx, *encryptFlag, inputFile, outputFile, chunkSize)
 		if err != nil {
-			log.Fatalf("Ошибка шифрования: %v", err)
+			return fmt.Errorf("Ошибка обработки каталога: %v", err)
+		}
+		fmt.Fprintf(stdout, "Каталог успешно обработан: %s -> %s (файлов: %d)\n", inputFile, outputFile, count)
+	} else if *encryptFlag {
+		if err := processFile(ctx, true, inputFile, outputFile, chunkSize); err != nil {
+			return fmt.Errorf("Ошибка шифрования: %v", err)
 		}
-		fmt.Printf("Файл успешно зашифрован: %s -> %s\n", inputFile, outputFile)
+		fmt.Fprintf(stdout, "Файл успешно зашифрован: %s -> %s\n", inputFile, outputFile)
 	} else {
-		err = decryptFile(ctx, inputFile, outputFile)
-		if err != nil {
-			log.Fatalf("Ошибка дешифрования: %v", err)
+		if err := processFile(ctx, false, inputFile, outputFile, chunkSize); err != nil {
+			return fmt.Errorf("Ошибка дешифрования: %v", err)
 		}
-		fmt.Printf("Файл успешно дешифрован: %s -> %s\n", inputFile, outputFile)
+		fmt.Fprintf(stdout, "Файл успешно дешифрован: %s -> %s\n", inputFile, outputFile)
 	}
 
 	duration := time.Since(startTime)
-	fileInfo, _ := os.Stat(inputFile)
-	fileSize := fileInfo.Size()
-
-	fmt.Printf("\nИнформация:\n")
-	fmt.Printf("  Алгоритм: %s\n", *algorithmFlag)
-	fmt.Printf("  Режим: %s\n", *modeFlag)
-	fmt.Printf("  Набивка: %s\n", *paddingFlag)
-	fmt.Printf("  Параллельная обработка: %v\n", *parallelFlag)
-	fmt.Printf("  Размер файла: %d байт\n", fileSize)
-	fmt.Printf("  Время выполнения: %v\n", duration)
-	fmt.Printf("  Ключ: %x\n", key)
-	if cipherMode != cripta.CipherModeECB {
-		fmt.Printf("  IV: %x\n", iv)
+	fileSize := inputInfo.Size()
+
+	fmt.Fprintf(stdout, "\nИнформация:\n")
+	fmt.Fprintf(stdout, "  Алгоритм: %s\n", cipher.Name())
+	fmt.Fprintf(stdout, "  Режим: %s\n", *modeFlag)
+	fmt.Fprintf(stdout, "  Набивка: %s\n", *paddingFlag)
+	fmt.Fprintf(stdout, "  Параллельная обработка: %v\n", parallel)
+	if chunkSize > 0 {
+		fmt.Fprintf(stdout, "  Размер порции: %d байт\n", chunkSize)
+	}
+	fmt.Fprintf(stdout, "  Размер файла: %d байт\n", fileSize)
+	fmt.Fprintf(stdout, "  Время выполнения: %v\n", duration)
+	fmt.Fprintf(stdout, "  Ключ: %x\n", key)
+	if cipherMode.RequiresIV() && *recursiveFlag {
+		fmt.Fprintf(stdout, "  IV: свой для каждого файла (записан в начале файла)\n")
+	} else if cipherMode.RequiresIV() {
+		fmt.Fprintf(stdout, "  IV: %x\n", iv)
+	}
+
+	return nil
+}
+
+// modeFlagNames значения флага -m в порядке вывода замеров
+var modeFlagNames = []string{"ecb", "cbc", "pcbc", "cfb", "ofb", "ctr", "cts", "random"}
+
+// benchAliases имена реестра, совпадающие с другими алгоритмами (deal - это deal128,
+// rijndael - aes128): в замерах они повторяли бы те же шифры
+var benchAliases = map[string]bool{"deal": true, "rijndael": true}
+
+// modeFlagName возвращает значение флага -m для режима шифрования
+func modeFlagName(mode cripta.CipherMode) string {
+	for _, name := range modeFlagNames {
+		if parseCipherMode(name) == mode {
+			return name
+		}
 	}
+	return fmt.Sprintf("режим %d", mode)
 }
 
-func CreateCipher(algorithm string) (cripta.ISymmetricCipher, int, error) {
-	switch algorithm {
-	case "des":
-		cipher, err := cripta.NewDESCipher()
-		return cipher, 8, err
-	case "deal128":
-		cipher, err := cripta.NewDEALCipher(16)
-		return cipher, 16, err
-	case "deal192":
-		cipher, err := cripta.NewDEALCipher(24)
-		return cipher, 24, err
-	case "deal256":
-		cipher, err := cripta.NewDEALCipher(32)
-		return cipher, 32, err
+// runBenchmark замеряет скорость всех зарегистрированных алгоритмов во всех режимах
+// на случайных данных заданного размера и выводит таблицу результатов
+func runBenchmark(stdout io.Writer, size string) error {
+	dataSize, err := parseChunkSize(size, 1)
+	if err != nil {
+		return fmt.Errorf("Ошибка размера данных для замера: %v", err)
+	}
+	data := make([]byte, dataSize)
+	if _, err := cripta.GenerateRandomBytes(data); err != nil {
+		return fmt.Errorf("Ошибка генерации данных для замера: %v", err)
+	}
+
+	modes := make([]cripta.CipherMode, len(modeFlagNames))
+	for i, name := range modeFlagNames {
+		modes[i] = parseCipherMode(name)
+	}
+
+	var algorithms []string
+	for _, name := range cripta.SupportedAlgorithms() {
+		if !benchAliases[name] {
+			algorithms = append(algorithms, name)
+		}
+	}
+
+	results := cripta.BenchmarkMatrix(data, algorithms, modes)
+
+	fmt.Fprintf(stdout, "Замер скорости на %d байтах (MB/s)\n", dataSize)
+	fmt.Fprintf(stdout, "%-12s %-8s %12s %12s\n", "Алгоритм", "Режим", "Шифрование", "Дешифрование")
+	for _, result := range results {
+		mode := modeFlagName(result.Mode)
+		if result.Err != nil {
+			fmt.Fprintf(stdout, "%-12s %-8s ошибка: %v\n", result.Algorithm, mode, result.Err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%-12s %-8s %12.2f %12.2f\n", result.Algorithm, mode, result.EncryptMBs, result.DecryptMBs)
+	}
+
+	return nil
+}
+
+// processHexBlock шифрует или расшифровывает один блок, заданный в hex, и выводит результат
+func processHexBlock(stdout io.Writer, encrypt bool, algorithm, keyHex, blockHex string) error {
+	if keyHex == "" {
+		return errors.New("Ошибка: для -block необходимо указать ключ -k")
+	}
+
+	cipher, _, err := CreateCipher(algorithm)
+	if err != nil {
+		return fmt.Errorf("Ошибка создания шифра: %v", err)
+	}
+
+	process := cripta.DecryptHexBlock
+	if encrypt {
+		process = cripta.EncryptHexBlock
+	}
+	result, err := process(cipher, keyHex, blockHex)
+	if err != nil {
+		return fmt.Errorf("Ошибка обработки блока: %v", err)
+	}
+
+	fmt.Fprintln(stdout, result)
+	return nil
+}
+
+// generateRSAKey генерирует пару ключей RSA заданной длины выбранным тестом простоты
+// и выводит ее компоненты в hex
+func generateRSAKey(stdout, keyOut, stderr io.Writer, bits int, primeTest string, allowWeak bool) error {
+	testType, err := parsePrimalityTest(primeTest)
+	if err != nil {
+		return fmt.Errorf("Ошибка выбора теста простоты: %v", err)
+	}
+
+	// Та же политика длины ключа, что и в RSAService.GenerateNewKey
+	policy := cripta.DefaultRSAKeyPolicy
+	policy.AllowWeakKeys = allowWeak
+	warning, err := policy.Check(bits)
+	if err != nil {
+		return fmt.Errorf("Ошибка генерации ключа RSA: %v", err)
+	}
+	if warning != "" {
+		fmt.Fprintf(stderr, "Предупреждение: %s\n", warning)
+	}
+
+	startTime := time.Now()
+	key, err := cripta.NewRSAKeyGenerator(testType, 0.999, bits).GenerateKeyPair()
+	if err != nil {
+		return fmt.Errorf("Ошибка генерации ключа RSA: %v", err)
+	}
+
+	fmt.Fprintf(stdout, "Ключ RSA сгенерирован за %v\n", time.Since(startTime))
+	fmt.Fprintf(stdout, "  Тест простоты: %s\n", primeTest)
+	fmt.Fprintf(stdout, "  Длина модуля: %d бит\n", key.PublicKey.N.BitLen())
+	fmt.Fprintf(keyOut, "  N: %x\n", key.PublicKey.N)
+	fmt.Fprintf(keyOut, "  E: %x\n", key.PublicKey.E)
+	fmt.Fprintf(keyOut, "  D: %x\n", key.PrivateKey.D)
+	fmt.Fprintf(keyOut, "  P: %x\n", key.PrivateKey.P)
+	fmt.Fprintf(keyOut, "  Q: %x\n", key.PrivateKey.Q)
+
+	return nil
+}
+
+func parsePrimalityTest(name string) (cripta.RSATestType, error) {
+	switch name {
+	case "fermat":
+		return cripta.RSAFermat, nil
+	case "solovay":
+		return cripta.RSASolovayStrassen, nil
+	case "miller":
+		return cripta.RSAMillerRabin, nil
 	default:
-		return nil, 0, fmt.Errorf("неизвестный алгоритм: %s", algorithm)
+		return 0, fmt.Errorf("неизвестный тест простоты: %s", name)
 	}
 }
 
+func CreateCipher(algorithm string) (cripta.ISymmetricCipher, int, error) {
+	return cripta.NewCipherByName(algorithm, 0)
+}
+
 func getOrGenerateKey(keyFlag string, keyLength int) ([]byte, error) {
 	if keyFlag != "" {
 		return parseHexString(keyFlag, keyLength)
@@ -166,7 +399,7 @@ func getOrGenerateIV(ivFlag string, ivLength int, mode cripta.CipherMode) ([]byt
 		return parseHexString(ivFlag, ivLength)
 	}
 	
-	if mode == cripta.CipherModeECB {
+	if !mode.RequiresIV() {
 		return nil, nil
 	}
 	
@@ -191,6 +424,34 @@ func parseHexString(hexStr string, expectedLength int) ([]byte, error) {
 	return data, nil
 }
 
+func parseChunkSize(chunk string, blockSize int) (int, error) {
+	value := strings.ToUpper(strings.TrimSpace(chunk))
+	multiplier := 1
+
+	switch {
+	case strings.HasSuffix(value, "MB"):
+		multiplier = 1024 * 1024
+		value = strings.TrimSuffix(value, "MB")
+	case strings.HasSuffix(value, "KB"):
+		multiplier = 1024
+		value = strings.TrimSuffix(value, "KB")
+	case strings.HasSuffix(value, "B"):
+		value = strings.TrimSuffix(value, "B")
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("неверный размер порции: %s", chunk)
+	}
+	size *= multiplier
+
+	if size%blockSize != 0 {
+		return 0, fmt.Errorf("размер порции %d должен быть кратен размеру блока %d", size, blockSize)
+	}
+
+	return size, nil
+}
+
 func parseCipherMode(mode string) cripta.CipherMode {
 	switch mode {
 	case "ecb":
@@ -205,6 +466,8 @@ func parseCipherMode(mode string) cripta.CipherMode {
 		return cripta.CipherModeOFB
 	case "ctr":
 		return cripta.CipherModeCTR
+	case "cts":
+		return cripta.CipherModeCBC_CTS
 	case "random":
 		return cripta.CipherModeRandomDelta
 	default:
@@ -222,6 +485,10 @@ func parsePaddingMode(padding string) cripta.PaddingMode {
 		return cripta.PaddingModeANSIX923
 	case "iso":
 		return cripta.PaddingModeISO10126
+	case "iso7816":
+		return cripta.PaddingModeISO7816
+	case "none":
+		return cripta.PaddingModeNone
 	default:
 		return cripta.PaddingModePKCS7
 	}
@@ -263,4 +530,110 @@ func decryptFile(ctx *cripta.CipherContext, inputPath, outputPath string) error
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+func encryptFileStream(ctx *cripta.CipherContext, inputPath, outputPath string, chunkSize int) error {
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer input.Close()
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	defer output.Close()
+
+	if err := ctx.EncryptStream(input, output, chunkSize); err != nil {
+		return fmt.Errorf("ошибка шифрования: %w", err)
+	}
+
+	return output.Close()
+}
+
+func decryptFileStream(ctx *cripta.CipherContext, inputPath, outputPath string, chunkSize int) error {
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer input.Close()
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	defer output.Close()
+
+	if err := ctx.DecryptStream(input, output, chunkSize); err != nil {
+		return fmt.Errorf("ошибка дешифрования: %w", err)
+	}
+
+	return output.Close()
+}
+
+// processFile шифрует или дешифрует один файл, целиком или порциями при chunkSize > 0
+func processFile(ctx *cripta.CipherContext, encrypt bool, inputPath, outputPath string, chunkSize int) error {
+	switch {
+	case encrypt && chunkSize > 0:
+		return encryptFileStream(ctx, inputPath, outputPath, chunkSize)
+	case chunkSize > 0:
+		return decryptFileStream(ctx, inputPath, outputPath, chunkSize)
+	case encrypt:
+		return encryptFile(ctx, inputPath, outputPath)
+	default:
+		return decryptFile(ctx, inputPath, outputPath)
+	}
+}
+
+// encryptedExtension расширение зашифрованных файлов при обработке каталога
+const encryptedExtension = ".enc"
+
+// processDirectory обходит каталог и обрабатывает каждый файл тем же контекстом,
+// повторяя структуру каталогов в outputDir. При шифровании уже зашифрованные
+// файлы (*.enc) пропускаются, при дешифровании обрабатываются только они.
+// Все файлы шифруются одним ключом, поэтому включается AutoIV: каждый файл
+// получает новый IV, записанный перед шифртекстом, и IV контекста не используется.
+// Возвращает число обработанных файлов.
+func processDirectory(ctx *cripta.CipherContext, encrypt bool, inputDir, outputDir string, chunkSize int) (int, error) {
+	count := 0
+	ctx.SetAutoIV(true)
+
+	err := filepath.WalkDir(inputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(inputDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(outputDir, relative)
+
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		isEncrypted := strings.HasSuffix(path, encryptedExtension)
+		if encrypt == isEncrypted {
+			return nil
+		}
+
+		if encrypt {
+			target += encryptedExtension
+		} else {
+			target = strings.TrimSuffix(target, encryptedExtension)
+		}
+
+		if err := processFile(ctx, encrypt, path, target, chunkSize); err != nil {
+			return fmt.Errorf("%s: %w", relative, err)
+		}
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +50,16 @@ type TestResult struct {
 	errorDetail     string
 }
 
+// ThroughputStats медианная (p50) и 95-я перцентиль скорости в MB/s
+// по успешным тестам матрицы
+type ThroughputStats struct {
+	encryptP50 float64
+	encryptP95 float64
+	decryptP50 float64
+	decryptP95 float64
+	samples    int
+}
+
 func Test(t *testing.T) {
 	if err := checkDirectories(); err != nil {
 		t.Fatalf("❌ Ошибка директорий: %v", err)
@@ -111,16 +131,15 @@ func generateCryptoTests(files []string) []CryptoTest {
 	}
 
 	modes := []struct {
-		mode        cripta.CipherMode
-		modeName    string
-		canParallel bool
+		mode     cripta.CipherMode
+		modeName string
 	}{
-		{cripta.CipherModeECB, "ECB", true},
-		{cripta.CipherModeCBC, "CBC", false},
-		{cripta.CipherModeCTR, "CTR", true},
-		{cripta.CipherModeCFB, "CFB", false},
-		{cripta.CipherModeOFB, "OFB", false},
-		{cripta.CipherModeRandomDelta, "RD", false},
+		{cripta.CipherModeECB, "ECB"},
+		{cripta.CipherModeCBC, "CBC"},
+		{cripta.CipherModeCTR, "CTR"},
+		{cripta.CipherModeCFB, "CFB"},
+		{cripta.CipherModeOFB, "OFB"},
+		{cripta.CipherModeRandomDelta, "RD"},
 	}
 
 	paddings := []struct {
@@ -184,7 +203,7 @@ func generateCryptoTests(files []string) []CryptoTest {
 			modeName:    mode.modeName,
 			padding:     padding.padding,
 			paddingName: padding.paddingName,
-			parallel:    config.parallel && mode.canParallel,
+			parallel:    config.parallel && mode.mode.SupportsParallel(),
 			inputFile:   file,
 		})
 	}
@@ -339,6 +358,57 @@ func printSummary(results []TestResult) {
 			decSpeed,
 			status)
 	}
+
+	stats := computeThroughputStats(results)
+	if stats.samples > 0 {
+		fmt.Printf("\nСкорость по %d успешным тестам (МБ/с): шифр p50 %.1f, p95 %.1f; дешифр p50 %.1f, p95 %.1f\n",
+			stats.samples, stats.encryptP50, stats.encryptP95, stats.decryptP50, stats.decryptP95)
+	}
+}
+
+// computeThroughputStats считает перцентили скорости только по успешным тестам
+func computeThroughputStats(results []TestResult) ThroughputStats {
+	var encrypt, decrypt []float64
+	for _, result := range results {
+		if result.success {
+			encrypt = append(encrypt, result.encryptSpeedKBs)
+			decrypt = append(decrypt, result.decryptSpeedKBs)
+		}
+	}
+
+	return ThroughputStats{
+		encryptP50: percentile(encrypt, 50),
+		encryptP95: percentile(encrypt, 95),
+		decryptP50: percentile(decrypt, 50),
+		decryptP95: percentile(decrypt, 95),
+		samples:    len(encrypt),
+	}
+}
+
+// percentile возвращает p-ю перцентиль (0..100) с линейной интерполяцией между
+// соседними значениями; для пустого набора возвращает 0. Исходный срез не меняется.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
 }
 
 func analyzeErrors(results []TestResult) {
@@ -395,3 +465,3547 @@ func generateRandomBytes(size int) []byte {
 	rand.Read(data)
 	return data
 }
+
+// TestCipherContextIndependentMessages проверяет, что состояние сцепления не переносится
+// между сообщениями: каждый вызов Encrypt и Decrypt начинает с IV контекста
+func TestCipherContextIndependentMessages(t *testing.T) {
+	fmt.Println("\nТЕСТ НЕЗАВИСИМОСТИ СООБЩЕНИЙ КОНТЕКСТА")
+
+	cipher, _, err := CreateCipher("des")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	messages := [][]byte{
+		[]byte("Первое сообщение"),
+		[]byte("Второе, совсем другое сообщение"),
+	}
+
+	var encrypted [][]byte
+	for _, msg := range messages {
+		enc, err := ctx.Encrypt(msg)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования: %v", err)
+		}
+		encrypted = append(encrypted, enc)
+	}
+
+	for i := len(encrypted) - 1; i >= 0; i-- {
+		dec, err := ctx.Decrypt(encrypted[i])
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования: %v", err)
+		}
+		if string(dec) != string(messages[i]) {
+			t.Errorf("Сообщение %d не совпадает при расшифровке в обратном порядке: '%s' != '%s'", i, dec, messages[i])
+		}
+	}
+}
+
+// TestCipherContextReset шифрует два сообщения с Reset между ними при включенном
+// обнаружении повтора IV и проверяет, что оба расшифровываются независимо
+func TestCipherContextReset(t *testing.T) {
+	fmt.Println("\nТЕСТ СБРОСА СОСТОЯНИЯ КОНТЕКСТА")
+
+	cipher, _, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key := generateRandomBytes(16)
+	iv := generateRandomBytes(16)
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ctx.SetIVReuseDetection(true)
+
+	first := []byte("Первое сообщение")
+	second := []byte("Второе, независимое сообщение")
+
+	encryptedFirst, err := ctx.Encrypt(first)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if _, err := ctx.Encrypt(second); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Fatalf("Без Reset повтор IV должен обнаруживаться, получено %v", err)
+	}
+
+	ctx.Reset()
+	encryptedSecond, err := ctx.Encrypt(second)
+	if err != nil {
+		t.Fatalf("После Reset шифрование должно проходить: %v", err)
+	}
+
+	// Каждое сообщение расшифровывается само по себе, в любом порядке
+	for _, c := range []struct {
+		encrypted, expected []byte
+	}{{encryptedSecond, second}, {encryptedFirst, first}} {
+		decrypted, err := ctx.Decrypt(c.encrypted)
+		if err != nil || !bytes.Equal(decrypted, c.expected) {
+			t.Errorf("Сообщение расшифровано неверно: %q, %v", decrypted, err)
+		}
+	}
+
+	// Обнаружение повторов после Reset остается включенным
+	if _, err := ctx.Encrypt(first); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("После Reset обнаружение повтора IV должно оставаться включенным, получено %v", err)
+	}
+}
+
+func TestParallelSingleBlock(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАРАЛЛЕЛЬНОЙ ОБРАБОТКИ ОДНОГО БЛОКА")
+
+	modes := []struct {
+		mode     cripta.CipherMode
+		modeName string
+		encSize  int
+	}{
+		{cripta.CipherModeECB, "ECB", 8},
+		// CTR не использует набивку: длина шифртекста равна длине данных
+		{cripta.CipherModeCTR, "CTR", 7},
+	}
+
+	for _, m := range modes {
+		t.Run(m.modeName, func(t *testing.T) {
+			cipher, _, err := CreateCipher("des")
+			if err != nil {
+				t.Fatalf("Ошибка создания шифра: %v", err)
+			}
+
+			ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), m.mode, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, true)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+
+			// Меньше одного блока: обрабатывается ровно один блок
+			data := []byte("1234567")
+			encrypted, err := ctx.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Ошибка шифрования: %v", err)
+			}
+			if len(encrypted) != m.encSize {
+				t.Fatalf("Ожидалось %d байт шифртекста, получено %d", m.encSize, len(encrypted))
+			}
+
+			decrypted, err := ctx.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("Ошибка дешифрования: %v", err)
+			}
+			if string(decrypted) != string(data) {
+				t.Errorf("Данные не совпадают: '%s' != '%s'", decrypted, data)
+			}
+
+			empty, err := ctx.Decrypt([]byte{})
+			if err != nil || len(empty) != 0 {
+				t.Errorf("Пустой шифртекст должен давать пустой результат: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticatedDecryption(t *testing.T) {
+	fmt.Println("\nТЕСТ АУТЕНТИФИЦИРОВАННОГО ШИФРОВАНИЯ")
+
+	cipher, _, err := CreateCipher("deal128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(16), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	plaintext := []byte("Сообщение с заголовком")
+	aad := []byte("header: v1")
+
+	ciphertext, tag, err := ctx.EncryptAEAD(plaintext, aad)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	decrypted, err := ctx.DecryptAEAD(ciphertext, tag, aad)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("Сообщение не совпадает: '%s' != '%s'", decrypted, plaintext)
+	}
+
+	_, err = ctx.DecryptAEAD(ciphertext, tag, []byte("header: v2"))
+	if !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Неверный AAD должен приводить к ErrAuthenticationFailed, получено: %v", err)
+	}
+
+	tampered := append([]byte{}, ciphertext...)
+	tampered[0] ^= 0x01
+	_, err = ctx.DecryptAEAD(tampered, tag, aad)
+	if !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Измененный шифртекст должен приводить к ErrAuthenticationFailed, получено: %v", err)
+	}
+}
+
+func TestCipherContextFromHeader(t *testing.T) {
+	fmt.Println("\nТЕСТ ВОССТАНОВЛЕНИЯ КОНТЕКСТА ПО ЗАГОЛОВКУ")
+
+	configs := []struct {
+		algorithm cripta.AlgorithmID
+		name      string
+		mode      cripta.CipherMode
+	}{
+		{cripta.AlgorithmDES, "des", cripta.CipherModeCBC},
+		{cripta.AlgorithmDEAL128, "deal128", cripta.CipherModeECB},
+		{cripta.AlgorithmDEAL256, "deal256", cripta.CipherModeCTR},
+	}
+
+	plaintext := []byte("Данные с самоописывающим заголовком")
+
+	for _, cfg := range configs {
+		cipher, keySize, err := CreateCipher(cfg.name)
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+
+		blockSize := 16
+		if cfg.name == "des" {
+			blockSize = 8
+		}
+		var iv []byte
+		if cfg.mode != cripta.CipherModeECB {
+			iv = generateRandomBytes(blockSize)
+		}
+		key := generateRandomBytes(keySize)
+
+		ctx, err := cripta.NewCipherContext(cipher, key, cfg.mode, cripta.PaddingModeANSIX923, iv, blockSize, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+
+		encrypted, err := ctx.EncryptWithHeader(cfg.algorithm, plaintext)
+		if err != nil {
+			t.Fatalf("%s: ошибка шифрования с заголовком: %v", cfg.name, err)
+		}
+
+		restored, rest, err := cripta.NewCipherContextFromHeader(encrypted, key)
+		if err != nil {
+			t.Fatalf("%s: ошибка разбора заголовка: %v", cfg.name, err)
+		}
+		if restored.GetMode() != cfg.mode || restored.GetBlockSize() != blockSize {
+			t.Errorf("%s: параметры контекста восстановлены неверно", cfg.name)
+		}
+
+		decrypted, err := restored.Decrypt(rest)
+		if err != nil {
+			t.Fatalf("%s: ошибка дешифрования: %v", cfg.name, err)
+		}
+		if string(decrypted) != string(plaintext) {
+			t.Errorf("%s: данные не совпадают после восстановления контекста", cfg.name)
+		}
+	}
+
+	// С AutoIV каждое сообщение получает свой IV, который должен попасть в заголовок
+	cipher, keySize, _ := CreateCipher("aes128")
+	key := generateRandomBytes(keySize)
+	for _, mode := range []cripta.CipherMode{cripta.CipherModeCBC, cripta.CipherModeCTR, cripta.CipherModeOFB, cripta.CipherModeCFB} {
+		ctx, err := cripta.NewCipherContext(cipher, key, mode, cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		ctx.SetAutoIV(true)
+
+		for i := 0; i < 2; i++ {
+			encrypted, err := ctx.EncryptWithHeader(cripta.AlgorithmAES128, plaintext)
+			if err != nil {
+				t.Fatalf("Режим %d: ошибка шифрования с заголовком: %v", mode, err)
+			}
+			restored, rest, err := cripta.NewCipherContextFromHeader(encrypted, key)
+			if err != nil {
+				t.Fatalf("Режим %d: ошибка разбора заголовка: %v", mode, err)
+			}
+			decrypted, err := restored.Decrypt(rest)
+			if err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Режим %d, сообщение %d: данные с AutoIV не совпадают после восстановления контекста: %v", mode, i, err)
+			}
+		}
+	}
+
+	if _, _, err := cripta.NewCipherContextFromHeader([]byte("XXXX\x01\x01\x01\x02\x00"), generateRandomBytes(8)); err == nil {
+		t.Errorf("Заголовок с неверной сигнатурой должен отклоняться")
+	}
+}
+
+func TestHeaderKeyCheck(t *testing.T) {
+	fmt.Println("\nТЕСТ КОНТРОЛЬНОГО ЗНАЧЕНИЯ КЛЮЧА В ЗАГОЛОВКЕ")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key := generateRandomBytes(keySize)
+	iv := generateRandomBytes(16)
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	encrypted, err := ctx.EncryptWithHeader(cripta.AlgorithmAES128, bytes.Repeat([]byte("data"), 1000))
+	if err != nil {
+		t.Fatalf("Ошибка шифрования с заголовком: %v", err)
+	}
+
+	header, _, err := cripta.ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("Ошибка разбора заголовка: %v", err)
+	}
+	if !bytes.Equal(header.KeyCheck, cripta.KeyCheckValue(key)) {
+		t.Fatalf("Контрольное значение ключа %x, ожидалось %x", header.KeyCheck, cripta.KeyCheckValue(key))
+	}
+
+	// Только заголовок, без шифртекста: неверный ключ должен определяться по 4 байтам
+	headerOnly, err := header.Marshal()
+	if err != nil {
+		t.Fatalf("Ошибка сериализации заголовка: %v", err)
+	}
+	wrongKey := append([]byte(nil), key...)
+	wrongKey[0] ^= 1
+	if _, _, err := cripta.NewCipherContextFromHeader(headerOnly, wrongKey); !errors.Is(err, cripta.ErrWrongKey) {
+		t.Errorf("Неверный ключ: ожидалась ErrWrongKey, получено %v", err)
+	}
+	if _, rest, err := cripta.NewCipherContextFromHeader(headerOnly, key); err != nil || len(rest) != 0 {
+		t.Errorf("Верный ключ отклонен по заголовку: %v", err)
+	}
+
+	// Заголовок без контрольного значения по-прежнему принимается с любым ключом
+	header.KeyCheck = nil
+	legacy, err := header.Marshal()
+	if err != nil {
+		t.Fatalf("Ошибка сериализации заголовка: %v", err)
+	}
+	if _, _, err := cripta.NewCipherContextFromHeader(legacy, wrongKey); err != nil {
+		t.Errorf("Заголовок без контрольного значения отклонен: %v", err)
+	}
+
+	if _, _, err := cripta.NewCipherContextFromHeader(headerOnly[:len(headerOnly)-1], key); err == nil {
+		t.Errorf("Заголовок с обрезанным контрольным значением должен отклоняться")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	fmt.Println("\nТЕСТ КОНТЕЙНЕРА SEAL/OPEN")
+
+	configs := []struct {
+		name    string
+		mode    cripta.CipherMode
+		padding cripta.PaddingMode
+		autoIV  bool
+	}{
+		{"des", cripta.CipherModeCBC, cripta.PaddingModePKCS7, false},
+		{"deal192", cripta.CipherModeECB, cripta.PaddingModeANSIX923, false},
+		{"aes128", cripta.CipherModeCTR, cripta.PaddingModeNone, true},
+		{"aes256", cripta.CipherModeCFB, cripta.PaddingModeISO10126, true},
+	}
+
+	for _, cfg := range configs {
+		cipher, keySize, err := CreateCipher(cfg.name)
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+		blockSize := 16
+		if cfg.name == "des" {
+			blockSize = 8
+		}
+		var iv []byte
+		if cfg.mode != cripta.CipherModeECB {
+			iv = generateRandomBytes(blockSize)
+		}
+
+		ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cfg.mode, cfg.padding, iv, blockSize, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		ctx.SetAutoIV(cfg.autoIV)
+
+		for _, size := range []int{0, 1, blockSize, 3*blockSize + 5} {
+			plaintext := generateRandomBytes(size)
+			if plaintext == nil {
+				plaintext = []byte{}
+			}
+
+			container, err := ctx.Seal(plaintext)
+			if err != nil {
+				t.Fatalf("%s: ошибка Seal (%d байт): %v", cfg.name, size, err)
+			}
+			opened, err := ctx.Open(container)
+			if err != nil {
+				t.Fatalf("%s: ошибка Open (%d байт): %v", cfg.name, size, err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Errorf("%s: данные не совпадают после Seal/Open (%d байт)", cfg.name, size)
+			}
+		}
+	}
+}
+
+func TestOpenTruncatedContainer(t *testing.T) {
+	fmt.Println("\nТЕСТ ОБРЕЗАННОГО КОНТЕЙНЕРА")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	container, err := ctx.Seal([]byte("Содержимое самодостаточного контейнера"))
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+
+	for length := 0; length < len(container); length++ {
+		if _, err := ctx.Open(container[:length]); err == nil {
+			t.Fatalf("Контейнер, обрезанный до %d байт, должен отклоняться", length)
+		}
+	}
+
+	if _, err := ctx.Open(append(append([]byte(nil), container...), 0)); err == nil {
+		t.Errorf("Контейнер с лишними байтами в конце должен отклоняться")
+	}
+
+	tampered := append([]byte(nil), container...)
+	tampered[len(tampered)-32-2] ^= 1 // последний байт шифртекста перед taglen и 32-байтовым тегом
+	if _, err := ctx.Open(tampered); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Измененный шифртекст: ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+}
+
+// TestContainerHeaderAuthenticated проверяет, что заголовок контейнера защищен тегом:
+// изменение алгоритма, режима, набивки или IV дает ошибку аутентификации
+func TestContainerHeaderAuthenticated(t *testing.T) {
+	fmt.Println("\nТЕСТ АУТЕНТИФИКАЦИИ ЗАГОЛОВКА КОНТЕЙНЕРА")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	container, err := ctx.Seal([]byte("Заголовок входит в аутентифицируемые данные"))
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+
+	// magic(4) | version | algo | mode | padding | ivlen | iv
+	fields := []struct {
+		name   string
+		offset int
+		value  byte
+	}{
+		{"алгоритм", 5, byte(cripta.AlgorithmAES256)},
+		{"режим", 6, byte(cripta.CipherModeCFB)},
+		{"набивка", 7, byte(cripta.PaddingModeANSIX923)},
+		{"IV", 9, container[9] ^ 0x01},
+	}
+
+	for _, field := range fields {
+		tampered := append([]byte(nil), container...)
+		tampered[field.offset] = field.value
+		if _, err := ctx.Open(tampered); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+			t.Errorf("Измененный заголовок (%s): ожидалась ErrAuthenticationFailed, получено %v", field.name, err)
+		}
+	}
+}
+
+// TestOpenAuto открывает контейнер DES-CBC, зная только ключ
+func TestOpenAuto(t *testing.T) {
+	fmt.Println("\nТЕСТ ОТКРЫТИЯ КОНТЕЙНЕРА ПО ОДНОМУ КЛЮЧУ")
+
+	key := generateRandomBytes(8)
+	cipher, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7,
+		generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	plaintext := []byte("Алгоритм и режим берутся из заголовка контейнера")
+	container, err := ctx.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+
+	opened, err := cripta.OpenAuto(container, key)
+	if err != nil {
+		t.Fatalf("Ошибка OpenAuto: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Данные не совпадают после OpenAuto: %q", opened)
+	}
+
+	wrongKey := append([]byte(nil), key...)
+	wrongKey[0] ^= 0x80
+	if _, err := cripta.OpenAuto(container, wrongKey); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Неверный ключ: ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+	if _, err := cripta.OpenAuto(container, generateRandomBytes(16)); err == nil {
+		t.Errorf("Ключ неверной длины должен отклоняться")
+	}
+}
+
+// TestContainerNonStandardCipher проверяет, что шифр с нестандартными параметрами,
+// неотличимый по имени от стандартного, не упаковывается в контейнер: OpenAuto
+// восстановил бы стандартный шифр и вернул мусор без ошибки
+func TestContainerNonStandardCipher(t *testing.T) {
+	fmt.Println("\nТЕСТ КОНТЕЙНЕРА С НЕСТАНДАРТНЫМ ШИФРОМ")
+
+	rijndael, err := cripta.NewRijndaelCipher(16, 16, 0x1D)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(rijndael, generateRandomBytes(16), cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if _, err := ctx.Seal([]byte("модуль 0x11D вместо 0x11B")); err == nil {
+		t.Errorf("Rijndael с модулем 0x1D не должен упаковываться под идентификатором AES")
+	}
+
+	des, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err = cripta.NewCipherContext(des, generateRandomBytes(8), cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if err := ctx.SetRounds(8); err != nil {
+		t.Fatalf("Ошибка изменения числа раундов: %v", err)
+	}
+	if _, err := ctx.Seal([]byte("ослабленный DES")); err == nil {
+		t.Errorf("DES с 8 раундами не должен упаковываться под идентификатором DES")
+	}
+}
+
+// TestContainerKeyExpiry проверяет срок действия ключа в заголовке контейнера:
+// истекший срок дает ErrKeyExpired, будущий не мешает открытию, а подмена срока
+// обнаруживается тегом
+func TestContainerKeyExpiry(t *testing.T) {
+	fmt.Println("\nТЕСТ СРОКА ДЕЙСТВИЯ КЛЮЧА В КОНТЕЙНЕРЕ")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key := generateRandomBytes(keySize)
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	plaintext := []byte("Ключ действует ограниченное время")
+
+	ctx.SetKeyExpiry(time.Now().Add(-time.Hour))
+	expired, err := ctx.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+	if _, err := ctx.Open(expired); !errors.Is(err, cripta.ErrKeyExpired) {
+		t.Errorf("Истекший ключ: ожидалась ErrKeyExpired, получено %v", err)
+	}
+
+	ctx.SetKeyExpiry(time.Now().Add(time.Hour))
+	valid, err := ctx.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+	opened, err := ctx.Open(valid)
+	if err != nil {
+		t.Fatalf("Ошибка Open до истечения срока: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Данные не совпадают после Seal/Open: %q", opened)
+	}
+
+	// magic(4) | version | algo | mode | padding | ivlen | iv(16) | saltlen | expires(8)
+	tampered := append([]byte(nil), expired...)
+	copy(tampered[26:34], make([]byte, 8))
+	if _, err := ctx.Open(tampered); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Удаленный срок действия: ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+
+	ctx.SetKeyExpiry(time.Time{})
+	unlimited, err := ctx.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Ошибка Seal: %v", err)
+	}
+	if _, err := cripta.OpenAuto(unlimited, key); err != nil {
+		t.Errorf("Контейнер без срока действия: %v", err)
+	}
+}
+
+func TestANSIX923Padding(t *testing.T) {
+	fmt.Println("\nТЕСТ СНЯТИЯ НАБИВКИ ANSI X.923")
+
+	key := generateRandomBytes(8)
+	newContext := func(padding cripta.PaddingMode) *cripta.CipherContext {
+		cipher, err := cripta.NewDESCipher()
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+		ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, padding, nil, 8, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		return ctx
+	}
+	ansi := newContext(cripta.PaddingModeANSIX923)
+	raw := newContext(cripta.PaddingModeNone)
+
+	// Последний байт данных совпадает с правдоподобной длиной набивки
+	plaintexts := [][]byte{
+		[]byte("abc\x00\x00\x00\x00\x05"),
+		[]byte("abcdef\x00\x02"),
+		[]byte("abcdef\x01"),
+		[]byte("\x00\x00\x00\x00\x00\x00\x00\x08"),
+		[]byte("0123456789\x00\x00\x00\x00\x03"),
+	}
+	for _, plaintext := range plaintexts {
+		encrypted, err := ansi.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования %x: %v", plaintext, err)
+		}
+		decrypted, err := ansi.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования %x: %v", plaintext, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Набивка снята неверно: %x, ожидалось %x", decrypted, plaintext)
+		}
+	}
+
+	// Расшифрованный последний блок задан явно
+	blocks := []struct {
+		block    []byte
+		expected []byte
+	}{
+		{[]byte("abcdef\x00\x02"), []byte("abcdef")},
+		{[]byte("abcdefg\x01"), []byte("abcdefg")},
+		{[]byte("\x00\x00\x00\x00\x00\x00\x00\x08"), []byte{}},
+		{[]byte("abcde\x01\x00\x03"), []byte("abcde\x01\x00\x03")}, // ненулевой байт в набивке
+		{[]byte("abcdefgh"), []byte("abcdefgh")},                   // длина набивки больше блока
+		{[]byte("abcdefg\x00"), []byte("abcdefg\x00")},             // нулевая длина набивки
+	}
+	for _, tc := range blocks {
+		encrypted, err := raw.Encrypt(tc.block)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования блока %x: %v", tc.block, err)
+		}
+		decrypted, err := ansi.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования блока %x: %v", tc.block, err)
+		}
+		if !bytes.Equal(decrypted, tc.expected) {
+			t.Errorf("Блок %x: получено %x, ожидалось %x", tc.block, decrypted, tc.expected)
+		}
+	}
+}
+
+// TestISO7816Padding проверяет набивку ISO/IEC 7816-4 для данных, кратных и
+// не кратных блоку, включая данные с байтами 0x80 и нулями в конце
+func TestISO7816Padding(t *testing.T) {
+	fmt.Println("\nТЕСТ НАБИВКИ ISO/IEC 7816-4")
+
+	key := generateRandomBytes(8)
+	newContext := func(padding cripta.PaddingMode) *cripta.CipherContext {
+		cipher, err := cripta.NewDESCipher()
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+		ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, padding, nil, 8, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		return ctx
+	}
+	iso := newContext(cripta.PaddingModeISO7816)
+	raw := newContext(cripta.PaddingModeNone)
+
+	tests := []struct {
+		plaintext []byte
+		padded    []byte
+	}{
+		{[]byte("abc"), []byte("abc\x80\x00\x00\x00\x00")},
+		{[]byte("abcdefg"), []byte("abcdefg\x80")},
+		{[]byte("abcdefgh"), []byte("abcdefgh\x80\x00\x00\x00\x00\x00\x00\x00")}, // набивка занимает весь блок
+		{[]byte{}, []byte("\x80\x00\x00\x00\x00\x00\x00\x00")},
+		{[]byte("abcdef\x80\x00"), []byte("abcdef\x80\x00\x80\x00\x00\x00\x00\x00\x00\x00")},
+		{[]byte("abc\x00\x00"), []byte("abc\x00\x00\x80\x00\x00")},
+	}
+	for _, tc := range tests {
+		encrypted, err := iso.Encrypt(tc.plaintext)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования %x: %v", tc.plaintext, err)
+		}
+		padded, err := raw.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования %x: %v", tc.plaintext, err)
+		}
+		if !bytes.Equal(padded, tc.padded) {
+			t.Errorf("Данные %x дополнены как %x, ожидалось %x", tc.plaintext, padded, tc.padded)
+		}
+		decrypted, err := iso.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования %x: %v", tc.plaintext, err)
+		}
+		if !bytes.Equal(decrypted, tc.plaintext) {
+			t.Errorf("Набивка снята неверно: %x, ожидалось %x", decrypted, tc.plaintext)
+		}
+	}
+
+	// Блок без байта 0x80 в конце не содержит корректной набивки и не изменяется
+	for _, block := range [][]byte{[]byte("abcdefgh"), make([]byte, 8)} {
+		encrypted, err := raw.Encrypt(block)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования блока %x: %v", block, err)
+		}
+		decrypted, err := iso.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования блока %x: %v", block, err)
+		}
+		if !bytes.Equal(decrypted, block) {
+			t.Errorf("Блок %x: получено %x, ожидалось без изменений", block, decrypted)
+		}
+	}
+}
+
+func TestBenchmarkMatrix(t *testing.T) {
+	fmt.Println("\nТЕСТ МАТРИЦЫ ЗАМЕРОВ СКОРОСТИ")
+
+	algos := []string{"des", "aes128", "aes256"}
+	modes := []cripta.CipherMode{cripta.CipherModeECB, cripta.CipherModeCBC, cripta.CipherModeCTR}
+
+	results := cripta.BenchmarkMatrix(generateRandomBytes(4096), algos, modes)
+	if len(results) != len(algos)*len(modes) {
+		t.Fatalf("Получено %d результатов, ожидалось %d", len(results), len(algos)*len(modes))
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("%s, режим %d: %v", result.Algorithm, result.Mode, result.Err)
+			continue
+		}
+		if result.EncryptMBs <= 0 || result.DecryptMBs <= 0 {
+			t.Errorf("%s, режим %d: некорректная скорость %.2f/%.2f MB/s",
+				result.Algorithm, result.Mode, result.EncryptMBs, result.DecryptMBs)
+		}
+	}
+
+	results = cripta.BenchmarkMatrix([]byte("data"), []string{"unknown"}, modes[:1])
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("Неизвестный алгоритм должен давать ошибку в результате")
+	}
+}
+
+func TestBenchCLI(t *testing.T) {
+	fmt.Println("\nТЕСТ ЗАМЕРА СКОРОСТИ ИЗ КОМАНДНОЙ СТРОКИ")
+
+	var stdout bytes.Buffer
+	if err := run([]string{"-bench=1KB"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("Ошибка замера: %v", err)
+	}
+	output := stdout.String()
+	for _, want := range []string{"des ", "aes128 ", " cts ", " random "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("В выводе замера нет %q:\n%s", want, output)
+		}
+	}
+	for _, alias := range []string{"rijndael ", "deal "} {
+		if strings.Contains(output, alias) {
+			t.Errorf("Псевдоним %q не должен замеряться повторно:\n%s", alias, output)
+		}
+	}
+	if rows := strings.Count(output, "\ndes "); rows != 8 {
+		t.Errorf("Для DES выведено %d режимов, ожидалось 8", rows)
+	}
+	if strings.Contains(output, "ошибка") || strings.Contains(output, "Inf") {
+		t.Errorf("Замер не должен содержать ошибок и бесконечных скоростей:\n%s", output)
+	}
+
+	if err := run([]string{"-bench=abc"}, io.Discard, io.Discard); err == nil {
+		t.Errorf("Неверный размер данных для замера должен отклоняться")
+	}
+}
+
+func TestEncryptImageECB(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЕМОНСТРАЦИИ ECB НА ИЗОБРАЖЕНИИ")
+
+	// Изображение 32x8 RGBA из горизонтальных полос двух цветов:
+	// каждая строка состоит из восьми одинаковых 16-байтовых блоков
+	const width, height = 32, 8
+	pixels := make([]byte, 0, width*height*4)
+	for y := 0; y < height; y++ {
+		color := []byte{0xff, 0x00, 0x00, 0xff}
+		if y%2 == 1 {
+			color = []byte{0x00, 0x00, 0xff, 0xff}
+		}
+		for x := 0; x < width; x++ {
+			pixels = append(pixels, color...)
+		}
+	}
+
+	countDistinctBlocks := func(data []byte) int {
+		distinct := make(map[string]bool)
+		for i := 0; i+16 <= len(data); i += 16 {
+			distinct[string(data[i:i+16])] = true
+		}
+		return len(distinct)
+	}
+
+	ecb, err := cripta.EncryptImageECB(pixels, width, height)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования ECB: %v", err)
+	}
+	cbc, err := cripta.EncryptImageCBC(pixels, width, height)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования CBC: %v", err)
+	}
+	if len(ecb) != len(pixels) || len(cbc) != len(pixels) {
+		t.Fatalf("Длина результата %d/%d, ожидалась %d", len(ecb), len(cbc), len(pixels))
+	}
+
+	if n := countDistinctBlocks(ecb); n != 2 {
+		t.Errorf("ECB: %d различных блоков шифртекста, ожидалось 2 (по числу цветов)", n)
+	}
+	if n := countDistinctBlocks(cbc); n != len(pixels)/16 {
+		t.Errorf("CBC: %d различных блоков шифртекста из %d", n, len(pixels)/16)
+	}
+
+	// Буфер 7x5 RGB не кратен размеру блока
+	odd, err := cripta.EncryptImageECB(make([]byte, 7*5*3), 7, 5)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования невыровненного буфера: %v", err)
+	}
+	if len(odd) != 7*5*3 {
+		t.Errorf("Длина результата %d, ожидалась %d", len(odd), 7*5*3)
+	}
+
+	if _, err := cripta.EncryptImageECB(make([]byte, 10), 3, 3); err == nil {
+		t.Errorf("Буфер, не соответствующий размерам изображения, должен отклоняться")
+	}
+}
+
+func TestCipherModeRequiresIV(t *testing.T) {
+	fmt.Println("\nТЕСТ ОПРЕДЕЛЕНИЯ РЕЖИМОВ С IV")
+
+	expected := map[cripta.CipherMode]bool{
+		cripta.CipherModeECB:         false,
+		cripta.CipherModeCBC:         true,
+		cripta.CipherModePCBC:        true,
+		cripta.CipherModeCFB:         true,
+		cripta.CipherModeOFB:         true,
+		cripta.CipherModeCTR:         true,
+		cripta.CipherModeRandomDelta: false,
+		cripta.CipherModeCBC_CTS:     true,
+	}
+	for mode, requires := range expected {
+		if got := mode.RequiresIV(); got != requires {
+			t.Errorf("Режим %d: RequiresIV = %v, ожидалось %v", mode, got, requires)
+		}
+	}
+
+	for mode, requires := range expected {
+		cipher, err := cripta.NewDESCipher()
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+		_, err = cripta.NewCipherContext(cipher, generateRandomBytes(8), mode, cripta.PaddingModePKCS7,
+			generateRandomBytes(5), 8, false)
+		if requires && err == nil {
+			t.Errorf("Режим %d: IV неверной длины должен отклоняться", mode)
+		}
+		if !requires && err != nil {
+			t.Errorf("Режим %d: IV не используется и не должен проверяться: %v", mode, err)
+		}
+	}
+}
+
+func TestDEALMultiBlock(t *testing.T) {
+	fmt.Println("\nТЕСТ DEAL: ОДИН БЛОК В ШИФРЕ, НЕСКОЛЬКО В КОНТЕКСТЕ")
+
+	cipher, err := cripta.NewDEALCipher(16)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key := generateRandomBytes(16)
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+
+	input := generateRandomBytes(32)
+	if _, err := cipher.EncryptBlock(input); err == nil {
+		t.Errorf("EncryptBlock должен отклонять 32-байтовый вход")
+	}
+	if _, err := cipher.DecryptBlock(input); err == nil {
+		t.Errorf("DecryptBlock должен отклонять 32-байтовый вход")
+	}
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, cripta.PaddingModeNone, nil, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	encrypted, err := ctx.Encrypt(input)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования 32 байт в контексте: %v", err)
+	}
+	for i := 0; i < len(input); i += 16 {
+		block, err := cipher.EncryptBlock(input[i : i+16])
+		if err != nil {
+			t.Fatalf("Ошибка шифрования блока: %v", err)
+		}
+		if !bytes.Equal(block, encrypted[i:i+16]) {
+			t.Errorf("Блок %d шифртекста контекста не совпадает с EncryptBlock", i/16)
+		}
+	}
+
+	decrypted, err := ctx.Decrypt(encrypted)
+	if err != nil || !bytes.Equal(decrypted, input) {
+		t.Errorf("32 байта не восстановлены после дешифрования: %v", err)
+	}
+}
+
+func TestIVReuseDetection(t *testing.T) {
+	fmt.Println("\nТЕСТ ОБНАРУЖЕНИЯ ПОВТОРА IV")
+
+	cipher, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCBC,
+		cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ctx.SetIVReuseDetection(true)
+
+	plaintext := []byte("сообщение")
+	if _, err := ctx.Encrypt(plaintext); err != nil {
+		t.Fatalf("Ошибка первого шифрования: %v", err)
+	}
+	if _, err := ctx.Encrypt(plaintext); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Fatalf("Повтор фиксированного IV: ожидалась ErrIVReuse, получено %v", err)
+	}
+
+	ctx.ClearIVHistory()
+	if _, err := ctx.Encrypt(plaintext); err != nil {
+		t.Errorf("После очистки истории IV снова разрешен: %v", err)
+	}
+	if _, err := ctx.Encrypt(plaintext); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("Обнаружение повтора должно оставаться включенным после очистки, получено %v", err)
+	}
+
+	// Новые IV из источника не считаются повтором, пока источник их не повторит
+	ctx.SetIVSource(cripta.NewCounterIVSource(0))
+	ctx.SetAutoIV(true)
+	for i := 0; i < 100; i++ {
+		if _, err := ctx.Encrypt(plaintext); err != nil {
+			t.Fatalf("Сообщение %d: %v", i, err)
+		}
+	}
+	ctx.SetIVSource(cripta.NewCounterIVSource(50))
+	if _, err := ctx.Encrypt(plaintext); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("Повтор IV из источника: ожидалась ErrIVReuse, получено %v", err)
+	}
+
+	ctx.SetIVReuseDetection(false)
+	if _, err := ctx.Encrypt(plaintext); err != nil {
+		t.Errorf("При выключенном обнаружении повтор разрешен: %v", err)
+	}
+
+	// Потоковые и побайтовые API проверяют повтор так же, как Encrypt
+	stream, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCTR,
+		cripta.PaddingModeNone, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	stream.SetIVReuseDetection(true)
+	if err := stream.EncryptStream(bytes.NewReader(plaintext), io.Discard, 64); err != nil {
+		t.Fatalf("Ошибка первого потокового шифрования: %v", err)
+	}
+	if err := stream.EncryptStream(bytes.NewReader(plaintext), io.Discard, 64); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("EncryptStream: ожидалась ErrIVReuse, получено %v", err)
+	}
+	if _, err := stream.EncryptStreamWithMAC(bytes.NewReader(plaintext), io.Discard, generateRandomBytes(32)); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("EncryptStreamWithMAC: ожидалась ErrIVReuse, получено %v", err)
+	}
+	if _, err := cripta.NewEncryptingWriter(stream, io.Discard); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("NewEncryptingWriter: ожидалась ErrIVReuse, получено %v", err)
+	}
+	if err := stream.EncryptInPlace(make([]byte, 16)); !errors.Is(err, cripta.ErrIVReuse) {
+		t.Errorf("EncryptInPlace: ожидалась ErrIVReuse, получено %v", err)
+	}
+}
+
+func TestCipherModeIsAuthenticated(t *testing.T) {
+	fmt.Println("\nТЕСТ ПРЕДУПРЕЖДЕНИЯ О РЕЖИМАХ БЕЗ АУТЕНТИФИКАЦИИ")
+
+	if !cripta.CipherModeGCM.IsAuthenticated() || !cripta.CipherModeSIV.IsAuthenticated() {
+		t.Errorf("GCM и SIV должны считаться режимами с аутентификацией")
+	}
+	for _, mode := range []cripta.CipherMode{cripta.CipherModeECB, cripta.CipherModeCBC, cripta.CipherModeCTR} {
+		if mode.IsAuthenticated() {
+			t.Errorf("Режим %d не обеспечивает аутентификацию", mode)
+		}
+	}
+
+	cipher, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if _, err := cripta.NewCipherContext(cipher, generateRandomBytes(16), cripta.CipherModeGCM,
+		cripta.PaddingModeNone, nil, 16, false); err == nil {
+		t.Errorf("CipherContext не должен принимать режим GCM")
+	}
+
+	root := t.TempDir()
+	input := filepath.Join(root, "input.txt")
+	if err := os.WriteFile(input, []byte("данные"), 0644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	if err := run([]string{"-e", "-a=des", "-m=cbc", input, filepath.Join(root, "a.enc")}, io.Discard, &stderr); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "не обеспечивает аутентификацию") {
+		t.Errorf("Ожидалось предупреждение в stderr, получено %q", stderr.String())
+	}
+
+	stderr.Reset()
+	args := []string{"-e", "-noauthwarning", "-a=des", "-m=cbc", input, filepath.Join(root, "b.enc")}
+	if err := run(args, io.Discard, &stderr); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("С -noauthwarning stderr должен быть пустым, получено %q", stderr.String())
+	}
+}
+
+func TestCascadeCipher(t *testing.T) {
+	fmt.Println("\nТЕСТ КАСКАДНОГО ШИФРОВАНИЯ")
+
+	des, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	aes, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if _, err := cripta.NewCascadeCipher(des, aes); err == nil {
+		t.Errorf("Каскад DES и Rijndael-128 с разными размерами блока должен отклоняться")
+	}
+
+	first, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	second, err := cripta.NewRijndaelCipher(16, 32, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	cascade, err := cripta.NewCascadeCipher(first, second)
+	if err != nil {
+		t.Fatalf("Ошибка создания каскада: %v", err)
+	}
+
+	key := generateRandomBytes(16 + 32)
+	ctx, err := cripta.NewCipherContext(cascade, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7,
+		generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	plaintext := []byte("Данные, зашифрованные двумя шифрами подряд")
+	encrypted, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	decrypted, err := ctx.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Данные не совпадают после каскадного шифрования")
+	}
+
+	// Блок каскада равен последовательному шифрованию каждым шифром
+	block := generateRandomBytes(16)
+	viaCascade, err := cascade.EncryptBlock(block)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования блока: %v", err)
+	}
+	step, _ := first.EncryptBlock(block)
+	step, _ = second.EncryptBlock(step)
+	if !bytes.Equal(viaCascade, step) {
+		t.Errorf("Каскад должен применять шифры по порядку")
+	}
+}
+
+func TestSecurityStrengthBits(t *testing.T) {
+	fmt.Println("\nТЕСТ ОЦЕНКИ СТОЙКОСТИ")
+
+	newContext := func(cipher cripta.ISymmetricCipher, keySize, blockSize int) *cripta.CipherContext {
+		ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cripta.CipherModeECB,
+			cripta.PaddingModePKCS7, nil, blockSize, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		return ctx
+	}
+
+	des, _ := cripta.NewDESCipher()
+	if bits := newContext(des, 8, 8).SecurityStrengthBits(); bits != 56 {
+		t.Errorf("DES: %d бит, ожидалось 56", bits)
+	}
+
+	rijndael, _ := cripta.NewRijndaelCipher(16, 32, 0x1B)
+	if bits := newContext(rijndael, 32, 16).SecurityStrengthBits(); bits != 256 {
+		t.Errorf("Rijndael-256: %d бит, ожидалось 256", bits)
+	}
+
+	deal, _ := cripta.NewDEALCipher(16)
+	if bits := newContext(deal, 16, 16).SecurityStrengthBits(); bits != 112 {
+		t.Errorf("DEAL-128: %d бит, ожидалось 112", bits)
+	}
+
+	// Двойной DES из-за встречи посередине не стойче одинарного, тройной дает 112 бит
+	for _, tc := range []struct {
+		count    int
+		expected int
+	}{{2, 56}, {3, 112}} {
+		ciphers := make([]cripta.ISymmetricCipher, tc.count)
+		for i := range ciphers {
+			ciphers[i], _ = cripta.NewDESCipher()
+		}
+		cascade, err := cripta.NewCascadeCipher(ciphers...)
+		if err != nil {
+			t.Fatalf("Ошибка создания каскада: %v", err)
+		}
+		if bits := newContext(cascade, 8*tc.count, 8).SecurityStrengthBits(); bits != tc.expected {
+			t.Errorf("Каскад из %d DES: %d бит, ожидалось %d", tc.count, bits, tc.expected)
+		}
+	}
+}
+
+// TestPercentile проверяет вычисление перцентилей для сводки скорости
+func TestPercentile(t *testing.T) {
+	fmt.Println("\nТЕСТ ПЕРЦЕНТИЛЕЙ СКОРОСТИ")
+
+	values := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+
+	tests := []struct {
+		name   string
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{"пустой набор", nil, 50, 0},
+		{"одно значение", []float64{7}, 95, 7},
+		{"медиана четного набора", values, 50, 50.5},
+		{"p95", values, 95, 95.05},
+		{"минимум", values, 0, 1},
+		{"максимум", values, 100, 100},
+		{"интерполяция", []float64{10, 20}, 25, 12.5},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: percentile(%v) = %v, ожидалось %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+
+	if values[0] != 100 {
+		t.Error("percentile не должна изменять исходный срез")
+	}
+
+	stats := computeThroughputStats([]TestResult{
+		{success: true, encryptSpeedKBs: 10, decryptSpeedKBs: 30},
+		{success: false, encryptSpeedKBs: 1000, decryptSpeedKBs: 1000},
+		{success: true, encryptSpeedKBs: 20, decryptSpeedKBs: 40},
+	})
+	if stats.samples != 2 || stats.encryptP50 != 15 || stats.decryptP50 != 35 {
+		t.Errorf("неуспешные тесты не должны учитываться: %+v", stats)
+	}
+}
+
+// TestNonceCounter проверяет IV из номера сообщения: потоки CTR соседних номеров
+// не пересекаются, один номер дает один и тот же поток, префикс IV сохраняется
+func TestNonceCounter(t *testing.T) {
+	fmt.Println("\nТЕСТ IV ИЗ НОМЕРА СООБЩЕНИЯ")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key := generateRandomBytes(keySize)
+	prefix := generateRandomBytes(16)
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCTR, cripta.PaddingModeNone, prefix, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	// Ключевой поток сообщения - шифртекст нулевых данных
+	const blocks = 8
+	keystream := func(n uint64) []byte {
+		if err := ctx.SetNonceCounter(n); err != nil {
+			t.Fatalf("Ошибка установки номера %d: %v", n, err)
+		}
+		stream, err := ctx.Encrypt(make([]byte, blocks*16))
+		if err != nil {
+			t.Fatalf("Ошибка шифрования сообщения %d: %v", n, err)
+		}
+		return stream
+	}
+
+	seen := make(map[string]uint64)
+	for n := uint64(0); n < 4; n++ {
+		stream := keystream(n)
+		for i := 0; i < blocks; i++ {
+			block := string(stream[i*16 : (i+1)*16])
+			if other, ok := seen[block]; ok {
+				t.Fatalf("Блок %d потока сообщения %d совпадает с блоком сообщения %d", i, n, other)
+			}
+			seen[block] = n
+		}
+	}
+
+	if !bytes.Equal(keystream(2), keystream(2)) {
+		t.Errorf("Один и тот же номер должен давать один и тот же поток")
+	}
+
+	// Номер занимает байты 4..11, префикс - старшие 4 байта исходного IV
+	nonceCipher, _, _ := CreateCipher("aes128")
+	nonceCipher.SetKey(key)
+	expectedIV := append(append([]byte{}, prefix[:4]...), 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0)
+	firstBlock, _ := nonceCipher.EncryptBlock(expectedIV)
+	if got := keystream(7); !bytes.Equal(got[:16], firstBlock) {
+		t.Errorf("IV сообщения 7 должен быть префикс || 7 || 0")
+	}
+
+	ecb, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, cripta.PaddingModeNone, nil, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if err := ecb.SetNonceCounter(1); err == nil {
+		t.Errorf("Номер сообщения вне режима CTR должен отклоняться")
+	}
+
+	desCipher, _, _ := CreateCipher("des")
+	des, err := cripta.NewCipherContext(desCipher, generateRandomBytes(8), cripta.CipherModeCTR, cripta.PaddingModeNone, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if err := des.SetNonceCounter(1); err == nil {
+		t.Errorf("Для 8-байтового блока номер сообщения должен отклоняться")
+	}
+}
+
+// TestSelfTest проверяет, что самопроверка проходит на корректной сборке и укладывается в разумное время
+func TestSelfTest(t *testing.T) {
+	fmt.Println("\nТЕСТ САМОПРОВЕРКИ")
+
+	start := time.Now()
+	if err := cripta.SelfTest(); err != nil {
+		t.Fatalf("Самопроверка не прошла: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Самопроверка заняла %v, ожидалось не больше секунды", elapsed)
+	}
+}
+
+// TestDecryptInto сравнивает расшифровку в готовый буфер с Decrypt во всех
+// поддерживаемых режимах и проверяет отказ при недостаточном буфере
+func TestDecryptInto(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЕШИФРОВАНИЯ В ГОТОВЫЙ БУФЕР")
+
+	modes := []struct {
+		mode     cripta.CipherMode
+		modeName string
+	}{
+		{cripta.CipherModeECB, "ECB"},
+		{cripta.CipherModeCBC, "CBC"},
+		{cripta.CipherModePCBC, "PCBC"},
+		{cripta.CipherModeCFB, "CFB"},
+		{cripta.CipherModeOFB, "OFB"},
+		{cripta.CipherModeCTR, "CTR"},
+	}
+
+	key := generateRandomBytes(16)
+	iv := generateRandomBytes(16)
+
+	for _, m := range modes {
+		for _, autoIV := range []bool{false, true} {
+			cipher, _, _ := CreateCipher("aes128")
+			ctx, err := cripta.NewCipherContext(cipher, key, m.mode, cripta.PaddingModePKCS7, iv, 16, false)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+			ctx.SetAutoIV(autoIV)
+
+			for _, size := range []int{0, 1, 16, 37, 16 * 10} {
+				data := make([]byte, size)
+				rand.Read(data)
+
+				encrypted, err := ctx.Encrypt(data)
+				if err != nil {
+					t.Fatalf("%s: ошибка шифрования: %v", m.modeName, err)
+				}
+				expected, err := ctx.Decrypt(encrypted)
+				if err != nil {
+					t.Fatalf("%s: ошибка дешифрования: %v", m.modeName, err)
+				}
+
+				dst := make([]byte, len(encrypted))
+				n, err := ctx.DecryptInto(dst, encrypted)
+				if err != nil {
+					t.Fatalf("%s (AutoIV %v, %d байт): ошибка DecryptInto: %v", m.modeName, autoIV, size, err)
+				}
+				if !bytes.Equal(dst[:n], expected) || !bytes.Equal(dst[:n], data) {
+					t.Errorf("%s (AutoIV %v, %d байт): DecryptInto отличается от Decrypt", m.modeName, autoIV, size)
+				}
+
+				if len(encrypted) > 0 && !autoIV {
+					if _, err := ctx.DecryptInto(make([]byte, len(encrypted)-1), encrypted); err == nil {
+						t.Errorf("%s: недостаточный буфер должен отклоняться", m.modeName)
+					}
+				}
+			}
+		}
+	}
+
+	cipher, _, _ := CreateCipher("aes128")
+	ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC_CTS, cripta.PaddingModeNone, iv, 16, false)
+	if _, err := ctx.DecryptInto(make([]byte, 64), make([]byte, 32)); err == nil {
+		t.Errorf("Режим CTS должен отклоняться")
+	}
+
+	// Перекрывающиеся буферы отклоняются, в том числе при сдвиге на часть блока
+	cipher, _, _ = CreateCipher("aes128")
+	ctx, _ = cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	encrypted, _ := ctx.Encrypt(generateRandomBytes(40))
+	for _, shift := range []int{0, 5, 16} {
+		buf := make([]byte, len(encrypted)+shift)
+		copy(buf[shift:], encrypted)
+		if _, err := ctx.DecryptInto(buf[:len(encrypted)], buf[shift:]); err == nil {
+			t.Errorf("Перекрывающиеся буферы со сдвигом %d должны отклоняться", shift)
+		}
+	}
+}
+
+// TestCipherRounds проверяет число раундов DES и DEAL и его изменение через SetRounds
+func TestCipherRounds(t *testing.T) {
+	fmt.Println("\nТЕСТ ЧИСЛА РАУНДОВ DES И DEAL")
+
+	des, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания DES: %v", err)
+	}
+	if des.Rounds() != 16 {
+		t.Errorf("DES: %d раундов, ожидалось 16", des.Rounds())
+	}
+	if err := des.SetRounds(4); err != nil || des.Rounds() != 4 {
+		t.Errorf("DES после SetRounds(4): %d раундов (%v)", des.Rounds(), err)
+	}
+
+	for _, tt := range []struct {
+		keyLength int
+		rounds    int
+	}{{16, 6}, {24, 6}, {32, 8}} {
+		deal, err := cripta.NewDEALCipher(tt.keyLength)
+		if err != nil {
+			t.Fatalf("Ошибка создания DEAL-%d: %v", tt.keyLength*8, err)
+		}
+		if deal.Rounds() != tt.rounds {
+			t.Errorf("DEAL-%d: %d раундов, ожидалось %d", tt.keyLength*8, deal.Rounds(), tt.rounds)
+		}
+		if err := deal.SetRounds(tt.rounds + 1); err == nil {
+			t.Errorf("DEAL-%d: число раундов больше длины расписания должно отклоняться", tt.keyLength*8)
+		}
+	}
+	if err := des.SetRounds(17); err == nil {
+		t.Errorf("DES: больше 16 раундов должно отклоняться")
+	}
+
+	// Сеть с числом раундов больше длины расписания, заданным до ключа:
+	// SetKey возвращает ошибку, а шифрование - ошибку вместо выхода за границы
+	schedule, _ := cripta.NewDEALKeySchedule(16)
+	roundFunction, _ := cripta.NewDEALRoundFunction()
+	feistel, err := cripta.NewFeistelNetwork(schedule, roundFunction, 16, 6)
+	if err != nil {
+		t.Fatalf("Ошибка создания сети Фейстеля: %v", err)
+	}
+	if err := feistel.SetRoundsCount(100); err != nil {
+		t.Fatalf("Ошибка изменения числа раундов до установки ключа: %v", err)
+	}
+	if err := feistel.SetKey(generateRandomBytes(16)); err == nil {
+		t.Errorf("SetKey должен сообщать о нехватке раундовых ключей")
+	}
+	if _, err := feistel.EncryptBlock(generateRandomBytes(16)); err == nil {
+		t.Errorf("Шифрование без полного расписания должно возвращать ошибку")
+	}
+	if _, err := feistel.DecryptBlock(generateRandomBytes(16)); err == nil {
+		t.Errorf("Дешифрование без полного расписания должно возвращать ошибку")
+	}
+}
+
+// TestStreamWithMAC шифрует большой буфер потоком с HMAC и проверяет, что изменение
+// любого байта шифртекста, неверный тег или ключ MAC обнаруживаются
+func TestStreamWithMAC(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОТОКОВОГО ШИФРОВАНИЯ С MAC")
+
+	cipher, keySize, err := CreateCipher("aes128")
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cripta.CipherModeCTR,
+		cripta.PaddingModeNone, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ctx.SetAutoIV(true)
+
+	macKey := generateRandomBytes(32)
+	data := generateRandomBytes(256*1024 + 123)
+
+	var encrypted bytes.Buffer
+	tag, err := ctx.EncryptStreamWithMAC(bytes.NewReader(data), &encrypted, macKey)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	var decrypted bytes.Buffer
+	if err := ctx.DecryptStreamWithMAC(bytes.NewReader(encrypted.Bytes()), &decrypted, macKey, tag); err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if !bytes.Equal(decrypted.Bytes(), data) {
+		t.Fatalf("Данные не совпадают после дешифрования")
+	}
+
+	// Байт IV, середина и последний байт шифртекста
+	for _, offset := range []int{0, encrypted.Len() / 2, encrypted.Len() - 1} {
+		tampered := append([]byte(nil), encrypted.Bytes()...)
+		tampered[offset] ^= 0x01
+		err := ctx.DecryptStreamWithMAC(bytes.NewReader(tampered), io.Discard, macKey, tag)
+		if !errors.Is(err, cripta.ErrAuthenticationFailed) {
+			t.Errorf("Изменение байта %d: ожидалась ErrAuthenticationFailed, получено %v", offset, err)
+		}
+	}
+
+	truncated := encrypted.Bytes()[:encrypted.Len()-16]
+	if err := ctx.DecryptStreamWithMAC(bytes.NewReader(truncated), io.Discard, macKey, tag); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Обрезанный шифртекст: ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+
+	wrongKey := append([]byte(nil), macKey...)
+	wrongKey[0] ^= 0x01
+	if err := ctx.DecryptStreamWithMAC(bytes.NewReader(encrypted.Bytes()), io.Discard, wrongKey, tag); !errors.Is(err, cripta.ErrAuthenticationFailed) {
+		t.Errorf("Неверный ключ MAC: ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+
+	if _, err := ctx.EncryptStreamWithMAC(bytes.NewReader(data), io.Discard, nil); err == nil {
+		t.Errorf("Пустой ключ MAC должен отклоняться")
+	}
+}
+
+// TestSupportedLists проверяет списки алгоритмов, режимов и набивок для интерфейсов
+func TestSupportedLists(t *testing.T) {
+	fmt.Println("\nТЕСТ СПИСКОВ АЛГОРИТМОВ, РЕЖИМОВ И НАБИВОК")
+
+	algorithms := cripta.SupportedAlgorithms()
+	listed := make(map[string]bool)
+	for _, name := range algorithms {
+		listed[name] = true
+	}
+	for _, name := range []string{"des", "deal128", "deal192", "deal256", "rijndael", "aes128", "aes192", "aes256"} {
+		if !listed[name] {
+			t.Errorf("Алгоритм %s отсутствует в списке %v", name, algorithms)
+		}
+	}
+	for i := 1; i < len(algorithms); i++ {
+		if algorithms[i-1] >= algorithms[i] {
+			t.Errorf("Список алгоритмов должен быть отсортирован: %v", algorithms)
+			break
+		}
+	}
+
+	// Каждый режим и каждая набивка из списков принимаются контекстом
+	for _, mode := range cripta.SupportedModes() {
+		for _, padding := range cripta.SupportedPaddings() {
+			cipher, _, _ := CreateCipher("aes128")
+			var iv []byte
+			if mode.RequiresIV() {
+				iv = generateRandomBytes(16)
+			}
+			if _, err := cripta.NewCipherContext(cipher, generateRandomBytes(16), mode, padding, iv, 16, false); err != nil {
+				t.Errorf("Режим %d с набивкой %d из списка отклонен: %v", mode, padding, err)
+			}
+		}
+		if mode.IsAuthenticated() {
+			t.Errorf("Режим %d реализован отдельным типом и не должен быть в списке", mode)
+		}
+	}
+	if len(cripta.SupportedPaddings()) != 6 {
+		t.Errorf("Ожидалось 6 режимов набивки, получено %d", len(cripta.SupportedPaddings()))
+	}
+}
+
+// TestHexBlock проверяет шифрование одного блока в hex на векторе DES
+// и тот же вектор через флаг -block командной строки
+func TestHexBlock(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРОВАНИЯ ОДНОГО БЛОКА В HEX")
+
+	cipher, _, _ := CreateCipher("des")
+	encrypted, err := cripta.EncryptHexBlock(cipher, "133457799BBCDFF1", "01 23 45 67 89 AB CD EF")
+	if err != nil || encrypted != "85e813540f0ab405" {
+		t.Errorf("EncryptHexBlock: %s, ожидалось 85e813540f0ab405 (%v)", encrypted, err)
+	}
+	decrypted, err := cripta.DecryptHexBlock(cipher, "133457799bbcdff1", "85E813540F0AB405")
+	if err != nil || decrypted != "0123456789abcdef" {
+		t.Errorf("DecryptHexBlock: %s, ожидалось 0123456789abcdef (%v)", decrypted, err)
+	}
+
+	if _, err := cripta.EncryptHexBlock(cipher, "133457799BBCDFF1", "0123456789AB"); err == nil {
+		t.Errorf("Блок неверной длины должен отклоняться")
+	}
+	if _, err := cripta.EncryptHexBlock(cipher, "not hex", "0123456789ABCDEF"); err == nil {
+		t.Errorf("Некорректный hex ключа должен отклоняться")
+	}
+
+	var stdout bytes.Buffer
+	if err := run([]string{"-e", "-a=des", "-k=133457799BBCDFF1", "-block=0123456789ABCDEF"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("Ошибка командной строки: %v", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "85e813540f0ab405" {
+		t.Errorf("-block: %s, ожидалось 85e813540f0ab405", got)
+	}
+	if err := run([]string{"-d", "-a=des", "-block=85e813540f0ab405"}, io.Discard, io.Discard); err == nil {
+		t.Errorf("-block без ключа должен отклоняться")
+	}
+}
+
+// xorCipher тестовый шифр для проверки реестра алгоритмов
+type xorCipher struct {
+	key []byte
+}
+
+func (c *xorCipher) Name() string {
+	return "XOR"
+}
+
+func (c *xorCipher) SetKey(key []uint8) error {
+	c.key = append([]byte{}, key...)
+	return nil
+}
+
+func (c *xorCipher) EncryptBlock(block []uint8) ([]uint8, error) {
+	out := make([]uint8, len(block))
+	for i := range block {
+		out[i] = block[i] ^ c.key[i%len(c.key)]
+	}
+	return out, nil
+}
+
+func (c *xorCipher) DecryptBlock(block []uint8) ([]uint8, error) {
+	return c.EncryptBlock(block)
+}
+
+func TestCipherRegistry(t *testing.T) {
+	fmt.Println("\nТЕСТ РЕЕСТРА АЛГОРИТМОВ")
+
+	factory := func(keyLen int) (cripta.ISymmetricCipher, int, error) {
+		if keyLen == 0 {
+			keyLen = 8
+		}
+		return &xorCipher{}, keyLen, nil
+	}
+	if err := cripta.RegisterCipher("test-xor", factory); err != nil {
+		t.Fatalf("Ошибка регистрации шифра: %v", err)
+	}
+	t.Cleanup(func() { cripta.UnregisterCipher("test-xor") })
+
+	if err := cripta.RegisterCipher("Test-XOR", factory); err == nil {
+		t.Errorf("Повторная регистрация должна завершаться ошибкой")
+	}
+
+	cipher, keyLen, err := cripta.NewCipherByName("TEST-XOR", 0)
+	if err != nil {
+		t.Fatalf("Ошибка создания зарегистрированного шифра: %v", err)
+	}
+	if keyLen != 8 {
+		t.Errorf("Длина ключа %d, ожидалось 8", keyLen)
+	}
+
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keyLen), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	msg := []byte("registry round trip")
+	enc, _ := ctx.Encrypt(msg)
+	dec, err := ctx.Decrypt(enc)
+	if err != nil || string(dec) != string(msg) {
+		t.Errorf("Шифрование зарегистрированным шифром некорректно: %v", err)
+	}
+
+	for _, name := range []string{"des", "deal128", "deal192", "deal256", "aes128", "aes192", "aes256"} {
+		if _, _, err := CreateCipher(name); err != nil {
+			t.Errorf("Встроенный шифр %s не зарегистрирован: %v", name, err)
+		}
+	}
+
+	if _, _, err := cripta.NewCipherByName("unknown", 0); err == nil {
+		t.Errorf("Неизвестное имя должно приводить к ошибке")
+	}
+
+	if !cripta.UnregisterCipher("test-xor") || cripta.UnregisterCipher("test-xor") {
+		t.Errorf("Шифр должен удаляться из реестра ровно один раз")
+	}
+	if _, _, err := cripta.NewCipherByName("test-xor", 0); err == nil {
+		t.Errorf("Удаленный шифр не должен создаваться по имени")
+	}
+}
+
+func TestChunkedStreaming(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОТОКОВОЙ ОБРАБОТКИ ПОРЦИЯМИ")
+
+	modes := []struct {
+		mode     cripta.CipherMode
+		modeName string
+	}{
+		{cripta.CipherModeECB, "ECB"},
+		{cripta.CipherModeCBC, "CBC"},
+		{cripta.CipherModePCBC, "PCBC"},
+		{cripta.CipherModeCFB, "CFB"},
+		{cripta.CipherModeOFB, "OFB"},
+		{cripta.CipherModeCTR, "CTR"},
+	}
+
+	data := generateRandomBytes(5000)
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+
+	for _, m := range modes {
+		t.Run(m.modeName, func(t *testing.T) {
+			cipher, _, _ := CreateCipher("des")
+			ctx, err := cripta.NewCipherContext(cipher, key, m.mode, cripta.PaddingModePKCS7, iv, 8, false)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+
+			expected, err := ctx.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Ошибка шифрования: %v", err)
+			}
+
+			for _, chunk := range []int{8, 64, 1000, 4096, 64 * 1024} {
+				var encrypted bytes.Buffer
+				if err := ctx.EncryptStream(bytes.NewReader(data), &encrypted, chunk); err != nil {
+					t.Fatalf("Ошибка потокового шифрования (порция %d): %v", chunk, err)
+				}
+				if !bytes.Equal(encrypted.Bytes(), expected) {
+					t.Fatalf("Шифртекст с порцией %d отличается от Encrypt", chunk)
+				}
+
+				var decrypted bytes.Buffer
+				if err := ctx.DecryptStream(bytes.NewReader(encrypted.Bytes()), &decrypted, chunk); err != nil {
+					t.Fatalf("Ошибка потокового дешифрования (порция %d): %v", chunk, err)
+				}
+				if !bytes.Equal(decrypted.Bytes(), data) {
+					t.Fatalf("Данные после потокового дешифрования (порция %d) не совпадают", chunk)
+				}
+			}
+		})
+	}
+
+	if _, err := parseChunkSize("64KB", 8); err != nil {
+		t.Errorf("Размер 64KB должен приниматься: %v", err)
+	}
+	if _, err := parseChunkSize("100", 8); err == nil {
+		t.Errorf("Размер, не кратный блоку, должен отклоняться")
+	}
+}
+
+func TestInvalidKeyLength(t *testing.T) {
+	fmt.Println("\nТЕСТ НЕВЕРНОЙ ДЛИНЫ КЛЮЧА")
+
+	cases := []struct {
+		algorithm string
+		blockSize int
+		keySize   int
+	}{
+		{"des", 8, 16},
+		{"deal256", 16, 16},
+	}
+
+	for _, tc := range cases {
+		cipher, _, err := CreateCipher(tc.algorithm)
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+
+		_, err = cripta.NewCipherContext(cipher, generateRandomBytes(tc.keySize), cripta.CipherModeCBC, cripta.PaddingModePKCS7, nil, tc.blockSize, false)
+		if !errors.Is(err, cripta.ErrInvalidKeyLength) {
+			t.Errorf("%s с ключом %d байт: ожидалась ErrInvalidKeyLength, получено: %v", tc.algorithm, tc.keySize, err)
+		} else {
+			fmt.Printf("   %s: %v\n", tc.algorithm, err)
+		}
+	}
+}
+
+func TestCipherRNG(t *testing.T) {
+	fmt.Println("\nТЕСТ ГЕНЕРАТОРА НА ОСНОВЕ БЛОЧНОГО ШИФРА")
+
+	for _, algorithm := range []string{"des", "aes128"} {
+		readStream := func(seed []byte) []byte {
+			cipher, _, err := CreateCipher(algorithm)
+			if err != nil {
+				t.Fatalf("Ошибка создания шифра: %v", err)
+			}
+			rng, err := cripta.NewCipherRNG(cipher, seed)
+			if err != nil {
+				t.Fatalf("Ошибка создания генератора: %v", err)
+			}
+
+			// Читаем неровными порциями, чтобы проверить буферизацию
+			stream := make([]byte, 0, 1000)
+			for _, size := range []int{1, 7, 100, 892} {
+				part := make([]byte, size)
+				if _, err := io.ReadFull(rng, part); err != nil {
+					t.Fatalf("Ошибка чтения: %v", err)
+				}
+				stream = append(stream, part...)
+			}
+			return stream
+		}
+
+		first := readStream([]byte("seed-1"))
+		second := readStream([]byte("seed-1"))
+		other := readStream([]byte("seed-2"))
+
+		if !bytes.Equal(first, second) {
+			t.Errorf("%s: одинаковые seed дали разные потоки", algorithm)
+		}
+		if bytes.Equal(first, other) {
+			t.Errorf("%s: разные seed дали одинаковые потоки", algorithm)
+		}
+	}
+}
+
+func TestEncryptInPlace(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРОВАНИЯ НА МЕСТЕ")
+
+	modes := []struct {
+		mode     cripta.CipherMode
+		modeName string
+	}{
+		{cripta.CipherModeECB, "ECB"},
+		{cripta.CipherModeCBC, "CBC"},
+		{cripta.CipherModeCTR, "CTR"},
+		{cripta.CipherModeOFB, "OFB"},
+	}
+
+	key := generateRandomBytes(16)
+	iv := generateRandomBytes(16)
+	data := generateRandomBytes(16 * 20)
+
+	for _, m := range modes {
+		cipher, _, _ := CreateCipher("deal128")
+		ctx, err := cripta.NewCipherContext(cipher, key, m.mode, cripta.PaddingModeNone, iv, 16, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+
+		expected, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("%s: ошибка шифрования: %v", m.modeName, err)
+		}
+
+		buf := append([]byte{}, data...)
+		if err := ctx.EncryptInPlace(buf); err != nil {
+			t.Fatalf("%s: ошибка шифрования на месте: %v", m.modeName, err)
+		}
+		if !bytes.Equal(buf, expected) {
+			t.Errorf("%s: результат шифрования на месте отличается от Encrypt", m.modeName)
+		}
+
+		decrypted, err := ctx.Decrypt(buf)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Errorf("%s: данные после шифрования на месте не расшифровываются: %v", m.modeName, err)
+		}
+
+		if err := ctx.EncryptInPlace(make([]byte, 17)); err == nil {
+			t.Errorf("%s: буфер, не кратный блоку, должен отклоняться", m.modeName)
+		}
+	}
+
+	cipher, _, _ := CreateCipher("deal128")
+	ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if err := ctx.EncryptInPlace(make([]byte, 32)); err == nil {
+		t.Errorf("Шифрование на месте с набивкой PKCS7 должно отклоняться")
+	}
+
+	// С AutoIV шифртекст Encrypt начинается с IV, которому в буфере нет места
+	ctx, _ = cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModeNone, iv, 16, false)
+	ctx.SetAutoIV(true)
+	if err := ctx.EncryptInPlace(make([]byte, 32)); err == nil {
+		t.Errorf("Шифрование на месте с AutoIV должно отклоняться")
+	}
+}
+
+func TestGOSTCipher(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРА ГОСТ 28147-89")
+
+	cipher, err := cripta.NewGOSTCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+
+	// Контрольный пример из ГОСТ Р 34.12-2015 (RFC 8891)
+	key, _ := hex.DecodeString("ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	plain, _ := hex.DecodeString("fedcba9876543210")
+	expected, _ := hex.DecodeString("4ee901e5c2d8ca3d")
+
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+
+	encrypted, err := cipher.EncryptBlock(plain)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !bytes.Equal(encrypted, expected) {
+		t.Errorf("Шифртекст %x, ожидалось %x", encrypted, expected)
+	}
+
+	decrypted, err := cipher.DecryptBlock(encrypted)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if !bytes.Equal(decrypted, plain) {
+		t.Errorf("Расшифровано %x, ожидалось %x", decrypted, plain)
+	}
+
+	gost, keyLen, err := CreateCipher("gost")
+	if err != nil {
+		t.Fatalf("Шифр ГОСТ не зарегистрирован: %v", err)
+	}
+	ctx, err := cripta.NewCipherContext(gost, generateRandomBytes(keyLen), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	msg := []byte("Сообщение, зашифрованное по ГОСТ")
+	enc, _ := ctx.Encrypt(msg)
+	dec, err := ctx.Decrypt(enc)
+	if err != nil || !bytes.Equal(dec, msg) {
+		t.Errorf("Шифрование в режиме CBC некорректно: %v", err)
+	}
+}
+
+func TestStreamModePadding(t *testing.T) {
+	fmt.Println("\nТЕСТ НАБИВКИ В ПОТОКОВЫХ РЕЖИМАХ")
+
+	modes := []struct {
+		mode     cripta.CipherMode
+		modeName string
+	}{
+		{cripta.CipherModeCFB, "CFB"},
+		{cripta.CipherModeOFB, "OFB"},
+		{cripta.CipherModeCTR, "CTR"},
+	}
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+
+	for _, m := range modes {
+		for _, parallel := range []bool{false, true} {
+			if parallel && !m.mode.SupportsParallel() {
+				continue
+			}
+			for _, size := range []int{1, 7, 8, 13, 1001} {
+				cipher, _, _ := CreateCipher("des")
+				ctx, err := cripta.NewCipherContext(cipher, key, m.mode, cripta.PaddingModeNone, iv, 8, parallel)
+				if err != nil {
+					t.Fatalf("Ошибка создания контекста: %v", err)
+				}
+				// Набивка, заданная для потокового режима, игнорируется
+				ctx.SetPaddingMode(cripta.PaddingModePKCS7)
+
+				data := generateRandomBytes(size)
+				enc, err := ctx.Encrypt(data)
+				if err != nil {
+					t.Fatalf("%s (parallel=%v, %d байт): ошибка шифрования: %v", m.modeName, parallel, size, err)
+				}
+				if len(enc) != len(data) {
+					t.Errorf("%s (parallel=%v): длина шифртекста %d, ожидалось %d", m.modeName, parallel, len(enc), len(data))
+				}
+
+				dec, err := ctx.Decrypt(enc)
+				if err != nil || !bytes.Equal(dec, data) {
+					t.Errorf("%s (parallel=%v, %d байт): расшифровка не совпала: %v", m.modeName, parallel, size, err)
+				}
+			}
+		}
+	}
+}
+
+// fixedReader бесконечно отдает один и тот же байт
+type fixedReader uint8
+
+func (r fixedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = uint8(r)
+	}
+	return len(p), nil
+}
+
+func TestDeterministicISO10126(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЕТЕРМИНИРОВАННОЙ НАБИВКИ ISO10126")
+
+	saved := cripta.RandReader
+	cripta.RandReader = fixedReader(0xAB)
+	defer func() { cripta.RandReader = saved }()
+
+	key := generateRandomBytes(8)
+	data := []byte("12345")
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, cripta.PaddingModeISO10126, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	first, err := ctx.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	second, err := ctx.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("С фиксированным источником шифртекст должен быть воспроизводимым")
+	}
+
+	// Расшифровка без снятия набивки показывает сам дополненный блок
+	raw, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, cripta.PaddingModeNone, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	block, err := raw.Decrypt(first)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	expected := []byte{'1', '2', '3', '4', '5', 0xAB, 0xAB, 0x03}
+	if !bytes.Equal(block, expected) {
+		t.Errorf("Дополненный блок %x, ожидалось %x", block, expected)
+	}
+
+	dec, err := ctx.Decrypt(first)
+	if err != nil || !bytes.Equal(dec, data) {
+		t.Errorf("Расшифровка не совпала: %v", err)
+	}
+}
+
+func TestInspectPadding(t *testing.T) {
+	fmt.Println("\nТЕСТ АНАЛИЗА НАБИВКИ")
+
+	tests := []struct {
+		name        string
+		padding     cripta.PaddingMode
+		block       []byte
+		expectedLen int
+		valid       bool
+	}{
+		{"PKCS7", cripta.PaddingModePKCS7, []byte{1, 2, 3, 4, 4, 4, 4, 4}, 4, true},
+		{"PKCS7 полный блок", cripta.PaddingModePKCS7, []byte{8, 8, 8, 8, 8, 8, 8, 8}, 8, true},
+		{"PKCS7 неверные байты", cripta.PaddingModePKCS7, []byte{1, 2, 3, 4, 4, 3, 4, 4}, 4, false},
+		{"PKCS7 длина больше блока", cripta.PaddingModePKCS7, []byte{1, 2, 3, 4, 5, 6, 7, 9}, 9, false},
+		{"ANSIX923", cripta.PaddingModeANSIX923, []byte{1, 2, 3, 4, 5, 0, 0, 3}, 3, true},
+		{"ANSIX923 ненулевые байты", cripta.PaddingModeANSIX923, []byte{1, 2, 3, 4, 5, 7, 0, 3}, 3, false},
+		{"ISO10126", cripta.PaddingModeISO10126, []byte{1, 2, 3, 4, 5, 0x5A, 0xC3, 3}, 3, true},
+		{"ISO10126 нулевая длина", cripta.PaddingModeISO10126, []byte{1, 2, 3, 4, 5, 6, 7, 0}, 0, false},
+		{"Zeros", cripta.PaddingModeZeros, []byte{1, 2, 3, 0, 0, 0, 0, 0}, 5, true},
+		{"Zeros без нулей", cripta.PaddingModeZeros, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 0, false},
+		{"Неверный размер блока", cripta.PaddingModePKCS7, []byte{2, 2}, 0, false},
+	}
+
+	cipher, _, _ := CreateCipher("des")
+	for _, tt := range tests {
+		ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeECB, tt.padding, nil, 8, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+
+		mode, length, valid := ctx.InspectPadding(tt.block)
+		if mode != tt.padding || length != tt.expectedLen || valid != tt.valid {
+			t.Errorf("%s: получено (%d, %d, %v), ожидалось (%d, %d, %v)",
+				tt.name, mode, length, valid, tt.padding, tt.expectedLen, tt.valid)
+		}
+	}
+
+	// В потоковых режимах набивка не применяется
+	ctx, _ := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCTR, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if mode, length, valid := ctx.InspectPadding([]byte{1, 2, 3}); mode != cripta.PaddingModeNone || length != 0 || !valid {
+		t.Errorf("CTR: получено (%d, %d, %v), ожидалась PaddingModeNone", mode, length, valid)
+	}
+}
+
+func TestCBCCiphertextStealing(t *testing.T) {
+	fmt.Println("\nТЕСТ CBC С КРАЖЕЙ ШИФРТЕКСТА")
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+	cipher, _, _ := CreateCipher("des")
+
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC_CTS, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	for _, size := range []int{8, 9, 15, 16, 17, 24, 100} {
+		data := generateRandomBytes(size)
+		enc, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("%d байт: ошибка шифрования: %v", size, err)
+		}
+		if len(enc) != size {
+			t.Errorf("%d байт: длина шифртекста %d", size, len(enc))
+		}
+
+		dec, err := ctx.Decrypt(enc)
+		if err != nil || !bytes.Equal(dec, data) {
+			t.Errorf("%d байт: расшифровка не совпала: %v", size, err)
+		}
+	}
+
+	// CS3 для кратной длины: обычный CBC с переставленными последними блоками
+	data := generateRandomBytes(24)
+	cbc, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModeNone, iv, 8, false)
+	expected, _ := cbc.Encrypt(data)
+	expected = append(append(append([]byte{}, expected[:8]...), expected[16:]...), expected[8:16]...)
+	enc, _ := ctx.Encrypt(data)
+	if !bytes.Equal(enc, expected) {
+		t.Errorf("Порядок блоков CS3 нарушен: %x != %x", enc, expected)
+	}
+
+	if _, err := ctx.Encrypt(generateRandomBytes(7)); err == nil {
+		t.Errorf("Данные короче блока должны отклоняться")
+	}
+}
+
+func TestHKDF(t *testing.T) {
+	fmt.Println("\nТЕСТ HKDF (RFC 5869)")
+
+	sequence := func(from, to int) []byte {
+		result := make([]byte, 0, to-from+1)
+		for b := from; b <= to; b++ {
+			result = append(result, byte(b))
+		}
+		return result
+	}
+
+	tests := []struct {
+		name            string
+		ikm, salt, info []byte
+		length          int
+		expected        string
+	}{
+		{
+			"Test Case 1", bytes.Repeat([]byte{0x0b}, 22), sequence(0x00, 0x0c), sequence(0xf0, 0xf9), 42,
+			"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		},
+		{
+			"Test Case 2", sequence(0x00, 0x4f), sequence(0x60, 0xaf), sequence(0xb0, 0xff), 82,
+			"b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c" +
+				"59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71" +
+				"cc30c58179ec3e87c14c01d5c1f3434f1d87",
+		},
+		{
+			"Test Case 3", bytes.Repeat([]byte{0x0b}, 22), nil, nil, 42,
+			"8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+		},
+	}
+
+	for _, tt := range tests {
+		okm := cripta.HKDF(tt.ikm, tt.salt, tt.info, tt.length)
+		if hex.EncodeToString(okm) != tt.expected {
+			t.Errorf("%s: получено %x, ожидалось %s", tt.name, okm, tt.expected)
+		}
+	}
+
+	if cripta.HKDF([]byte("secret"), nil, nil, 255*32+1) != nil {
+		t.Errorf("Слишком большая длина должна давать nil")
+	}
+}
+
+func TestRecursiveDirectory(t *testing.T) {
+	fmt.Println("\nТЕСТ РЕКУРСИВНОЙ ОБРАБОТКИ КАТАЛОГА")
+
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	files := map[string][]byte{
+		"a.txt":              []byte("корневой файл"),
+		"nested/b.bin":       generateRandomBytes(1000),
+		"nested/deep/c.txt":  []byte("вложенный файл"),
+		"nested/old.txt.enc": []byte("уже зашифрован"),
+	}
+	for name, content := range files {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Ошибка создания каталога: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Ошибка записи файла: %v", err)
+		}
+	}
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	for _, chunkSize := range []int{0, 64} {
+		encrypted := filepath.Join(root, fmt.Sprintf("encrypted-%d", chunkSize))
+		decrypted := filepath.Join(root, fmt.Sprintf("decrypted-%d", chunkSize))
+
+		count, err := processDirectory(ctx, true, source, encrypted, chunkSize)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования каталога: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("Зашифровано %d файлов, ожидалось 3 (уже зашифрованный пропускается)", count)
+		}
+		if _, err := os.Stat(filepath.Join(encrypted, "nested/old.txt.enc.enc")); err == nil {
+			t.Errorf("Уже зашифрованный файл не должен шифроваться повторно")
+		}
+
+		if _, err := processDirectory(ctx, false, encrypted, decrypted, chunkSize); err != nil {
+			t.Fatalf("Ошибка дешифрования каталога: %v", err)
+		}
+
+		for name, content := range files {
+			if filepath.Ext(name) == ".enc" {
+				continue
+			}
+			restored, err := os.ReadFile(filepath.Join(decrypted, name))
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if !bytes.Equal(restored, content) {
+				t.Errorf("%s: содержимое не совпадает после расшифровки", name)
+			}
+		}
+	}
+
+	// Одинаковые файлы шифруются на разных IV: в CTR общий IV дал бы общий поток ключа
+	same := filepath.Join(root, "same")
+	content := []byte("одинаковое содержимое двух файлов")
+	if err := os.MkdirAll(same, 0755); err != nil {
+		t.Fatalf("Ошибка создания каталога: %v", err)
+	}
+	for _, name := range []string{"first.txt", "second.txt"} {
+		if err := os.WriteFile(filepath.Join(same, name), content, 0644); err != nil {
+			t.Fatalf("Ошибка записи файла: %v", err)
+		}
+	}
+	ctr, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCTR, cripta.PaddingModeNone, generateRandomBytes(8), 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	sameEncrypted := filepath.Join(root, "same-encrypted")
+	if _, err := processDirectory(ctr, true, same, sameEncrypted, 0); err != nil {
+		t.Fatalf("Ошибка шифрования каталога: %v", err)
+	}
+	first, _ := os.ReadFile(filepath.Join(sameEncrypted, "first.txt.enc"))
+	second, _ := os.ReadFile(filepath.Join(sameEncrypted, "second.txt.enc"))
+	if len(first) != 8+len(content) || bytes.Equal(first[:8], second[:8]) || bytes.Equal(first[8:], second[8:]) {
+		t.Errorf("Файлы каталога должны шифроваться на разных IV, записанных перед шифртекстом")
+	}
+}
+
+// TestDESRoundFunctionStages сверяет этапы функции раунда DES с разобранным
+// примером (ключ 133457799BBCDFF1, сообщение 0123456789ABCDEF)
+func TestDESRoundFunctionStages(t *testing.T) {
+	fmt.Println("\nТЕСТ ЭТАПОВ ФУНКЦИИ РАУНДА DES")
+
+	mustHex := func(s string) []byte {
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatalf("Неверный hex: %s", s)
+		}
+		return data
+	}
+
+	key := mustHex("133457799BBCDFF1")
+	r0 := mustHex("F0AAF0AA")
+
+	roundKeys, err := (&cripta.DESKeySchedule{}).GenerateRoundKeys(key)
+	if err != nil {
+		t.Fatalf("Ошибка генерации раундовых ключей: %v", err)
+	}
+	if hex.EncodeToString(roundKeys[0]) != "1b02effc7072" {
+		t.Errorf("K1 = %x, ожидалось 1b02effc7072", roundKeys[0])
+	}
+	if hex.EncodeToString(roundKeys[15]) != "cb3d8b0e17f5" {
+		t.Errorf("K16 = %x, ожидалось cb3d8b0e17f5", roundKeys[15])
+	}
+
+	expanded, err := cripta.PermuteBits(r0, cripta.E_TABLE, false, 1)
+	if err != nil || hex.EncodeToString(expanded) != "7a15557a1555" {
+		t.Errorf("E(R0) = %x, ожидалось 7a15557a1555", expanded)
+	}
+
+	// Подстановка: 8 групп по 6 бит из K1 xor E(R0) = 011000 010001 011110 111010 100001 100110 010100 100111
+	groups := []uint8{0x18, 0x11, 0x1E, 0x3A, 0x21, 0x26, 0x14, 0x27}
+	var substituted uint32
+	for i, group := range groups {
+		row := (group>>4)&0x02 | group&0x01
+		col := (group >> 1) & 0x0F
+		substituted = substituted<<4 | uint32(cripta.S_BOXES[i][row][col])
+	}
+	if substituted != 0x5C82B597 {
+		t.Errorf("S(K1 xor E(R0)) = %08x, ожидалось 5c82b597", substituted)
+	}
+
+	permuted, err := cripta.PermuteBits(mustHex("5C82B597"), cripta.P_TABLE, false, 1)
+	if err != nil || hex.EncodeToString(permuted) != "234aa9bb" {
+		t.Errorf("P(S) = %x, ожидалось 234aa9bb", permuted)
+	}
+
+	f, err := (&cripta.DESRoundFunction{}).Apply(r0, roundKeys[0], 0)
+	if err != nil || hex.EncodeToString(f) != "234aa9bb" {
+		t.Errorf("f(R0, K1) = %x, ожидалось 234aa9bb", f)
+	}
+
+	cipher, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания DES: %v", err)
+	}
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+	encrypted, err := cipher.EncryptBlock(mustHex("0123456789ABCDEF"))
+	if err != nil || hex.EncodeToString(encrypted) != "85e813540f0ab405" {
+		t.Errorf("DES(0123456789ABCDEF) = %x, ожидалось 85e813540f0ab405", encrypted)
+	}
+	decrypted, err := cipher.DecryptBlock(encrypted)
+	if err != nil || hex.EncodeToString(decrypted) != "0123456789abcdef" {
+		t.Errorf("Расшифровка дала %x", decrypted)
+	}
+}
+
+func TestDESAvalanche(t *testing.T) {
+	fmt.Println("\nТЕСТ ЛАВИННОГО ЭФФЕКТА DES")
+
+	cipher, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания DES: %v", err)
+	}
+
+	ratio, err := cripta.AvalancheTest(cipher, generateRandomBytes(8), generateRandomBytes(8))
+	if err != nil {
+		t.Fatalf("Ошибка измерения: %v", err)
+	}
+	fmt.Printf("Доля изменившихся битов: %.3f\n", ratio)
+
+	if ratio < 0.4 || ratio > 0.6 {
+		t.Errorf("Лавинный эффект DES %.3f вне диапазона [0.4, 0.6]", ratio)
+	}
+}
+
+func TestDESRoundKeysDistinct(t *testing.T) {
+	fmt.Println("\nТЕСТ РАЗЛИЧИЯ РАУНДОВЫХ КЛЮЧЕЙ DES")
+
+	cipher, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания DES: %v", err)
+	}
+
+	if cipher.RoundKeysDistinct() {
+		t.Errorf("До установки ключа проверка должна возвращать false")
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := cipher.SetKey(generateRandomBytes(8)); err != nil {
+			t.Fatalf("Ошибка установки ключа: %v", err)
+		}
+		if !cipher.RoundKeysDistinct() {
+			t.Errorf("Для случайного ключа раундовые ключи должны различаться")
+		}
+	}
+
+	// У слабых ключей DES все 16 раундовых ключей совпадают
+	weakKeys := []string{"0101010101010101", "FEFEFEFEFEFEFEFE", "E0E0E0E0F1F1F1F1", "1F1F1F1F0E0E0E0E"}
+	for _, weak := range weakKeys {
+		key, _ := hex.DecodeString(weak)
+		if err := cipher.SetKey(key); err != nil {
+			t.Fatalf("Ошибка установки ключа: %v", err)
+		}
+		if cipher.RoundKeysDistinct() {
+			t.Errorf("Слабый ключ %s должен давать повторяющиеся раундовые ключи", weak)
+		}
+
+		roundKeys, _ := (&cripta.DESKeySchedule{}).GenerateRoundKeys(key)
+		for round := 1; round < len(roundKeys); round++ {
+			if !bytes.Equal(roundKeys[round], roundKeys[0]) {
+				t.Errorf("Слабый ключ %s: раундовый ключ %d отличается от первого", weak, round+1)
+				break
+			}
+		}
+	}
+}
+
+// countingReader отдает возрастающую последовательность байтов и считает обращения
+type countingReader struct {
+	next  uint8
+	calls int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.calls++
+	for i := range p {
+		p[i] = r.next
+		r.next++
+	}
+	return len(p), nil
+}
+
+func TestIVSource(t *testing.T) {
+	fmt.Println("\nТЕСТ ИСТОЧНИКОВ IV")
+
+	counter := cripta.NewCounterIVSource(0)
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		iv, err := counter.NextIV(16)
+		if err != nil {
+			t.Fatalf("Ошибка получения IV: %v", err)
+		}
+		if seen[string(iv)] {
+			t.Fatalf("Счетчик повторил IV %x", iv)
+		}
+		seen[string(iv)] = true
+	}
+
+	exhausted := cripta.NewCounterIVSource(^uint64(0))
+	if _, err := exhausted.NextIV(8); err != nil {
+		t.Fatalf("Последнее значение счетчика должно выдаваться: %v", err)
+	}
+	if _, err := exhausted.NextIV(8); err == nil {
+		t.Errorf("После переполнения счетчик должен возвращать ошибку")
+	}
+
+	saved := cripta.RandReader
+	reader := &countingReader{}
+	cripta.RandReader = reader
+	defer func() { cripta.RandReader = saved }()
+
+	iv, err := cripta.RandomIVSource{}.NextIV(8)
+	if err != nil || reader.calls == 0 || !bytes.Equal(iv, []byte{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Случайный источник должен читать RandReader: %x, %v", iv, err)
+	}
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCBC, cripta.PaddingModePKCS7, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ctx.SetIVSource(cripta.NewCounterIVSource(1))
+	ctx.SetAutoIV(true)
+
+	data := []byte("одинаковое сообщение")
+	first, err := ctx.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	second, _ := ctx.Encrypt(data)
+	if !bytes.Equal(first[:8], []byte{0, 0, 0, 0, 0, 0, 0, 1}) || bytes.Equal(first, second) {
+		t.Errorf("Каждое сообщение должно получать новый IV из источника")
+	}
+
+	for _, encrypted := range [][]byte{first, second} {
+		dec, err := ctx.Decrypt(encrypted)
+		if err != nil || !bytes.Equal(dec, data) {
+			t.Errorf("Расшифровка с AutoIV не совпала: %v", err)
+		}
+	}
+
+	var stream bytes.Buffer
+	if err := ctx.EncryptStream(bytes.NewReader(data), &stream, 8); err != nil {
+		t.Fatalf("Ошибка потокового шифрования: %v", err)
+	}
+	var restored bytes.Buffer
+	if err := ctx.DecryptStream(bytes.NewReader(stream.Bytes()), &restored, 8); err != nil || !bytes.Equal(restored.Bytes(), data) {
+		t.Errorf("Потоковая расшифровка с AutoIV не совпала: %v", err)
+	}
+}
+
+// TestCounterIVSourceCTR проверяет, что потоки ключа CTR двух многоблочных сообщений
+// на соседних IV из CounterIVSource не пересекаются
+func TestCounterIVSourceCTR(t *testing.T) {
+	fmt.Println("\nТЕСТ СЧЕТЧИКА IV В РЕЖИМЕ CTR")
+
+	cipher, keySize, _ := CreateCipher("aes128")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keySize), cripta.CipherModeCTR,
+		cripta.PaddingModeNone, nil, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ctx.SetIVSource(cripta.NewCounterIVSource(0))
+	ctx.SetAutoIV(true)
+
+	// Шифртекст нулей без IV - это поток ключа сообщения
+	zeros := make([]byte, 4*16)
+	keystreams := make([][]byte, 2)
+	for i := range keystreams {
+		encrypted, err := ctx.Encrypt(zeros)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования сообщения %d: %v", i, err)
+		}
+		keystreams[i] = encrypted[16:]
+	}
+
+	seen := make(map[string]bool)
+	for i, keystream := range keystreams {
+		for offset := 0; offset < len(keystream); offset += 16 {
+			block := string(keystream[offset : offset+16])
+			if seen[block] {
+				t.Fatalf("Блок потока ключа %d сообщения %d уже использовался", offset/16, i)
+			}
+			seen[block] = true
+		}
+	}
+
+	// В 8-байтовом блоке не остается места под счетчик блоков
+	desCipher, _, _ := CreateCipher("des")
+	desCtx, err := cripta.NewCipherContext(desCipher, generateRandomBytes(8), cripta.CipherModeCTR,
+		cripta.PaddingModeNone, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	desCtx.SetIVSource(cripta.NewCounterIVSource(0))
+	desCtx.SetAutoIV(true)
+	if _, err := desCtx.Encrypt(make([]byte, 16)); err == nil {
+		t.Errorf("Счетчик IV для CTR с 8-байтовым блоком должен отклоняться")
+	}
+}
+
+func TestDEAL64(t *testing.T) {
+	fmt.Println("\nТЕСТ ЭКСПЕРИМЕНТАЛЬНОГО DEAL-64")
+
+	for _, keyLength := range []int{16, 24, 32} {
+		cipher, err := cripta.NewDEALCipher64(keyLength)
+		if err != nil {
+			t.Fatalf("Ошибка создания DEAL-64: %v", err)
+		}
+		if cipher.GetBlockSize() != 8 {
+			t.Errorf("Размер блока %d, ожидалось 8", cipher.GetBlockSize())
+		}
+
+		ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(keyLength), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+
+		data := generateRandomBytes(1000)
+		enc, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("DEAL-64/%d: ошибка шифрования: %v", keyLength*8, err)
+		}
+		if bytes.Equal(enc[:len(data)], data) {
+			t.Errorf("DEAL-64/%d: шифртекст совпадает с открытым текстом", keyLength*8)
+		}
+
+		dec, err := ctx.Decrypt(enc)
+		if err != nil || !bytes.Equal(dec, data) {
+			t.Errorf("DEAL-64/%d: расшифровка не совпала: %v", keyLength*8, err)
+		}
+	}
+}
+
+func TestEncryptBatch(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАКЕТНОГО ШИФРОВАНИЯ")
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCBC, cripta.PaddingModePKCS7, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	messages := make([][]byte, 1000)
+	for i := range messages {
+		messages[i] = []byte(fmt.Sprintf("сообщение %d", i))
+	}
+
+	results, ivs, err := ctx.EncryptBatch(messages)
+	if err != nil {
+		t.Fatalf("Ошибка пакетного шифрования: %v", err)
+	}
+	if len(results) != len(messages) || len(ivs) != len(messages) {
+		t.Fatalf("Получено %d шифртекстов и %d IV", len(results), len(ivs))
+	}
+
+	seen := make(map[string]bool)
+	for i := range messages {
+		if seen[string(ivs[i])] {
+			t.Fatalf("IV %x повторяется", ivs[i])
+		}
+		seen[string(ivs[i])] = true
+
+		ctx.SetIV(ivs[i])
+		dec, err := ctx.Decrypt(results[i])
+		if err != nil || !bytes.Equal(dec, messages[i]) {
+			t.Fatalf("Сообщение %d не совпало после расшифровки: %v", i, err)
+		}
+	}
+
+	ecb, _ := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeECB, cripta.PaddingModePKCS7, nil, 8, false)
+	if _, _, err := ecb.EncryptBatch(messages); err == nil {
+		t.Errorf("ECB не использует IV и должен отклоняться")
+	}
+}
+
+func TestDecryptWithKeys(t *testing.T) {
+	fmt.Println("\nТЕСТ РАСШИФРОВКИ ПРИ РОТАЦИИ КЛЮЧЕЙ")
+
+	oldKey := generateRandomBytes(8)
+	newKey := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+	data := []byte("данные под новым ключом")
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, newKey, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	ciphertext, tag, err := ctx.EncryptAEAD(data, nil)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	sealed := append(ciphertext, tag...)
+
+	reader, err := cripta.NewCipherContext(cipher, oldKey, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	plaintext, index, err := reader.DecryptWithKeys(sealed, [][]byte{oldKey, newKey})
+	if err != nil || index != 1 || !bytes.Equal(plaintext, data) {
+		t.Errorf("Ожидалась расшифровка вторым ключом: индекс %d, %v", index, err)
+	}
+
+	// Ключ контекста не должен меняться после перебора
+	own, _, _ := reader.EncryptAEAD(data, nil)
+	withOld, _ := cripta.NewCipherContext(cipher, oldKey, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	expected, _, _ := withOld.EncryptAEAD(data, nil)
+	if !bytes.Equal(own, expected) {
+		t.Errorf("Ключ контекста изменился после DecryptWithKeys")
+	}
+
+	if _, index, err := reader.DecryptWithKeys(sealed, [][]byte{oldKey, generateRandomBytes(8)}); !errors.Is(err, cripta.ErrAuthenticationFailed) || index != -1 {
+		t.Errorf("Без подходящего ключа ожидалась ErrAuthenticationFailed, получено %v", err)
+	}
+}
+
+// TestQuietMode проверяет, что в тихом режиме stdout остается пустым,
+// а ключ и IV выводятся только в stderr по флагу -print-key
+func TestQuietMode(t *testing.T) {
+	fmt.Println("\nТЕСТ ТИХОГО РЕЖИМА CLI")
+
+	root := t.TempDir()
+	input := filepath.Join(root, "input.txt")
+	encrypted := filepath.Join(root, "output.enc")
+	decrypted := filepath.Join(root, "output.txt")
+	if err := os.WriteFile(input, []byte("данные для конвейера"), 0644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-e", "-quiet", "-print-key", "-a=des", "-m=cbc", input, encrypted}, &stdout, &stderr); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("В тихом режиме stdout должен быть пустым, получено: %q", stdout.String())
+	}
+
+	var keyHex, ivHex string
+	if _, err := fmt.Sscanf(stderr.String(), "Алгоритм: DES\nКлюч: %s\nIV: %s\n", &keyHex, &ivHex); err != nil {
+		t.Fatalf("Не удалось прочитать ключ и IV из stderr %q: %v", stderr.String(), err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	args := []string{"-d", "-quiet", "-a=des", "-m=cbc", "-k=" + keyHex, "-iv=" + ivHex, encrypted, decrypted}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Errorf("Без -print-key тихий режим не должен ничего выводить: stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	result, err := os.ReadFile(decrypted)
+	if err != nil || string(result) != "данные для конвейера" {
+		t.Errorf("Файл восстановлен неверно: %q, %v", result, err)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-e", "-a=des", "-m=cbc", input, encrypted}, &stdout, io.Discard); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !bytes.Contains(stdout.Bytes(), []byte("Информация")) {
+		t.Errorf("Без -quiet должна выводиться сводка")
+	}
+
+	// Сгенерированный ключ выводится в stderr и без -print-key, иначе данные не восстановить
+	stderr.Reset()
+	if err := run([]string{"-e", "-quiet", "-a=des", "-m=cbc", input, encrypted}, io.Discard, &stderr); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Ключ: ") || !strings.Contains(stderr.String(), "IV: ") {
+		t.Errorf("В тихом режиме сгенерированные ключ и IV должны выводиться в stderr, получено %q", stderr.String())
+	}
+}
+
+// faultyCipher оборачивает шифр и портит результат дешифрования блока,
+// имитируя сбой оборудования или ошибку реализации
+type faultyCipher struct {
+	cripta.ISymmetricCipher
+}
+
+func (c *faultyCipher) DecryptBlock(block []uint8) ([]uint8, error) {
+	out, err := c.ISymmetricCipher.DecryptBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	out[0] ^= 0x01
+	return out, nil
+}
+
+// TestVerifyRoundTrip проверяет самопроверку Encrypt во всех режимах и ее срабатывание при сбое
+func TestVerifyRoundTrip(t *testing.T) {
+	fmt.Println("\nТЕСТ САМОПРОВЕРКИ ПОСЛЕ ШИФРОВАНИЯ")
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+	data := append(generateRandomBytes(37), 0, 0)
+
+	modes := []cripta.CipherMode{
+		cripta.CipherModeECB, cripta.CipherModeCBC, cripta.CipherModePCBC, cripta.CipherModeCFB,
+		cripta.CipherModeOFB, cripta.CipherModeCTR, cripta.CipherModeRandomDelta, cripta.CipherModeCBC_CTS,
+	}
+	paddings := []cripta.PaddingMode{cripta.PaddingModeZeros, cripta.PaddingModePKCS7, cripta.PaddingModeISO10126}
+
+	for _, mode := range modes {
+		for _, padding := range paddings {
+			for _, parallel := range []bool{false, true} {
+				if parallel && !mode.SupportsParallel() {
+					continue
+				}
+				des, _ := cripta.NewDESCipher()
+				ctx, err := cripta.NewCipherContext(des, key, mode, padding, iv, 8, parallel)
+				if err != nil {
+					t.Fatalf("Ошибка создания контекста: %v", err)
+				}
+				ctx.SetVerifyRoundTrip(true)
+
+				if _, err := ctx.Encrypt(data); err != nil {
+					t.Errorf("Режим %d, набивка %d, параллельно %v: самопроверка не прошла: %v", mode, padding, parallel, err)
+				}
+			}
+		}
+	}
+
+	des, _ := cripta.NewDESCipher()
+	ctx, err := cripta.NewCipherContext(&faultyCipher{des}, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if _, err := ctx.Encrypt(data); err != nil {
+		t.Fatalf("Без самопроверки сбой дешифрования не должен влиять на Encrypt: %v", err)
+	}
+
+	ctx.SetVerifyRoundTrip(true)
+	if _, err := ctx.Encrypt(data); !errors.Is(err, cripta.ErrRoundTripMismatch) {
+		t.Errorf("Ожидалась ошибка ErrRoundTripMismatch, получено %v", err)
+	}
+}
+
+// TestCipherNames проверяет идентификаторы алгоритмов, которые шифры сообщают через Name
+func TestCipherNames(t *testing.T) {
+	fmt.Println("\nТЕСТ ИМЕН АЛГОРИТМОВ")
+
+	des, _ := cripta.NewDESCipher()
+	gost, _ := cripta.NewGOSTCipher()
+	deal128, _ := cripta.NewDEALCipher(16)
+	deal256, _ := cripta.NewDEALCipher(32)
+	deal64, _ := cripta.NewDEALCipher64(24)
+	rijndael, _ := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	rijndaelWide, _ := cripta.NewRijndaelCipher(32, 24, 0x1B)
+
+	tests := []struct {
+		cipher   cripta.ISymmetricCipher
+		expected string
+	}{
+		{des, "DES"},
+		{gost, "GOST 28147-89"},
+		{deal128, "DEAL-128"},
+		{deal256, "DEAL-256"},
+		{deal64, "DEAL64-192"},
+		{rijndael, "Rijndael-128/128"},
+		{rijndaelWide, "Rijndael-256/192"},
+	}
+	for _, tt := range tests {
+		if name := tt.cipher.Name(); name != tt.expected {
+			t.Errorf("Name() = %q, ожидалось %q", name, tt.expected)
+		}
+	}
+
+	registered := map[string]string{
+		"des": "DES", "gost": "GOST 28147-89", "deal192": "DEAL-192",
+		"aes128": "Rijndael-128/128", "aes256": "Rijndael-128/256",
+	}
+	for algorithm, expected := range registered {
+		cipher, _, err := CreateCipher(algorithm)
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра %s: %v", algorithm, err)
+		}
+		if name := cipher.Name(); name != expected {
+			t.Errorf("%s: Name() = %q, ожидалось %q", algorithm, name, expected)
+		}
+	}
+}
+
+// TestParallelRandomDelta сверяет параллельный RandomDelta с последовательным
+// при одинаковом источнике дельт и проверяет перекрестное дешифрование
+func TestParallelRandomDelta(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАРАЛЛЕЛЬНОГО RANDOM DELTA")
+
+	saved := cripta.RandReader
+	defer func() { cripta.RandReader = saved }()
+
+	key := generateRandomBytes(16)
+	for _, size := range []int{1, 15, 16, 17, 1000, 4096} {
+		data := generateRandomBytes(size)
+
+		contexts := make([]*cripta.CipherContext, 2)
+		ciphertexts := make([][]byte, 2)
+		for i, parallel := range []bool{false, true} {
+			cipher, _, _ := CreateCipher("aes128")
+			ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeRandomDelta, cripta.PaddingModePKCS7, nil, 16, parallel)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+
+			cripta.RandReader = &countingReader{}
+			encrypted, err := ctx.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Размер %d, параллельно %v: ошибка шифрования: %v", size, parallel, err)
+			}
+			contexts[i], ciphertexts[i] = ctx, encrypted
+		}
+
+		if !bytes.Equal(ciphertexts[0], ciphertexts[1]) {
+			t.Errorf("Размер %d: параллельный шифртекст отличается от последовательного", size)
+		}
+
+		for i, ctx := range contexts {
+			decrypted, err := ctx.Decrypt(ciphertexts[1-i])
+			if err != nil || !bytes.Equal(decrypted, data) {
+				t.Errorf("Размер %d: перекрестное дешифрование не удалось: %v", size, err)
+			}
+		}
+	}
+}
+
+// BenchmarkRandomDelta сравнивает последовательный и параллельный RandomDelta
+func BenchmarkRandomDelta(b *testing.B) {
+	data := generateRandomBytes(64 * 1024)
+	key := generateRandomBytes(16)
+
+	for _, parallel := range []bool{false, true} {
+		cipher, _, _ := CreateCipher("aes128")
+		ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeRandomDelta, cripta.PaddingModePKCS7, nil, 16, parallel)
+
+		b.Run(fmt.Sprintf("parallel=%v", parallel), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := ctx.Encrypt(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// unpreparedRoundFunction скрывает PrepareRoundKeys, так что функция раунда DEAL
+// устанавливает ключ DES при каждом вызове Apply, как до подготовки ключей
+type unpreparedRoundFunction struct {
+	cripta.IRoundFunction
+}
+
+// BenchmarkDEALRoundKeys сравнивает DEAL-128 с экземплярами DES, подготовленными
+// при установке ключа, и с расписанием ключей DES в каждом вызове функции раунда
+func BenchmarkDEALRoundKeys(b *testing.B) {
+	data := generateRandomBytes(64 * 1024)
+	key := generateRandomBytes(16)
+
+	newNetwork := func(prepared bool) *cripta.FeistelNetwork {
+		schedule, err := cripta.NewDEALKeySchedule(16)
+		if err != nil {
+			b.Fatal(err)
+		}
+		roundFunction, err := cripta.NewDEALRoundFunction()
+		if err != nil {
+			b.Fatal(err)
+		}
+		var function cripta.IRoundFunction = roundFunction
+		if !prepared {
+			function = unpreparedRoundFunction{roundFunction}
+		}
+		network, err := cripta.NewFeistelNetwork(schedule, function, 16, 6)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := network.SetKey(key); err != nil {
+			b.Fatal(err)
+		}
+		return network
+	}
+
+	reference, _ := newNetwork(false).EncryptBlock(data[:16])
+	if fast, _ := newNetwork(true).EncryptBlock(data[:16]); !bytes.Equal(fast, reference) {
+		b.Fatalf("Подготовленные ключи дают другой шифртекст: %x, ожидалось %x", fast, reference)
+	}
+
+	for _, prepared := range []bool{false, true} {
+		network := newNetwork(prepared)
+		b.Run(fmt.Sprintf("prepared=%v", prepared), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				for offset := 0; offset < len(data); offset += 16 {
+					if _, err := network.EncryptBlock(data[offset : offset+16]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRoundIndexCiphertext проверяет, что передача номера раунда в функцию раунда
+// и подготовка ключей DEAL не меняют шифртекст
+func TestRoundIndexCiphertext(t *testing.T) {
+	fmt.Println("\nТЕСТ НЕИЗМЕННОСТИ ШИФРТЕКСТА ПРИ ПЕРЕДАЧЕ НОМЕРА РАУНДА")
+
+	des, err := cripta.NewDESCipher()
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	desKey, _ := hex.DecodeString("133457799BBCDFF1")
+	desBlock, _ := hex.DecodeString("0123456789ABCDEF")
+	if err := des.SetKey(desKey); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+	encrypted, err := des.EncryptBlock(desBlock)
+	if err != nil || hex.EncodeToString(encrypted) != "85e813540f0ab405" {
+		t.Errorf("DES: %x, ожидалось 85e813540f0ab405 (%v)", encrypted, err)
+	}
+
+	for _, keySize := range []int{16, 24, 32} {
+		key := generateRandomBytes(keySize)
+		rounds := 6
+		if keySize == 32 {
+			rounds = 8
+		}
+
+		networks := make([]*cripta.FeistelNetwork, 2)
+		for i, prepared := range []bool{true, false} {
+			schedule, err := cripta.NewDEALKeySchedule(keySize)
+			if err != nil {
+				t.Fatalf("Ошибка создания расписания: %v", err)
+			}
+			roundFunction, err := cripta.NewDEALRoundFunction()
+			if err != nil {
+				t.Fatalf("Ошибка создания функции раунда: %v", err)
+			}
+			var function cripta.IRoundFunction = roundFunction
+			if !prepared {
+				function = unpreparedRoundFunction{roundFunction}
+			}
+			if networks[i], err = cripta.NewFeistelNetwork(schedule, function, 16, rounds); err != nil {
+				t.Fatalf("Ошибка создания сети Фейстеля: %v", err)
+			}
+			if err := networks[i].SetKey(key); err != nil {
+				t.Fatalf("Ошибка установки ключа: %v", err)
+			}
+		}
+
+		for i := 0; i < 10; i++ {
+			block := generateRandomBytes(16)
+			fast, err := networks[0].EncryptBlock(block)
+			if err != nil {
+				t.Fatalf("Ошибка шифрования: %v", err)
+			}
+			slow, err := networks[1].EncryptBlock(block)
+			if err != nil {
+				t.Fatalf("Ошибка шифрования: %v", err)
+			}
+			if !bytes.Equal(fast, slow) {
+				t.Fatalf("DEAL-%d: подготовленные ключи дают %x, ожидалось %x", keySize*8, fast, slow)
+			}
+			restored, err := networks[0].DecryptBlock(fast)
+			if err != nil || !bytes.Equal(restored, block) {
+				t.Fatalf("DEAL-%d: блок не восстановлен: %v", keySize*8, err)
+			}
+		}
+	}
+}
+
+// TestFeistelNetworkParameters проверяет значения по умолчанию и отклонение
+// некорректных размеров блока и числа раундов сети Фейстеля
+func TestFeistelNetworkParameters(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАРАМЕТРОВ СЕТИ ФЕЙСТЕЛЯ")
+
+	network, err := cripta.NewFeistelNetwork(&cripta.GOSTKeySchedule{}, &cripta.GOSTRoundFunction{}, 0, 0)
+	if err != nil {
+		t.Fatalf("Нулевые параметры должны заменяться значениями по умолчанию: %v", err)
+	}
+	blockSize, _ := network.GetBlockSize()
+	rounds, _ := network.GetRoundsCount()
+	if blockSize != cripta.DefaultFeistelBlockSize || rounds != cripta.DefaultFeistelRounds {
+		t.Errorf("Получены блок %d и %d раундов, ожидалось %d и %d",
+			blockSize, rounds, cripta.DefaultFeistelBlockSize, cripta.DefaultFeistelRounds)
+	}
+
+	invalid := []struct {
+		blockSize int
+		rounds    int
+	}{
+		{7, 16}, {9, 16}, {-8, 16}, {-1, 16}, {8, -1},
+	}
+	for _, tt := range invalid {
+		if _, err := cripta.NewFeistelNetwork(&cripta.GOSTKeySchedule{}, &cripta.GOSTRoundFunction{}, tt.blockSize, tt.rounds); err == nil {
+			t.Errorf("Блок %d и %d раундов должны отклоняться", tt.blockSize, tt.rounds)
+		}
+	}
+}
+
+// TestDetachedIV проверяет шифрование с IV, возвращаемым отдельно от шифртекста
+func TestDetachedIV(t *testing.T) {
+	fmt.Println("\nТЕСТ ОТДЕЛЬНОГО IV")
+
+	cipher, _, _ := CreateCipher("aes128")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(16), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(16), 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	data := generateRandomBytes(100)
+	ciphertext, iv, err := ctx.EncryptDetached(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if len(iv) != 16 || len(ciphertext) != 112 {
+		t.Errorf("IV %d байт и шифртекст %d байт, ожидалось 16 и 112", len(iv), len(ciphertext))
+	}
+
+	decrypted, err := ctx.DecryptDetached(ciphertext, iv)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("Ошибка расшифровки: %v", err)
+	}
+
+	// Шифртекст не содержит IV и расшифровывается обычным контекстом с тем же IV
+	ctx.SetIV(iv)
+	if decrypted, err := ctx.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("Шифртекст не должен содержать IV: %v", err)
+	}
+
+	if _, secondIV, _ := ctx.EncryptDetached(data); bytes.Equal(secondIV, iv) {
+		t.Errorf("Каждый вызов должен использовать новый IV")
+	}
+	if _, err := ctx.DecryptDetached(ciphertext, iv[:8]); err == nil {
+		t.Errorf("IV неверной длины должен отклоняться")
+	}
+}
+
+// TestModeSupportsParallel перечисляет режимы и ожидаемую возможность параллельной обработки
+func TestModeSupportsParallel(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАРАЛЛЕЛИЗУЕМОСТИ РЕЖИМОВ")
+
+	expected := map[cripta.CipherMode]bool{
+		cripta.CipherModeECB:         true,
+		cripta.CipherModeCBC:         false,
+		cripta.CipherModePCBC:        false,
+		cripta.CipherModeCFB:         false,
+		cripta.CipherModeOFB:         false,
+		cripta.CipherModeCTR:         true,
+		cripta.CipherModeRandomDelta: true,
+		cripta.CipherModeCBC_CTS:     false,
+	}
+	for mode, want := range expected {
+		if got := mode.SupportsParallel(); got != want {
+			t.Errorf("Режим %d: SupportsParallel() = %v, ожидалось %v", mode, got, want)
+		}
+	}
+}
+
+// TestParallelUnsupported проверяет ошибку при запросе параллельной обработки
+// для CBC и молчаливый переход на последовательную при SilentFallback
+func TestParallelUnsupported(t *testing.T) {
+	fmt.Println("\nТЕСТ НЕПОДДЕРЖИВАЕМОЙ ПАРАЛЛЕЛЬНОЙ ОБРАБОТКИ")
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(8), cripta.CipherModeCBC, cripta.PaddingModePKCS7, generateRandomBytes(8), 8, true)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	data := generateRandomBytes(100)
+	if _, err := ctx.Encrypt(data); !errors.Is(err, cripta.ErrParallelUnsupported) {
+		t.Errorf("Encrypt: ожидалась ошибка ErrParallelUnsupported, получено %v", err)
+	}
+	if _, err := ctx.Decrypt(make([]byte, 104)); !errors.Is(err, cripta.ErrParallelUnsupported) {
+		t.Errorf("Decrypt: ожидалась ошибка ErrParallelUnsupported, получено %v", err)
+	}
+
+	ctx.SetSilentFallback(true)
+	encrypted, err := ctx.Encrypt(data)
+	if err != nil {
+		t.Fatalf("С SilentFallback шифрование должно выполняться последовательно: %v", err)
+	}
+	decrypted, err := ctx.Decrypt(encrypted)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("С SilentFallback расшифровка не удалась: %v", err)
+	}
+}
+
+// TestDecryptRange сверяет расшифровку диапазона с полной расшифровкой в CTR и OFB
+func TestDecryptRange(t *testing.T) {
+	fmt.Println("\nТЕСТ РАСШИФРОВКИ ДИАПАЗОНА")
+
+	// Младшие байты IV близки к переполнению, чтобы сдвиг счетчика давал перенос
+	iv := generateRandomBytes(16)
+	copy(iv[13:], []byte{0xff, 0xff, 0xfe})
+
+	for _, mode := range []cripta.CipherMode{cripta.CipherModeCTR, cripta.CipherModeOFB} {
+		for _, autoIV := range []bool{false, true} {
+			cipher, _, _ := CreateCipher("aes128")
+			ctx, err := cripta.NewCipherContext(cipher, generateRandomBytes(16), mode, cripta.PaddingModeNone, iv, 16, false)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+			ctx.SetAutoIV(autoIV)
+
+			data := generateRandomBytes(1000)
+			ciphertext, err := ctx.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Режим %d: ошибка шифрования: %v", mode, err)
+			}
+			full, err := ctx.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Режим %d: ошибка расшифровки: %v", mode, err)
+			}
+
+			for _, r := range [][2]int{{100, 200}, {0, 1000}, {0, 0}, {16, 32}, {999, 1000}, {37, 38}} {
+				part, err := ctx.DecryptRange(ciphertext, r[0], r[1])
+				if err != nil {
+					t.Fatalf("Режим %d, AutoIV %v, [%d, %d): ошибка: %v", mode, autoIV, r[0], r[1], err)
+				}
+				if !bytes.Equal(part, full[r[0]:r[1]]) {
+					t.Errorf("Режим %d, AutoIV %v, [%d, %d): диапазон не совпадает с полной расшифровкой", mode, autoIV, r[0], r[1])
+				}
+			}
+
+			if _, err := ctx.DecryptRange(ciphertext, 200, 100); err == nil {
+				t.Errorf("Режим %d: обратный диапазон должен отклоняться", mode)
+			}
+			if _, err := ctx.DecryptRange(ciphertext, 0, 1001); err == nil {
+				t.Errorf("Режим %d: диапазон за концом данных должен отклоняться", mode)
+			}
+		}
+	}
+
+	cipher, _, _ := CreateCipher("aes128")
+	ctx, _ := cripta.NewCipherContext(cipher, generateRandomBytes(16), cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if _, err := ctx.DecryptRange(make([]byte, 32), 0, 16); err == nil {
+		t.Errorf("CBC не поддерживает расшифровку диапазона, ожидалась ошибка")
+	}
+}
+
+// TestPCBC проверяет PCBC на невыровненных данных и известное свойство режима:
+// после перестановки двух соседних блоков шифртекста искажаются только они
+func TestPCBC(t *testing.T) {
+	fmt.Println("\nТЕСТ РЕЖИМА PCBC")
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+
+	for length := 1; length <= 50; length++ {
+		cipher, _, _ := CreateCipher("des")
+		ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModePCBC, cripta.PaddingModePKCS7, iv, 8, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+
+		data := generateRandomBytes(length)
+		encrypted, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("%d байт: ошибка шифрования: %v", length, err)
+		}
+		decrypted, err := ctx.Decrypt(encrypted)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Errorf("%d байт: расшифровка не совпала: %v", length, err)
+		}
+	}
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModePCBC, cripta.PaddingModePKCS7, iv, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+
+	data := generateRandomBytes(8*6 + 3)
+	encrypted, err := ctx.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	// Меняем местами блоки 1 и 2
+	swapped := append([]byte{}, encrypted...)
+	copy(swapped[8:16], encrypted[16:24])
+	copy(swapped[16:24], encrypted[8:16])
+
+	decrypted, err := ctx.Decrypt(swapped)
+	if err != nil {
+		t.Fatalf("Ошибка расшифровки: %v", err)
+	}
+	if len(decrypted) != len(data) {
+		t.Fatalf("Длина расшифровки %d, ожидалось %d", len(decrypted), len(data))
+	}
+	if !bytes.Equal(decrypted[:8], data[:8]) {
+		t.Errorf("Блок до перестановки должен расшифровываться верно")
+	}
+	if bytes.Equal(decrypted[8:16], data[8:16]) || bytes.Equal(decrypted[16:24], data[16:24]) {
+		t.Errorf("Переставленные блоки должны расшифровываться неверно")
+	}
+	if !bytes.Equal(decrypted[24:], data[24:]) {
+		t.Errorf("Блоки после перестановки должны восстанавливаться")
+	}
+}
+
+// TestReducedRounds уменьшает число раундов DES до 8 через контекст
+func TestReducedRounds(t *testing.T) {
+	fmt.Println("\nТЕСТ ОСЛАБЛЕННОГО DES")
+
+	key := generateRandomBytes(8)
+	data := generateRandomBytes(100)
+
+	fullCipher, _, _ := CreateCipher("des")
+	full, err := cripta.NewCipherContext(fullCipher, key, cripta.CipherModeECB, cripta.PaddingModePKCS7, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	fullEncrypted, _ := full.Encrypt(data)
+
+	reducedCipher, _, _ := CreateCipher("des")
+	reduced, err := cripta.NewCipherContext(reducedCipher, key, cripta.CipherModeECB, cripta.PaddingModePKCS7, nil, 8, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	if err := reduced.SetRounds(8); err != nil {
+		t.Fatalf("Ошибка установки числа раундов: %v", err)
+	}
+
+	encrypted, err := reduced.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if bytes.Equal(encrypted, fullEncrypted) {
+		t.Errorf("Шифртекст 8-раундового DES не должен совпадать с полным DES")
+	}
+	decrypted, err := reduced.Decrypt(encrypted)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("Ошибка расшифровки 8-раундового DES: %v", err)
+	}
+
+	for _, rounds := range []int{0, 17} {
+		if err := reduced.SetRounds(rounds); err == nil {
+			t.Errorf("%d раундов DES должно отклоняться", rounds)
+		}
+	}
+
+	aes, _, _ := CreateCipher("aes128")
+	ctx, _ := cripta.NewCipherContext(aes, generateRandomBytes(16), cripta.CipherModeECB, cripta.PaddingModePKCS7, nil, 16, false)
+	if err := ctx.SetRounds(8); err == nil {
+		t.Errorf("Шифр без RoundConfigurable должен возвращать ошибку")
+	}
+}
+
+// TestIsDeterministic проверяет воспроизводимость шифрования в разных режимах
+func TestIsDeterministic(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЕТЕРМИНИРОВАННОСТИ ШИФРОВАНИЯ")
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+	data := generateRandomBytes(64)
+
+	cases := []struct {
+		name          string
+		mode          cripta.CipherMode
+		autoIV        bool
+		deterministic bool
+	}{
+		{"ECB", cripta.CipherModeECB, false, true},
+		{"CBC с фиксированным IV", cripta.CipherModeCBC, false, true},
+		{"CBC с AutoIV", cripta.CipherModeCBC, true, false},
+		{"CTR с AutoIV", cripta.CipherModeCTR, true, false},
+		{"RandomDelta", cripta.CipherModeRandomDelta, false, false},
+	}
+
+	for _, tc := range cases {
+		cipher, _, _ := CreateCipher("des")
+		ctx, err := cripta.NewCipherContext(cipher, key, tc.mode, cripta.PaddingModePKCS7, iv, 8, false)
+		if err != nil {
+			t.Fatalf("%s: ошибка создания контекста: %v", tc.name, err)
+		}
+		ctx.SetAutoIV(tc.autoIV)
+
+		deterministic, err := cripta.IsDeterministic(ctx, data)
+		if err != nil {
+			t.Fatalf("%s: ошибка: %v", tc.name, err)
+		}
+		if deterministic != tc.deterministic {
+			t.Errorf("%s: IsDeterministic = %v, ожидалось %v", tc.name, deterministic, tc.deterministic)
+		}
+	}
+}
+
+// TestDESCustomSBoxes проверяет DES с собственным набором S-блоков
+func TestDESCustomSBoxes(t *testing.T) {
+	fmt.Println("\nТЕСТ DES С СОБСТВЕННЫМИ S-БЛОКАМИ")
+
+	// Стандартные S-блоки со строками, циклически сдвинутыми на одну позицию
+	var boxes [8][64]byte
+	for box := 0; box < 8; box++ {
+		for row := 0; row < 4; row++ {
+			for col := 0; col < 16; col++ {
+				boxes[box][row*16+col] = cripta.S_BOXES[box][row][(col+1)%16]
+			}
+		}
+	}
+
+	custom, err := cripta.NewDESCipherWithSBoxes(boxes)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	standard, _ := cripta.NewDESCipher()
+
+	key := generateRandomBytes(8)
+	custom.SetKey(key)
+	standard.SetKey(key)
+
+	for i := 0; i < 20; i++ {
+		block := generateRandomBytes(8)
+		encrypted, err := custom.EncryptBlock(block)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования: %v", err)
+		}
+		decrypted, err := custom.DecryptBlock(encrypted)
+		if err != nil || !bytes.Equal(decrypted, block) {
+			t.Fatalf("Блок %x восстановлен неверно: %x, %v", block, decrypted, err)
+		}
+		if reference, _ := standard.EncryptBlock(block); bytes.Equal(encrypted, reference) {
+			t.Errorf("Шифртекст с другими S-блоками не должен совпадать со стандартным DES")
+		}
+	}
+
+	duplicate := boxes
+	duplicate[3][17] = duplicate[3][16]
+	if _, err := cripta.NewDESCipherWithSBoxes(duplicate); err == nil {
+		t.Errorf("Строка с повторяющимся значением должна отклоняться")
+	}
+
+	tooWide := boxes
+	tooWide[0][0] = 16
+	if _, err := cripta.NewDESCipherWithSBoxes(tooWide); err == nil {
+		t.Errorf("Значение больше 15 должно отклоняться")
+	}
+}
+
+// TestGenerateRSAKeyCLI генерирует ключ RSA через CLI каждым тестом простоты
+func TestGenerateRSAKeyCLI(t *testing.T) {
+	fmt.Println("\nТЕСТ ГЕНЕРАЦИИ КЛЮЧА RSA ИЗ CLI")
+
+	for _, primeTest := range []string{"fermat", "solovay", "miller"} {
+		var stdout bytes.Buffer
+		if err := run([]string{"-genkey", "-bits=512", "-allow-weak", "-primetest=" + primeTest}, &stdout, io.Discard); err != nil {
+			t.Fatalf("%s: ошибка генерации: %v", primeTest, err)
+		}
+
+		values := map[string]*big.Int{}
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			name, value, found := strings.Cut(strings.TrimSpace(line), ": ")
+			if !found || len(name) != 1 {
+				continue
+			}
+			n, ok := new(big.Int).SetString(value, 16)
+			if !ok {
+				t.Fatalf("%s: неверное значение %s: %q", primeTest, name, value)
+			}
+			values[name] = n
+		}
+
+		n, e, d := values["N"], values["E"], values["D"]
+		if n == nil || e == nil || d == nil {
+			t.Fatalf("%s: в выводе нет N, E или D: %q", primeTest, stdout.String())
+		}
+		if n.BitLen() != 512 {
+			t.Errorf("%s: модуль %d бит, ожидалось 512", primeTest, n.BitLen())
+		}
+
+		m := big.NewInt(123456789)
+		if got := new(big.Int).Exp(new(big.Int).Exp(m, e, n), d, n); got.Cmp(m) != 0 {
+			t.Errorf("%s: (m^e)^d mod n != m", primeTest)
+		}
+		fmt.Printf("  %s: OK\n", primeTest)
+	}
+
+	if err := run([]string{"-genkey", "-primetest=unknown"}, io.Discard, io.Discard); err == nil {
+		t.Errorf("Неизвестный тест простоты должен возвращать ошибку")
+	}
+
+	// Политика длины ключа та же, что у RSAService: 512 бит без -allow-weak отклоняются
+	if err := run([]string{"-genkey", "-bits=512"}, io.Discard, io.Discard); err == nil {
+		t.Errorf("Ключ 512 бит без -allow-weak должен отклоняться")
+	}
+
+	// В тихом режиме ключ выводится в stderr вместе с предупреждением о слабом ключе
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-genkey", "-quiet", "-bits=512", "-allow-weak"}, &stdout, &stderr); err != nil {
+		t.Fatalf("Ошибка генерации: %v", err)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "N: ") || !strings.Contains(stderr.String(), "Предупреждение") {
+		t.Errorf("В тихом режиме ключ и предупреждение должны выводиться в stderr: stdout %q, stderr %q",
+			stdout.String(), stderr.String())
+	}
+}
+
+// TestEncryptingWriter проверяет шифрующую обертку io.Writer: запись порциями
+// произвольной длины дает тот же шифртекст, что и Encrypt, и сочетается с gzip
+func TestEncryptingWriter(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРУЮЩЕЙ ОБЕРТКИ IO.WRITER")
+
+	key := generateRandomBytes(16)
+	iv := generateRandomBytes(16)
+	data := generateRandomBytes(1000)
+
+	for _, mode := range []cripta.CipherMode{cripta.CipherModeCFB, cripta.CipherModeOFB, cripta.CipherModeCTR} {
+		cipher, _, _ := CreateCipher("aes128")
+		ctx, err := cripta.NewCipherContext(cipher, key, mode, cripta.PaddingModeNone, iv, 16, false)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		expected, _ := ctx.Encrypt(data)
+
+		var buf bytes.Buffer
+		writer, err := cripta.NewEncryptingWriter(ctx, &buf)
+		if err != nil {
+			t.Fatalf("Режим %d: ошибка создания обертки: %v", mode, err)
+		}
+		for offset, size := 0, 1; offset < len(data); offset, size = offset+size, size+7 {
+			end := min(offset+size, len(data))
+			if _, err := writer.Write(data[offset:end]); err != nil {
+				t.Fatalf("Режим %d: ошибка записи: %v", mode, err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Режим %d: ошибка закрытия: %v", mode, err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Режим %d: шифртекст обертки не совпадает с Encrypt", mode)
+		}
+
+		// gzip -> шифрование: сжатые данные шифруются по мере записи
+		buf.Reset()
+		ctx.SetAutoIV(true)
+		writer, err = cripta.NewEncryptingWriter(ctx, &buf)
+		if err != nil {
+			t.Fatalf("Режим %d: ошибка создания обертки: %v", mode, err)
+		}
+		text := bytes.Repeat([]byte("сжимаемые данные "), 200)
+		compressor := gzip.NewWriter(writer)
+		compressor.Write(text)
+		compressor.Close()
+		writer.Close()
+
+		compressed, err := ctx.Decrypt(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Режим %d: ошибка расшифровки: %v", mode, err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("Режим %d: расшифрованные данные не являются gzip: %v", mode, err)
+		}
+		restored, err := io.ReadAll(reader)
+		if err != nil || !bytes.Equal(restored, text) {
+			t.Errorf("Режим %d: данные после gzip и шифрования восстановлены неверно: %v", mode, err)
+		}
+	}
+
+	cipher, _, _ := CreateCipher("aes128")
+	ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if _, err := cripta.NewEncryptingWriter(ctx, io.Discard); err == nil {
+		t.Errorf("CBC не является потоковым режимом, ожидалась ошибка")
+	}
+}
+
+// TestDecryptingReader пропускает большой случайный буфер через шифрующую
+// и расшифровывающую обертки, соединенные каналом io.Pipe
+func TestDecryptingReader(t *testing.T) {
+	fmt.Println("\nТЕСТ РАСШИФРОВЫВАЮЩЕЙ ОБЕРТКИ IO.READER")
+
+	key := generateRandomBytes(16)
+	data := generateRandomBytes(1 << 18)
+
+	for _, mode := range []cripta.CipherMode{cripta.CipherModeCFB, cripta.CipherModeOFB, cripta.CipherModeCTR} {
+		for _, autoIV := range []bool{false, true} {
+			cipher, _, _ := CreateCipher("aes128")
+			ctx, err := cripta.NewCipherContext(cipher, key, mode, cripta.PaddingModeNone, generateRandomBytes(16), 16, false)
+			if err != nil {
+				t.Fatalf("Ошибка создания контекста: %v", err)
+			}
+			ctx.SetAutoIV(autoIV)
+
+			pipeReader, pipeWriter := io.Pipe()
+			go func() {
+				writer, err := cripta.NewEncryptingWriter(ctx, pipeWriter)
+				if err != nil {
+					pipeWriter.CloseWithError(err)
+					return
+				}
+				// Порции не кратны блоку, чтобы гамма переходила между вызовами Write
+				for offset := 0; offset < len(data); offset += 1000 {
+					if _, err := writer.Write(data[offset:min(offset+1000, len(data))]); err != nil {
+						return
+					}
+				}
+				writer.Close()
+			}()
+
+			reader, err := cripta.NewDecryptingReader(ctx, pipeReader)
+			if err != nil {
+				t.Fatalf("Режим %d: ошибка создания обертки: %v", mode, err)
+			}
+			var restored bytes.Buffer
+			if _, err := io.CopyBuffer(&restored, reader, make([]byte, 333)); err != nil {
+				t.Fatalf("Режим %d, AutoIV %v: ошибка чтения: %v", mode, autoIV, err)
+			}
+			if !bytes.Equal(restored.Bytes(), data) {
+				t.Errorf("Режим %d, AutoIV %v: данные восстановлены неточно", mode, autoIV)
+			}
+		}
+	}
+
+	cipher, _, _ := CreateCipher("aes128")
+	ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeECB, cripta.PaddingModePKCS7, nil, 16, false)
+	if _, err := cripta.NewDecryptingReader(ctx, bytes.NewReader(nil)); err == nil {
+		t.Errorf("ECB не является потоковым режимом, ожидалась ошибка")
+	}
+}
+
+// TestCounterOverflow ограничивает счетчик CTR четырьмя битами (16 блоков)
+// и проверяет перенос внутри них и ошибку при переполнении
+func TestCounterOverflow(t *testing.T) {
+	fmt.Println("\nТЕСТ ПЕРЕПОЛНЕНИЯ СЧЕТЧИКА CTR")
+
+	key := generateRandomBytes(8)
+	iv := generateRandomBytes(8)
+	iv[7] |= 0x0f // младшие 4 бита - максимальное значение счетчика
+
+	for _, parallel := range []bool{false, true} {
+		cipher, _, _ := CreateCipher("des")
+		ctx, err := cripta.NewCipherContext(cipher, key, cripta.CipherModeCTR, cripta.PaddingModeNone, iv, 8, parallel)
+		if err != nil {
+			t.Fatalf("Ошибка создания контекста: %v", err)
+		}
+		if err := ctx.SetCounterBits(4); err != nil {
+			t.Fatalf("Ошибка установки ширины счетчика: %v", err)
+		}
+
+		// Ровно 16 блоков помещаются в пространство счетчика
+		data := generateRandomBytes(16 * 8)
+		encrypted, err := ctx.Encrypt(data)
+		if err != nil {
+			t.Fatalf("parallel=%v: 16 блоков должны шифроваться: %v", parallel, err)
+		}
+		decrypted, err := ctx.Decrypt(encrypted)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Errorf("parallel=%v: ошибка расшифровки: %v", parallel, err)
+		}
+
+		// Второй блок гаммы: младшие 4 бита перешли через 0, старшие биты IV не изменились
+		keystream, _ := ctx.Encrypt(make([]byte, 16))
+		next := append([]byte{}, iv...)
+		next[7] &^= 0x0f
+		block, _ := cipher.EncryptBlock(next)
+		if !bytes.Equal(keystream[8:16], block) {
+			t.Errorf("parallel=%v: счетчик должен переноситься только внутри 4 младших битов", parallel)
+		}
+
+		if _, err := ctx.Encrypt(make([]byte, 16*8+1)); !errors.Is(err, cripta.ErrCounterOverflow) {
+			t.Errorf("parallel=%v: ожидалась ErrCounterOverflow, получено %v", parallel, err)
+		}
+		if _, err := ctx.Decrypt(make([]byte, 17*8)); !errors.Is(err, cripta.ErrCounterOverflow) {
+			t.Errorf("parallel=%v: при расшифровке ожидалась ErrCounterOverflow, получено %v", parallel, err)
+		}
+	}
+
+	cipher, _, _ := CreateCipher("des")
+	ctx, _ := cripta.NewCipherContext(cipher, key, cripta.CipherModeCTR, cripta.PaddingModeNone, iv, 8, false)
+	ctx.SetCounterBits(4)
+	writer, err := cripta.NewEncryptingWriter(ctx, io.Discard)
+	if err != nil {
+		t.Fatalf("Ошибка создания обертки: %v", err)
+	}
+	if _, err := writer.Write(make([]byte, 16*8)); err != nil {
+		t.Fatalf("16 блоков должны записываться: %v", err)
+	}
+	if _, err := writer.Write([]byte{0}); !errors.Is(err, cripta.ErrCounterOverflow) {
+		t.Errorf("Обертка: ожидалась ErrCounterOverflow, получено %v", err)
+	}
+
+	if err := ctx.SetCounterBits(65); err == nil {
+		t.Errorf("Счетчик шире блока должен отклоняться")
+	}
+}
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	mathrand "math/rand"
+	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"OKLabs/cripta"
 )
 
+// weakKeyPolicy разрешает 512-битные ключи, на которых тесты RSA работают быстро;
+// пороги те же, что в DefaultRSAKeyPolicy, поэтому предупреждение сохраняется
+var weakKeyPolicy = cripta.RSAKeyPolicy{MinKeyBits: 1024, WarnKeyBits: 2048, AllowWeakKeys: true}
+
 // TestRSAIntegration запускает основные интеграционные тесты
 func TestRSAIntegration(t *testing.T) {
 	fmt.Println("\nИНТЕГРАЦИОННЫЕ ТЕСТЫ RSA СИСТЕМЫ")
@@ -45,6 +58,7 @@ func testKeyGeneration(t *testing.T) {
 		start := time.Now()
 		
 		rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.9999, size)
+		rsa.SetKeyPolicy(weakKeyPolicy)
 		err := rsa.GenerateNewKey()
 		
 		duration := time.Since(start)
@@ -297,6 +311,923 @@ func TestMathFunctions(t *testing.T) {
 	fmt.Printf("РЕЗУЛЬТАТ: %d/%d тестов пройдено\n", passed, total)
 }
 
+// TestPrivateKeyValidation проверяет обнаружение поврежденного закрытого ключа
+func TestPrivateKeyValidation(t *testing.T) {
+	fmt.Println("\nТЕСТ ПРОВЕРКИ ЗАКРЫТОГО КЛЮЧА")
+
+	generator := cripta.NewRSAKeyGenerator(cripta.RSAMillerRabin, 0.999, 512)
+	key, err := generator.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	if err := key.PrivateKey.Validate(); err != nil {
+		t.Errorf("Корректный ключ не прошел проверку: %v", err)
+	}
+
+	tamperedD := key.PrivateKey
+	tamperedD.D = new(big.Int).Add(key.PrivateKey.D, big.NewInt(1))
+	if err := tamperedD.Validate(); err == nil {
+		t.Errorf("Ключ с измененным d должен отклоняться")
+	}
+
+	tamperedN := key.PrivateKey
+	tamperedN.N = new(big.Int).Add(key.PrivateKey.N, big.NewInt(2))
+	if err := tamperedN.Validate(); err == nil {
+		t.Errorf("Ключ с N != P*Q должен отклоняться")
+	}
+}
+
+// TestFixedWindowModExp сверяет возведение в степень с фиксированным окном с BigModExp
+func TestFixedWindowModExp(t *testing.T) {
+	fmt.Println("\nТЕСТ ВОЗВЕДЕНИЯ В СТЕПЕНЬ С ФИКСИРОВАННЫМ ОКНОМ")
+
+	limit := new(big.Int).Lsh(big.NewInt(1), 256)
+	for i := 0; i < 50; i++ {
+		base, _ := rand.Int(rand.Reader, limit)
+		exp, _ := rand.Int(rand.Reader, limit)
+		mod, _ := rand.Int(rand.Reader, limit)
+		mod.Add(mod, big.NewInt(2))
+
+		for _, window := range []int{1, 3, 4, 5} {
+			got := cripta.FixedWindowModExp(base, exp, mod, window)
+			if expected := cripta.BigModExp(base, exp, mod); got.Cmp(expected) != 0 {
+				t.Fatalf("окно %d: %v^%v mod %v = %v, ожидалось %v", window, base, exp, mod, got, expected)
+			}
+		}
+	}
+
+	if got := cripta.FixedWindowModExp(big.NewInt(7), big.NewInt(0), big.NewInt(13), 4); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("7^0 mod 13 = %v, ожидалось 1", got)
+	}
+	for _, window := range []int{0, -1, cripta.MaxFixedWindowBits + 1} {
+		if got := cripta.FixedWindowModExp(big.NewInt(7), big.NewInt(5), big.NewInt(13), window); got != nil {
+			t.Errorf("Окно %d должно отклоняться, получено %v", window, got)
+		}
+	}
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+	if err := rsa.SetFixedWindowDecryption(cripta.MaxFixedWindowBits + 1); err == nil {
+		t.Errorf("Ширина окна больше %d должна отклоняться", cripta.MaxFixedWindowBits)
+	}
+	if err := rsa.SetFixedWindowDecryption(4); err != nil {
+		t.Fatalf("Ошибка установки окна: %v", err)
+	}
+
+	message := "фиксированное окно"
+	encrypted, err := rsa.EncryptString(message)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	decrypted, err := rsa.DecryptString(encrypted)
+	if err != nil || decrypted != message {
+		t.Errorf("Расшифровка с фиксированным окном не совпала: %q, %v", decrypted, err)
+	}
+}
+
+// TestElGamal проверяет шифрование Эль-Гамаля и рандомизацию шифртекста
+func TestElGamal(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРОВАНИЯ ЭЛЬ-ГАМАЛЯ")
+
+	elgamal := cripta.NewElGamalService(cripta.MillerRabinTest, 0.999, 256)
+	if err := elgamal.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	for _, message := range []string{"a", "Эль-Гамаль", "short message"} {
+		c1, c2, err := elgamal.Encrypt([]byte(message))
+		if err != nil {
+			t.Fatalf("Ошибка шифрования %q: %v", message, err)
+		}
+
+		decrypted, err := elgamal.Decrypt(c1, c2)
+		if err != nil || string(decrypted) != message {
+			t.Errorf("Расшифровка %q дала %q: %v", message, decrypted, err)
+		}
+	}
+
+	message := []byte("одно и то же")
+	c1a, c2a, _ := elgamal.Encrypt(message)
+	c1b, c2b, _ := elgamal.Encrypt(message)
+	if c1a.Cmp(c1b) == 0 && c2a.Cmp(c2b) == 0 {
+		t.Errorf("Два шифрования одного сообщения должны различаться")
+	}
+
+	pub, _ := elgamal.GetPublicKey()
+	if pub.P.BitLen() != 256 {
+		t.Errorf("Длина p %d бит вместо 256", pub.P.BitLen())
+	}
+	if _, _, err := elgamal.Encrypt(new(big.Int).Add(pub.P, big.NewInt(1)).Bytes()); err == nil {
+		t.Errorf("Сообщение не меньше p должно отклоняться")
+	}
+
+	for _, message := range [][]byte{{0x00, 0x00, 0x42}, {0x00}, {}} {
+		c1, c2, err := elgamal.Encrypt(message)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования %x: %v", message, err)
+		}
+		decrypted, err := elgamal.Decrypt(c1, c2)
+		if err != nil || !bytes.Equal(decrypted, message) {
+			t.Errorf("Ведущие нули потеряны: %x -> %x: %v", message, decrypted, err)
+		}
+	}
+
+	// Генерация ключа и шифрование читают cripta.RandReader: при одинаковом
+	// детерминированном источнике ключи и шифртексты совпадают
+	saved := cripta.RandReader
+	defer func() { cripta.RandReader = saved }()
+
+	var keys []*cripta.ElGamalPublicKey
+	var ciphertexts [][2]*big.Int
+	for i := 0; i < 2; i++ {
+		cripta.RandReader = mathrand.New(mathrand.NewSource(1135))
+		service := cripta.NewElGamalService(cripta.MillerRabinTest, 0.999, 128)
+		if err := service.GenerateNewKey(); err != nil {
+			t.Fatalf("Ошибка генерации ключа: %v", err)
+		}
+		pub, _ := service.GetPublicKey()
+		c1, c2, err := service.Encrypt([]byte("seed"))
+		if err != nil {
+			t.Fatalf("Ошибка шифрования: %v", err)
+		}
+		keys = append(keys, pub)
+		ciphertexts = append(ciphertexts, [2]*big.Int{c1, c2})
+	}
+	if keys[0].P.Cmp(keys[1].P) != 0 || keys[0].Y.Cmp(keys[1].Y) != 0 {
+		t.Errorf("При одинаковом RandReader ключи должны совпадать")
+	}
+	if ciphertexts[0][0].Cmp(ciphertexts[1][0]) != 0 || ciphertexts[0][1].Cmp(ciphertexts[1][1]) != 0 {
+		t.Errorf("При одинаковом RandReader шифртексты должны совпадать")
+	}
+}
+
+// TestRSABlockSizes проверяет размеры блоков открытого текста и шифртекста
+func TestRSABlockSizes(t *testing.T) {
+	fmt.Println("\nТЕСТ РАЗМЕРОВ БЛОКОВ RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	rsa.SetPadding(cripta.RSAPaddingNone)
+	if _, err := rsa.MaxPlaintextBlockSize(); err == nil {
+		t.Errorf("До генерации ключа размер блока должен возвращать ошибку")
+	}
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	maxPlain, err := rsa.MaxPlaintextBlockSize()
+	if err != nil {
+		t.Fatalf("Ошибка получения размера блока: %v", err)
+	}
+	cipherBlock, err := rsa.CiphertextBlockSize()
+	if err != nil {
+		t.Fatalf("Ошибка получения размера блока: %v", err)
+	}
+	if maxPlain != cipherBlock-11 {
+		t.Errorf("Размер блока открытого текста %d, ожидалось %d", maxPlain, cipherBlock-11)
+	}
+
+	message := []byte(strings.Repeat("x", maxPlain))
+	encrypted, err := rsa.Encrypt(message)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if len(encrypted) != cipherBlock {
+		t.Errorf("Шифртекст %d байт, ожидался один блок %d байт", len(encrypted), cipherBlock)
+	}
+
+	long := []byte(strings.Repeat("y", 3*maxPlain))
+	encrypted, err = rsa.Encrypt(long)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if len(encrypted) != 3*cipherBlock {
+		t.Errorf("Шифртекст %d байт, ожидалось три блока по %d байт", len(encrypted), cipherBlock)
+	}
+	decrypted, err := rsa.Decrypt(encrypted)
+	if err != nil || string(decrypted) != string(long) {
+		t.Errorf("Поблочная расшифровка не совпала: %v", err)
+	}
+}
+
+// TestWienerAttackTrace проверяет, что атака не пишет в stdout, а ведет трассировку
+func TestWienerAttackTrace(t *testing.T) {
+	fmt.Println("\nТЕСТ ТРАССИРОВКИ АТАКИ ВИНЕРА")
+
+	publicKey := &cripta.RSAPublicKey{N: big.NewInt(90581), E: big.NewInt(17993)}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Ошибка создания канала: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	result := cripta.NewWienerAttackService().Attack(publicKey)
+
+	os.Stdout = stdout
+	writer.Close()
+	output, _ := io.ReadAll(reader)
+
+	if len(output) != 0 {
+		t.Errorf("Атака не должна писать в stdout, получено: %q", output)
+	}
+	if !result.Success || result.FoundD.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Ожидалось d = 5, результат: %s", result.Message)
+	}
+
+	expected := fmt.Sprintf("Вычислено %d подходящих дробей", len(result.Convergents))
+	found := false
+	for _, line := range result.Trace {
+		if line == expected {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Трассировка не содержит %q: %v", expected, result.Trace)
+	}
+}
+
+// TestModulusBitLength проверяет, что модуль n всегда имеет ровно заданную длину
+func TestModulusBitLength(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЛИНЫ МОДУЛЯ RSA")
+
+	for _, bitLength := range []int{512, 513, 768} {
+		generator := cripta.NewRSAKeyGenerator(cripta.RSAMillerRabin, 0.999, bitLength)
+		for i := 0; i < 20; i++ {
+			key, err := generator.GenerateKeyPair()
+			if err != nil {
+				t.Fatalf("%d бит: ошибка генерации ключа: %v", bitLength, err)
+			}
+			if got := key.PublicKey.N.BitLen(); got != bitLength {
+				t.Fatalf("%d бит: модуль имеет длину %d бит", bitLength, got)
+			}
+		}
+		fmt.Printf("  %d бит: 20 ключей нужной длины\n", bitLength)
+	}
+}
+
+// TestMultibyteStringRoundTrip проверяет точное восстановление длинной UTF-8 строки,
+// блоки которой режут многобайтовые последовательности, и блоков с ведущими нулями
+func TestMultibyteStringRoundTrip(t *testing.T) {
+	fmt.Println("\nТЕСТ МНОГОБАЙТОВЫХ СТРОК RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	rsa.SetPadding(cripta.RSAPaddingNone)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	message := strings.Repeat("Привет, мир! 🔐🚀 Шифрование RSA ✅ ", 20)
+	encrypted, err := rsa.EncryptString(message)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	decrypted, err := rsa.DecryptString(encrypted)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if decrypted != message {
+		t.Errorf("Строка восстановлена неточно: %d байт вместо %d", len(decrypted), len(message))
+	}
+
+	// Промежуточный блок, начинающийся с нулевых байтов, не должен укорачиваться
+	maxPlain, err := rsa.MaxPlaintextBlockSize()
+	if err != nil {
+		t.Fatalf("Ошибка получения размера блока: %v", err)
+	}
+	data := bytes.Repeat([]byte{0xAB}, 3*maxPlain)
+	data[maxPlain], data[maxPlain+1] = 0, 0
+
+	encrypted, err = rsa.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	decryptedData, err := rsa.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if !bytes.Equal(decryptedData, data) {
+		t.Errorf("Данные с ведущими нулями в блоке восстановлены неточно")
+	}
+
+	// Единственный и последний блоки с ведущими нулями, а также пустое сообщение
+	lastBlock := bytes.Repeat([]byte{0xCD}, 2*maxPlain+10)
+	lastBlock[2*maxPlain], lastBlock[2*maxPlain+1] = 0, 0
+	fullLastBlock := bytes.Repeat([]byte{0xEF}, 2*maxPlain)
+	fullLastBlock[maxPlain] = 0
+	cases := map[string][]byte{
+		"единственный блок":     []byte("\x00Привет"),
+		"только нули":           {0, 0, 0},
+		"последний блок":        lastBlock,
+		"полный последний блок": fullLastBlock,
+		"пустое сообщение":      {},
+	}
+	for name, data := range cases {
+		encrypted, err := rsa.Encrypt(data)
+		if err != nil {
+			t.Fatalf("%s: ошибка шифрования: %v", name, err)
+		}
+		decryptedData, err := rsa.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("%s: ошибка дешифрования: %v", name, err)
+		}
+		if !bytes.Equal(decryptedData, data) {
+			t.Errorf("%s: восстановлено %d байт вместо %d", name, len(decryptedData), len(data))
+		}
+	}
+}
+
+// TestBytesFixedLen проверяет дополнение big-endian представления ведущими нулями
+func TestBytesFixedLen(t *testing.T) {
+	fmt.Println("\nТЕСТ ПРЕОБРАЗОВАНИЯ ЧИСЛА В БАЙТЫ ФИКСИРОВАННОЙ ДЛИНЫ")
+
+	got := cripta.BytesFixedLen(big.NewInt(5), 4)
+	if !bytes.Equal(got, []byte{0, 0, 0, 5}) {
+		t.Errorf("BytesFixedLen(5, 4) = %v, ожидалось [0 0 0 5]", got)
+	}
+
+	if got := cripta.BytesFixedLen(big.NewInt(0), 3); !bytes.Equal(got, []byte{0, 0, 0}) {
+		t.Errorf("BytesFixedLen(0, 3) = %v, ожидалось [0 0 0]", got)
+	}
+	if got := cripta.BytesFixedLen(big.NewInt(0x1234), 2); !bytes.Equal(got, []byte{0x12, 0x34}) {
+		t.Errorf("BytesFixedLen(0x1234, 2) = %v", got)
+	}
+	if got := cripta.BytesFixedLen(big.NewInt(0x1234), 1); got != nil {
+		t.Errorf("Число, не помещающееся в длину, должно давать nil, получено %v", got)
+	}
+}
+
+// TestRSAPadding проверяет все схемы набивки RSA и то, что без набивки
+// шифрование остается «учебным» m^e mod n
+func TestRSAPadding(t *testing.T) {
+	fmt.Println("\nТЕСТ НАБИВКИ RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+	pub, _ := rsa.GetPublicKey()
+	k := len(pub.N.Bytes())
+
+	// По умолчанию используется OAEP: 2*20 + 2 байта блока уходят на набивку
+	if maxPlain, _ := rsa.MaxPlaintextBlockSize(); maxPlain != k-42 {
+		t.Errorf("По умолчанию блок открытого текста %d байт, ожидалось %d (OAEP)", maxPlain, k-42)
+	}
+
+	messages := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("Набивка RSA"),
+		bytes.Repeat([]byte{0x00, 0xAB}, 100),
+	}
+
+	paddings := []struct {
+		name    string
+		padding cripta.RSAPadding
+	}{
+		{"None", cripta.RSAPaddingNone},
+		{"PKCS1v15", cripta.RSAPaddingPKCS1v15},
+		{"OAEP", cripta.RSAPaddingOAEP},
+	}
+
+	for _, p := range paddings {
+		rsa.SetPadding(p.padding)
+
+		for _, message := range messages {
+			encrypted, err := rsa.Encrypt(message)
+			if err != nil {
+				t.Fatalf("%s: ошибка шифрования %d байт: %v", p.name, len(message), err)
+			}
+			if len(encrypted)%k != 0 {
+				t.Errorf("%s: шифртекст %d байт не кратен длине модуля %d", p.name, len(encrypted), k)
+			}
+			decrypted, err := rsa.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("%s: ошибка дешифрования %d байт: %v", p.name, len(message), err)
+			}
+			if !bytes.Equal(decrypted, message) {
+				t.Errorf("%s: сообщение из %d байт восстановлено неточно", p.name, len(message))
+			}
+		}
+
+		first, _ := rsa.Encrypt([]byte("одно и то же"))
+		second, _ := rsa.Encrypt([]byte("одно и то же"))
+		if randomized := !bytes.Equal(first, second); randomized != (p.padding != cripta.RSAPaddingNone) {
+			t.Errorf("%s: рандомизация шифртекста %v не соответствует схеме", p.name, randomized)
+		}
+
+		fmt.Printf("  %s: OK\n", p.name)
+	}
+
+	// Без набивки шифртекст совпадает с m^e mod n фиксированной длины, где m -
+	// сообщение с байтом-маркером последнего блока 0x01 впереди
+	rsa.SetPadding(cripta.RSAPaddingNone)
+	message := []byte("textbook")
+	encrypted, _ := rsa.Encrypt(message)
+	expected := new(big.Int).Exp(new(big.Int).SetBytes(append([]byte{0x01}, message...)), pub.E, pub.N)
+	if !bytes.Equal(encrypted, cripta.BytesFixedLen(expected, k)) {
+		t.Errorf("Без набивки шифртекст должен быть равен m^e mod n")
+	}
+
+	// Испорченный шифртекст OAEP отклоняется общей ошибкой без уточнения причины
+	rsa.SetPadding(cripta.RSAPaddingOAEP)
+	for _, pos := range []int{1, k / 2, k - 1} {
+		encrypted, _ = rsa.Encrypt(message)
+		encrypted[pos] ^= 0x01
+		_, err := rsa.Decrypt(encrypted)
+		if err == nil {
+			t.Errorf("Испорченный в байте %d шифртекст OAEP должен отклоняться", pos)
+		} else if !errors.Is(err, cripta.ErrRSADecryption) {
+			t.Errorf("Байт %d: ожидалась ErrRSADecryption, получено %v", pos, err)
+		}
+	}
+}
+
+// TestExportPublicKeySSH разбирает строку authorized_keys и сверяет N и E с ключом
+func TestExportPublicKeySSH(t *testing.T) {
+	fmt.Println("\nТЕСТ ЭКСПОРТА КЛЮЧА В ФОРМАТЕ SSH")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if _, err := rsa.ExportPublicKeySSH(); err == nil {
+		t.Errorf("До генерации ключа экспорт должен возвращать ошибку")
+	}
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	line, err := rsa.ExportPublicKeySSH()
+	if err != nil {
+		t.Fatalf("Ошибка экспорта: %v", err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 2 || fields[0] != "ssh-rsa" {
+		t.Fatalf("Неверный формат строки: %q", line)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		t.Fatalf("Ошибка декодирования base64: %v", err)
+	}
+
+	// Разбор последовательности строк SSH: 4 байта длины, затем данные
+	var parts [][]byte
+	for len(blob) > 0 {
+		if len(blob) < 4 {
+			t.Fatalf("Обрезанный префикс длины")
+		}
+		length := binary.BigEndian.Uint32(blob)
+		if uint32(len(blob)-4) < length {
+			t.Fatalf("Обрезанные данные: длина %d, осталось %d байт", length, len(blob)-4)
+		}
+		parts = append(parts, blob[4:4+length])
+		blob = blob[4+length:]
+	}
+	if len(parts) != 3 || string(parts[0]) != "ssh-rsa" {
+		t.Fatalf("Ожидалось три поля с типом ssh-rsa, получено %d", len(parts))
+	}
+	for i, mpint := range parts[1:] {
+		if len(mpint) > 0 && mpint[0]&0x80 != 0 {
+			t.Errorf("Поле %d закодировано как отрицательное число", i+1)
+		}
+	}
+
+	pub, _ := rsa.GetPublicKey()
+	if e := new(big.Int).SetBytes(parts[1]); e.Cmp(pub.E) != 0 {
+		t.Errorf("E = %v, ожидалось %v", e, pub.E)
+	}
+	if n := new(big.Int).SetBytes(parts[2]); n.Cmp(pub.N) != 0 {
+		t.Errorf("N не совпадает с модулем ключа")
+	}
+}
+
+// TestBigJacobiSymbol сверяет BigJacobiSymbol с big.Jacobi, в том числе для четных a
+func TestBigJacobiSymbol(t *testing.T) {
+	fmt.Println("\nТЕСТ СИМВОЛА ЯКОБИ ДЛЯ БОЛЬШИХ ЧИСЕЛ")
+
+	for n := int64(3); n < 200; n += 2 {
+		for a := int64(0); a < n; a++ {
+			expected := big.Jacobi(big.NewInt(a), big.NewInt(n))
+			if got := cripta.BigJacobiSymbol(big.NewInt(a), big.NewInt(n)); got.Int64() != int64(expected) {
+				t.Fatalf("(%d/%d) = %v, ожидалось %d", a, n, got, expected)
+			}
+		}
+	}
+}
+
+// TestRSAPrivateKeyNonInvertible проверяет, что e без обратного по модулю φ(n) дает ошибку
+func TestRSAPrivateKeyNonInvertible(t *testing.T) {
+	fmt.Println("\nТЕСТ НЕОБРАТИМОГО ОТКРЫТОГО ПОКАЗАТЕЛЯ RSA")
+
+	// φ(7*11) = 60 делится на 3, поэтому e = 3 не имеет обратного по модулю φ(n)
+	_, err := cripta.NewRSAPrivateKey(big.NewInt(7), big.NewInt(11), big.NewInt(3))
+	if err == nil {
+		t.Fatal("Ожидалась ошибка для e, не взаимно простого с φ(n)")
+	}
+	t.Logf("Ошибка для необратимого e: %v", err)
+
+	key, err := cripta.NewRSAPrivateKey(big.NewInt(7), big.NewInt(11), big.NewInt(7))
+	if err != nil {
+		t.Fatalf("Ошибка для обратимого e: %v", err)
+	}
+	if key.D.Cmp(big.NewInt(43)) != 0 {
+		t.Errorf("d = %v, ожидалось 43", key.D)
+	}
+	if err := key.Validate(); err != nil {
+		t.Errorf("Ключ не прошел проверку: %v", err)
+	}
+}
+
+// TestEulerTotient сверяет функцию Эйлера с известными значениями
+func TestEulerTotient(t *testing.T) {
+	fmt.Println("\nТЕСТ ФУНКЦИИ ЭЙЛЕРА")
+
+	cases := map[int64]int64{1: 1, 2: 1, 9: 6, 10: 4, 36: 12, 97: 96, 3233: 3120, 0: 0, -5: 0}
+	for n, expected := range cases {
+		if got := cripta.EulerTotient(n); got != expected {
+			t.Errorf("φ(%d) = %d, ожидалось %d", n, got, expected)
+		}
+	}
+
+	if got := cripta.BigEulerTotientFromFactors(big.NewInt(61), big.NewInt(53)); got.Cmp(big.NewInt(3120)) != 0 {
+		t.Errorf("φ(61*53) = %v, ожидалось 3120", got)
+	}
+
+	key, err := cripta.NewRSAKeyGenerator(cripta.RSAMillerRabin, 0.99, 512).GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+	p, q := key.PrivateKey.P, key.PrivateKey.Q
+	expected := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+	if got := cripta.BigEulerTotientFromFactors(p, q); got.Cmp(expected) != 0 {
+		t.Errorf("φ(n) = %v, ожидалось (p-1)(q-1) = %v", got, expected)
+	}
+	if key.PrivateKey.Phi().Cmp(expected) != 0 {
+		t.Errorf("RSAPrivateKey.Phi не совпадает с (p-1)(q-1)")
+	}
+}
+
+// TestPKCS1v15UnpadConstantTime подает на дешифрование EM, испорченные в разных
+// позициях, и проверяет, что все они отклоняются одной и той же общей ошибкой,
+// а корректные EM с разделителем в любом месте снимаются полностью. Время не
+// измеряется: проверяется, что результат не выдает место нарушения набивки.
+func TestPKCS1v15UnpadConstantTime(t *testing.T) {
+	fmt.Println("\nТЕСТ СНЯТИЯ НАБИВКИ PKCS#1 V1.5 ЗА ПОСТОЯННОЕ ВРЕМЯ")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+	rsa.SetPadding(cripta.RSAPaddingPKCS1v15)
+	pub, _ := rsa.GetPublicKey()
+	k := len(pub.N.Bytes())
+
+	// buildEM строит 0x00 || 0x02 || PS(0xFF) || 0x00 || message
+	buildEM := func(message []byte) []byte {
+		em := bytes.Repeat([]byte{0xFF}, k)
+		em[0], em[1] = 0x00, 0x02
+		em[k-len(message)-1] = 0x00
+		copy(em[k-len(message):], message)
+		return em
+	}
+	encryptEM := func(em []byte) []byte {
+		c := new(big.Int).Exp(new(big.Int).SetBytes(em), pub.E, pub.N)
+		return cripta.BytesFixedLen(c, k)
+	}
+
+	valid := [][]byte{
+		{},
+		[]byte("сообщение"),
+		{0x00, 0x00, 0x01},
+		bytes.Repeat([]byte{0x5A}, k-3-8),
+	}
+	for _, message := range valid {
+		decrypted, err := rsa.Decrypt(encryptEM(buildEM(message)))
+		if err != nil {
+			t.Fatalf("Корректная набивка для %d байт отклонена: %v", len(message), err)
+		}
+		if !bytes.Equal(decrypted, message) {
+			t.Errorf("Сообщение из %d байт восстановлено неточно: %x", len(message), decrypted)
+		}
+	}
+
+	invalid := map[string]func() []byte{
+		"первый байт не 0x00": func() []byte {
+			em := buildEM([]byte("m"))
+			em[0] = 0x01
+			return em
+		},
+		"тип блока не 0x02": func() []byte {
+			em := buildEM([]byte("m"))
+			em[1] = 0x01
+			return em
+		},
+		"нет разделителя": func() []byte {
+			em := buildEM(nil)
+			em[k-1] = 0xFF
+			return em
+		},
+		"PS короче 8 байт": func() []byte {
+			em := buildEM(nil)
+			em[2+7] = 0x00
+			return em
+		},
+		"пустая PS": func() []byte {
+			em := buildEM(nil)
+			em[2] = 0x00
+			return em
+		},
+	}
+	for name, build := range invalid {
+		_, err := rsa.Decrypt(encryptEM(build()))
+		if !errors.Is(err, cripta.ErrRSADecryption) {
+			t.Errorf("%s: ожидалась ErrRSADecryption, получено %v", name, err)
+		} else if err.Error() != cripta.ErrRSADecryption.Error() {
+			t.Errorf("%s: ошибка не должна уточнять причину: %v", name, err)
+		}
+	}
+}
+
+// TestRSAKeyPolicy проверяет политику длины ключа: по умолчанию 512 бит отклоняется,
+// с AllowWeakKeys создается с предупреждением, 2048 бит принимается без предупреждения
+func TestRSAKeyPolicy(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОЛИТИКИ ДЛИНЫ КЛЮЧА RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	if err := rsa.GenerateNewKey(); !errors.Is(err, cripta.ErrWeakRSAKey) {
+		t.Fatalf("512-битный ключ по умолчанию должен отклоняться с ErrWeakRSAKey, получено %v", err)
+	}
+	if _, err := rsa.GetPublicKey(); err == nil {
+		t.Errorf("Отклоненный ключ не должен устанавливаться")
+	}
+
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("С AllowWeakKeys 512-битный ключ должен создаваться: %v", err)
+	}
+	if rsa.KeyWarning() == "" {
+		t.Errorf("Для 512-битного ключа ожидалось предупреждение")
+	}
+
+	rsa = cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 1024)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("1024-битный ключ должен создаваться: %v", err)
+	}
+	if rsa.KeyWarning() == "" {
+		t.Errorf("Для 1024-битного ключа ожидалось предупреждение")
+	}
+
+	rsa = cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 2048)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("2048-битный ключ должен создаваться: %v", err)
+	}
+	if warning := rsa.KeyWarning(); warning != "" {
+		t.Errorf("2048-битный ключ должен приниматься без предупреждения: %s", warning)
+	}
+}
+
+// TestWienerConvergents проверяет, что подходящие дроби приближаются к e/n
+// с убывающей погрешностью, а последняя равна e/n
+func TestWienerConvergents(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОДХОДЯЩИХ ДРОБЕЙ АТАКИ ВИНЕРА")
+
+	publicKey := &cripta.RSAPublicKey{N: big.NewInt(90581), E: big.NewInt(17993)}
+	result := cripta.NewWienerAttackService().Attack(publicKey)
+
+	lines := result.ConvergentsString()
+	if len(lines) != len(result.Convergents) || len(lines) == 0 {
+		t.Fatalf("Ожидалось %d строк, получено %d", len(result.Convergents), len(lines))
+	}
+	if lines[0] != "0/1" {
+		t.Errorf("Первая подходящая дробь к e/n < 1 должна быть 0/1, получено %s", lines[0])
+	}
+
+	target := new(big.Rat).SetFrac(publicKey.E, publicKey.N)
+	var prevError *big.Rat
+	for i, convergent := range result.Convergents {
+		if lines[i] != convergent.A.String()+"/"+convergent.B.String() {
+			t.Errorf("Строка %d: %s, ожидалось %s/%s", i, lines[i], convergent.A, convergent.B)
+		}
+
+		approx := new(big.Rat).SetFrac(convergent.A, convergent.B)
+		diff := new(big.Rat).Sub(approx, target)
+		diff.Abs(diff)
+		if prevError != nil && diff.Cmp(prevError) >= 0 {
+			t.Errorf("Погрешность дроби %s не меньше предыдущей", lines[i])
+		}
+		prevError = diff
+	}
+
+	if prevError.Sign() != 0 {
+		t.Errorf("Последняя подходящая дробь %s должна равняться e/n", lines[len(lines)-1])
+	}
+}
+
+// TestHybridKeyTransport передает симметричный ключ через RSA и шифрует им данные
+func TestHybridKeyTransport(t *testing.T) {
+	fmt.Println("\nТЕСТ ПЕРЕДАЧИ СИММЕТРИЧНОГО КЛЮЧА ЧЕРЕЗ RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+	pub, _ := rsa.GetPublicKey()
+	info := []byte("файл 1")
+
+	symKey, wrapped, err := cripta.DeriveSymmetricKey(pub, info)
+	if err != nil {
+		t.Fatalf("Ошибка вывода ключа: %v", err)
+	}
+	if len(symKey) != 32 || len(wrapped) != len(pub.N.Bytes()) {
+		t.Fatalf("Длины ключа %d и обернутого ключа %d не соответствуют ожидаемым", len(symKey), len(wrapped))
+	}
+
+	recovered, err := rsa.RecoverSymmetricKey(wrapped, info)
+	if err != nil {
+		t.Fatalf("Ошибка восстановления ключа: %v", err)
+	}
+	if !bytes.Equal(recovered, symKey) {
+		t.Fatalf("Восстановленный ключ не совпадает с исходным")
+	}
+
+	// Ключ применим для симметричного шифрования данных
+	cipher, _, err := cripta.NewCipherByName("aes256", len(symKey))
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	iv := make([]byte, 16)
+	rand.Read(iv)
+	ctx, err := cripta.NewCipherContext(cipher, recovered, cripta.CipherModeCBC, cripta.PaddingModePKCS7, iv, 16, false)
+	if err != nil {
+		t.Fatalf("Ошибка создания контекста: %v", err)
+	}
+	message := []byte("данные, зашифрованные переданным ключом")
+	encrypted, _ := ctx.Encrypt(message)
+	if decrypted, err := ctx.Decrypt(encrypted); err != nil || !bytes.Equal(decrypted, message) {
+		t.Errorf("Ошибка шифрования переданным ключом: %v", err)
+	}
+
+	other, err := rsa.RecoverSymmetricKey(wrapped, []byte("файл 2"))
+	if err != nil || bytes.Equal(other, symKey) {
+		t.Errorf("Другой info должен давать другой ключ (%v)", err)
+	}
+
+	second, secondWrapped, _ := cripta.DeriveSymmetricKey(pub, info)
+	if bytes.Equal(second, symKey) || bytes.Equal(secondWrapped, wrapped) {
+		t.Errorf("Каждый вызов должен генерировать новый ключ")
+	}
+
+	tampered := append([]byte(nil), wrapped...)
+	tampered[len(tampered)/2] ^= 0x01
+	if key, err := rsa.RecoverSymmetricKey(tampered, info); err == nil && bytes.Equal(key, symKey) {
+		t.Errorf("Измененный обернутый ключ не должен давать исходный ключ")
+	}
+	if _, err := rsa.RecoverSymmetricKey(wrapped[1:], info); err == nil {
+		t.Errorf("Обернутый ключ неверной длины должен отклоняться")
+	}
+}
+
+// TestRSAServiceConcurrent шифрует и дешифрует из нескольких горутин одним
+// сервисом, пока другая горутина меняет ключ; запускать с -race
+func TestRSAServiceConcurrent(t *testing.T) {
+	fmt.Println("\nТЕСТ ПАРАЛЛЕЛЬНОЙ РАБОТЫ С СЕРВИСОМ RSA")
+
+	rsa := cripta.NewRSAService(cripta.RSAMillerRabin, 0.999, 512)
+	rsa.SetKeyPolicy(weakKeyPolicy)
+	if err := rsa.GenerateNewKey(); err != nil {
+		t.Fatalf("Ошибка генерации ключа: %v", err)
+	}
+
+	const workers = 8
+	const iterations = 5
+
+	// Ключ не меняется: каждая горутина должна получить свое сообщение обратно
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				message := []byte(fmt.Sprintf("горутина %d, сообщение %d", w, i))
+				ciphertext, err := rsa.Encrypt(message)
+				if err != nil {
+					t.Errorf("Горутина %d: ошибка шифрования: %v", w, err)
+					return
+				}
+				decrypted, err := rsa.Decrypt(ciphertext)
+				if err != nil {
+					t.Errorf("Горутина %d: ошибка дешифрования: %v", w, err)
+					return
+				}
+				if !bytes.Equal(decrypted, message) {
+					t.Errorf("Горутина %d: получено %q, ожидалось %q", w, decrypted, message)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Ключ меняется во время шифрования: каждая операция выполняется одним из ключей,
+	// поэтому длина шифртекста всегда равна длине модуля
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2; i++ {
+			if err := rsa.GenerateNewKey(); err != nil {
+				t.Errorf("Ошибка смены ключа: %v", err)
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				ciphertext, err := rsa.Encrypt([]byte("сообщение"))
+				if err != nil {
+					t.Errorf("Горутина %d: ошибка шифрования: %v", w, err)
+					return
+				}
+				if len(ciphertext) != 64 {
+					t.Errorf("Горутина %d: длина шифртекста %d, ожидалось 64", w, len(ciphertext))
+					return
+				}
+				if _, err := rsa.GetPublicKey(); err != nil {
+					t.Errorf("Горутина %d: %v", w, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	<-done
+}
+
+// FuzzPrimality сравнивает вердикты тестов простоты с big.Int.ProbablyPrime(40).
+// Исключение - тест Ферма на числах Кармайкла: они проходят проверку a^(n-1) = 1
+// для всех взаимно простых с n оснований, и тест Ферма может принять их за простые.
+// Такое расхождение допускается, если n ведет себя как число Кармайкла.
+func FuzzPrimality(f *testing.F) {
+	for _, seed := range []int64{0, 1, 2, 3, 4, 97, 561, 1105, 7919, 41041, 825265, 1000003, 999999999989} {
+		f.Add(big.NewInt(seed).Bytes())
+	}
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xc5}) // 2^64 - 59, простое
+
+	tests := []cripta.PrimalityTest{
+		cripta.NewFermatTest(),
+		cripta.NewSolovayStrassenTest(),
+		cripta.NewMillerRabinTest(),
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 64 {
+			data = data[:64]
+		}
+		n := new(big.Int).SetBytes(data)
+		expected := n.ProbablyPrime(40)
+
+		for _, test := range tests {
+			if got := test.IsPrime(n, 0.999999); got != expected {
+				if got && test.TestName() == cripta.NewFermatTest().TestName() && looksLikeCarmichael(n) {
+					continue
+				}
+				t.Errorf("%s: IsPrime(%v) = %v, big.Int.ProbablyPrime = %v", test.TestName(), n, got, expected)
+			}
+		}
+	})
+}
+
+// looksLikeCarmichael проверяет, что составное n проходит тест Ферма по 20 случайным
+// взаимно простым основаниям. Для составного числа, не являющегося числом Кармайкла,
+// таких оснований не больше половины, поэтому ложный ответ маловероятен.
+func looksLikeCarmichael(n *big.Int) bool {
+	nMinusOne := new(big.Int).Sub(n, big.NewInt(1))
+	for i := 0; i < 20; {
+		a, err := rand.Int(rand.Reader, nMinusOne)
+		if err != nil || a.Cmp(big.NewInt(2)) < 0 {
+			continue
+		}
+		if new(big.Int).GCD(nil, nil, a, n).Cmp(big.NewInt(1)) != 0 {
+			continue
+		}
+		if new(big.Int).Exp(a, nMinusOne, n).Cmp(big.NewInt(1)) != 0 {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
 // BenchmarkRSA бенчмарки производительности
 func BenchmarkRSA(b *testing.B) {
 	fmt.Println("\nБЕНЧМАРК ПРОИЗВОДИТЕЛЬНОСТИ RSA")
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"crypto/aes"
+	stdcipher "crypto/cipher"
 	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,6 +49,16 @@ type TestResult struct {
 	errorDetail     string
 }
 
+// ThroughputStats медианная (p50) и 95-я перцентиль скорости в MB/s
+// по успешным тестам матрицы
+type ThroughputStats struct {
+	encryptP50 float64
+	encryptP95 float64
+	decryptP50 float64
+	decryptP95 float64
+	samples    int
+}
+
 func TestRijndaelAll(t *testing.T) {
 	if err := checkDirectories(); err != nil {
 		t.Fatalf("Ошибка проверки директорий: %v", err)
@@ -119,14 +136,13 @@ func generateRijndaelTests(files []string) []RijndaelTest {
 	}
 
 	modes := []struct {
-		mode        cripta.CipherMode
-		modeName    string
-		canParallel bool
+		mode     cripta.CipherMode
+		modeName string
 	}{
-		{cripta.CipherModeECB, "ECB", true},
-		{cripta.CipherModeCBC, "CBC", false},
-		{cripta.CipherModeCTR, "CTR", true},
-		{cripta.CipherModeCFB, "CFB", false},
+		{cripta.CipherModeECB, "ECB"},
+		{cripta.CipherModeCBC, "CBC"},
+		{cripta.CipherModeCTR, "CTR"},
+		{cripta.CipherModeCFB, "CFB"},
 	}
 
 	paddings := []struct {
@@ -155,7 +171,7 @@ func generateRijndaelTests(files []string) []RijndaelTest {
 						modeName:    modes[modeIdx].modeName,
 						padding:     paddings[paddingIdx].padding,
 						paddingName: paddings[paddingIdx].paddingName,
-						parallel:    modes[modeIdx].canParallel && (fileIdx == 1),
+						parallel:    modes[modeIdx].mode.SupportsParallel() && (fileIdx == 1),
 						inputFile:   files[fileIdx],
 					})
 				}
@@ -312,6 +328,57 @@ func printSummary(results []TestResult) {
 			decSpeed,
 			status)
 	}
+
+	stats := computeThroughputStats(results)
+	if stats.samples > 0 {
+		fmt.Printf("\nСкорость по %d успешным тестам (МБ/с): шифр p50 %.1f, p95 %.1f; дешифр p50 %.1f, p95 %.1f\n",
+			stats.samples, stats.encryptP50, stats.encryptP95, stats.decryptP50, stats.decryptP95)
+	}
+}
+
+// computeThroughputStats считает перцентили скорости только по успешным тестам
+func computeThroughputStats(results []TestResult) ThroughputStats {
+	var encrypt, decrypt []float64
+	for _, result := range results {
+		if result.success {
+			encrypt = append(encrypt, result.encryptSpeedMBs)
+			decrypt = append(decrypt, result.decryptSpeedMBs)
+		}
+	}
+
+	return ThroughputStats{
+		encryptP50: percentile(encrypt, 50),
+		encryptP95: percentile(encrypt, 95),
+		decryptP50: percentile(decrypt, 50),
+		decryptP95: percentile(decrypt, 95),
+		samples:    len(encrypt),
+	}
+}
+
+// percentile возвращает p-ю перцентиль (0..100) с линейной интерполяцией между
+// соседними значениями; для пустого набора возвращает 0. Исходный срез не меняется.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
 }
 
 func testGF28Operations() {
@@ -553,4 +620,862 @@ func TestRijndaelModes(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestRijndaelModulusValidation(t *testing.T) {
+	fmt.Printf("\nПРОВЕРКА НЕПРИВОДИМОСТИ МОДУЛЯ:\n")
+
+	// x⁸ + x⁴ + 1 = (x⁴ + x² + 1)²
+	reducible := byte(0x11)
+
+	_, err := cripta.NewRijndaelCipher(16, 16, reducible)
+	if err == nil {
+		t.Fatalf("Приводимый модуль 0x%02x должен отклоняться", reducible)
+	}
+	if !strings.Contains(err.Error(), "0x11") {
+		t.Errorf("Сообщение об ошибке должно содержать модуль: %v", err)
+	}
+
+	// Без проверки неприводимости модуль все равно отклоняется из-за вырожденного S-бокса
+	_, err = cripta.NewRijndaelCipherWithOptions(16, 16, reducible, cripta.RijndaelOptions{AllowReducibleModulus: true})
+	if err == nil || !strings.Contains(err.Error(), "not a permutation") {
+		t.Errorf("Приводимый модуль при AllowReducibleModulus должен отклоняться как невзаимно однозначный S-бокс: %v", err)
+	}
+
+	gf := cripta.NewGF28Service()
+	for _, poly := range gf.GetAllIrreduciblePolynomials() {
+		if _, err := cripta.NewRijndaelCipher(16, 16, poly); err != nil {
+			t.Errorf("Неприводимый модуль 0x%02x отклонен: %v", poly, err)
+		}
+	}
+
+	fmt.Printf("   Приводимый модуль 0x%02x отклонен, все неприводимые модули приняты\n", reducible)
+}
+
+// aesSBox каноническая таблица S-бокса AES (FIPS-197)
+var aesSBox = []byte{
+	0x63, 0x7c, 0x77, 0x7b, 0xf2, 0x6b, 0x6f, 0xc5, 0x30, 0x01, 0x67, 0x2b, 0xfe, 0xd7, 0xab, 0x76,
+	0xca, 0x82, 0xc9, 0x7d, 0xfa, 0x59, 0x47, 0xf0, 0xad, 0xd4, 0xa2, 0xaf, 0x9c, 0xa4, 0x72, 0xc0,
+	0xb7, 0xfd, 0x93, 0x26, 0x36, 0x3f, 0xf7, 0xcc, 0x34, 0xa5, 0xe5, 0xf1, 0x71, 0xd8, 0x31, 0x15,
+	0x04, 0xc7, 0x23, 0xc3, 0x18, 0x96, 0x05, 0x9a, 0x07, 0x12, 0x80, 0xe2, 0xeb, 0x27, 0xb2, 0x75,
+	0x09, 0x83, 0x2c, 0x1a, 0x1b, 0x6e, 0x5a, 0xa0, 0x52, 0x3b, 0xd6, 0xb3, 0x29, 0xe3, 0x2f, 0x84,
+	0x53, 0xd1, 0x00, 0xed, 0x20, 0xfc, 0xb1, 0x5b, 0x6a, 0xcb, 0xbe, 0x39, 0x4a, 0x4c, 0x58, 0xcf,
+	0xd0, 0xef, 0xaa, 0xfb, 0x43, 0x4d, 0x33, 0x85, 0x45, 0xf9, 0x02, 0x7f, 0x50, 0x3c, 0x9f, 0xa8,
+	0x51, 0xa3, 0x40, 0x8f, 0x92, 0x9d, 0x38, 0xf5, 0xbc, 0xb6, 0xda, 0x21, 0x10, 0xff, 0xf3, 0xd2,
+	0xcd, 0x0c, 0x13, 0xec, 0x5f, 0x97, 0x44, 0x17, 0xc4, 0xa7, 0x7e, 0x3d, 0x64, 0x5d, 0x19, 0x73,
+	0x60, 0x81, 0x4f, 0xdc, 0x22, 0x2a, 0x90, 0x88, 0x46, 0xee, 0xb8, 0x14, 0xde, 0x5e, 0x0b, 0xdb,
+	0xe0, 0x32, 0x3a, 0x0a, 0x49, 0x06, 0x24, 0x5c, 0xc2, 0xd3, 0xac, 0x62, 0x91, 0x95, 0xe4, 0x79,
+	0xe7, 0xc8, 0x37, 0x6d, 0x8d, 0xd5, 0x4e, 0xa9, 0x6c, 0x56, 0xf4, 0xea, 0x65, 0x7a, 0xae, 0x08,
+	0xba, 0x78, 0x25, 0x2e, 0x1c, 0xa6, 0xb4, 0xc6, 0xe8, 0xdd, 0x74, 0x1f, 0x4b, 0xbd, 0x8b, 0x8a,
+	0x70, 0x3e, 0xb5, 0x66, 0x48, 0x03, 0xf6, 0x0e, 0x61, 0x35, 0x57, 0xb9, 0x86, 0xc1, 0x1d, 0x9e,
+	0xe1, 0xf8, 0x98, 0x11, 0x69, 0xd9, 0x8e, 0x94, 0x9b, 0x1e, 0x87, 0xe9, 0xce, 0x55, 0x28, 0xdf,
+	0x8c, 0xa1, 0x89, 0x0d, 0xbf, 0xe6, 0x42, 0x68, 0x41, 0x99, 0x2d, 0x0f, 0xb0, 0x54, 0xbb, 0x16,
+}
+
+func TestRijndaelSBox(t *testing.T) {
+	fmt.Printf("\nПРОВЕРКА S-БОКСА AES:\n")
+
+	cipher, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Не удалось создать шифр: %v", err)
+	}
+
+	sBox := cipher.GetSBox()
+	invSBox := cipher.GetInvSBox()
+
+	if len(sBox) != 256 || len(invSBox) != 256 {
+		t.Fatalf("Размер S-бокса должен быть 256, получено %d и %d", len(sBox), len(invSBox))
+	}
+
+	for i := range aesSBox {
+		if sBox[i] != aesSBox[i] {
+			t.Errorf("S-бокс[0x%02x] = 0x%02x, ожидалось 0x%02x", i, sBox[i], aesSBox[i])
+		}
+		if invSBox[sBox[i]] != byte(i) {
+			t.Errorf("Обратный S-бокс[0x%02x] = 0x%02x, ожидалось 0x%02x", sBox[i], invSBox[sBox[i]], i)
+		}
+	}
+
+	// Возвращаемые таблицы должны быть копиями
+	sBox[0] ^= 0xFF
+	if cipher.GetSBox()[0] != aesSBox[0] {
+		t.Errorf("GetSBox должен возвращать копию таблицы")
+	}
+
+	fmt.Printf("   S-бокс для модуля 0x1B совпадает с каноническим S-боксом AES\n")
+}
+
+func TestRijndaelConstantTimeSBox(t *testing.T) {
+	fmt.Printf("\nПРОВЕРКА S-БОКСА ПОСТОЯННОГО ВРЕМЕНИ:\n")
+
+	configs := []struct {
+		block int
+		key   int
+	}{
+		{16, 16},
+		{16, 24},
+		{16, 32},
+		{24, 24},
+	}
+
+	for _, cfg := range configs {
+		table, err := cripta.NewRijndaelCipher(cfg.block, cfg.key, 0x1B)
+		if err != nil {
+			t.Fatalf("Не удалось создать шифр: %v", err)
+		}
+		constantTime, err := cripta.NewRijndaelCipherWithOptions(cfg.block, cfg.key, 0x1B, cripta.RijndaelOptions{ConstantTimeSBox: true})
+		if err != nil {
+			t.Fatalf("Не удалось создать шифр: %v", err)
+		}
+
+		key := generateRandomBytes(cfg.key)
+		if err := table.SetKey(key); err != nil {
+			t.Fatalf("Ошибка установки ключа: %v", err)
+		}
+		if err := constantTime.SetKey(key); err != nil {
+			t.Fatalf("Ошибка установки ключа: %v", err)
+		}
+
+		for i := 0; i < 16; i++ {
+			block := generateRandomBytes(cfg.block)
+
+			expected, _ := table.EncryptBlock(block)
+			actual, err := constantTime.EncryptBlock(block)
+			if err != nil {
+				t.Fatalf("Ошибка шифрования: %v", err)
+			}
+			if string(expected) != string(actual) {
+				t.Fatalf("AES-%d-%d: шифртексты табличного и постоянного S-бокса различаются", cfg.key*8, cfg.block*8)
+			}
+
+			decrypted, err := constantTime.DecryptBlock(actual)
+			if err != nil {
+				t.Fatalf("Ошибка расшифрования: %v", err)
+			}
+			if string(decrypted) != string(block) {
+				t.Fatalf("AES-%d-%d: расшифрование с S-боксом постоянного времени некорректно", cfg.key*8, cfg.block*8)
+			}
+		}
+	}
+
+	fmt.Printf("   S-бокс постоянного времени совпадает с табличным\n")
+}
+
+func TestDecryptOpenSSL(t *testing.T) {
+	fmt.Printf("\nСОВМЕСТИМОСТЬ С OPENSSL ENC:\n")
+
+	// echo 'Hello from OpenSSL enc! Проверка совместимости.' |
+	//     openssl enc -aes-128-cbc -md md5 -pass pass:lab3-secret
+	fixture, _ := hex.DecodeString("53616c7465645f5fa9f8b3e51d7e65d6db77da684a5fa2a084865d40376575d5" +
+		"c0c094caca04bd4a3c422ad5e481473e332ab4e21f3d7aad7598171f61ba8aa1" +
+		"e0d996109ea74505c21e104ba45a430dbb64d16a86c7f4ff666a32a8627148a4")
+	expected := "Hello from OpenSSL enc! Проверка совместимости.\n"
+
+	plaintext, err := cripta.DecryptOpenSSL(fixture, "lab3-secret")
+	if err != nil {
+		t.Fatalf("Ошибка расшифрования файла OpenSSL: %v", err)
+	}
+	if string(plaintext) != expected {
+		t.Fatalf("Расшифровано '%s', ожидалось '%s'", plaintext, expected)
+	}
+
+	if _, err := cripta.DecryptOpenSSL(fixture, "wrong-password"); err == nil {
+		t.Errorf("Неверный пароль должен приводить к ошибке")
+	}
+	if _, err := cripta.DecryptOpenSSL(fixture[8:], "lab3-secret"); err == nil {
+		t.Errorf("Данные без сигнатуры Salted__ должны отклоняться")
+	}
+
+	fmt.Printf("   Файл openssl enc -aes-128-cbc расшифрован корректно\n")
+}
+
+func TestRijndaelAvalanche(t *testing.T) {
+	fmt.Println("\nТЕСТ ЛАВИННОГО ЭФФЕКТА RIJNDAEL")
+
+	for _, keySize := range []int{16, 24, 32} {
+		cipher, err := cripta.NewRijndaelCipher(16, keySize, 0x1B)
+		if err != nil {
+			t.Fatalf("Ошибка создания шифра: %v", err)
+		}
+
+		key := make([]byte, keySize)
+		block := make([]byte, 16)
+		rand.Read(key)
+		rand.Read(block)
+
+		ratio, err := cripta.AvalancheTest(cipher, key, block)
+		if err != nil {
+			t.Fatalf("Ошибка измерения: %v", err)
+		}
+		fmt.Printf("AES-%d: доля изменившихся битов %.3f\n", keySize*8, ratio)
+
+		if ratio < 0.4 || ratio > 0.6 {
+			t.Errorf("AES-%d: лавинный эффект %.3f вне диапазона [0.4, 0.6]", keySize*8, ratio)
+		}
+	}
+}
+
+func TestGF28Divide(t *testing.T) {
+	fmt.Println("\nТЕСТ ДЕЛЕНИЯ В GF(2^8)")
+
+	gf := cripta.NewGF28Service()
+
+	for _, modulus := range []byte{0x1B, 0x1D, 0x2B, 0x63} {
+		for a := 0; a < 256; a += 7 {
+			for b := 1; b < 256; b += 5 {
+				product, _ := gf.Multiply(byte(a), byte(b), modulus)
+				quotient, err := gf.Divide(product, byte(b), modulus)
+				if err != nil {
+					t.Fatalf("Модуль 0x%02x: ошибка деления: %v", modulus, err)
+				}
+				if quotient != byte(a) {
+					t.Fatalf("Модуль 0x%02x: (0x%02x * 0x%02x) / 0x%02x = 0x%02x", modulus, a, b, b, quotient)
+				}
+			}
+		}
+	}
+
+	if _, err := gf.Divide(0x53, 0, 0x1B); err == nil {
+		t.Errorf("Деление на ноль должно возвращать ошибку")
+	}
+}
+
+// TestGF28Tables проверяет выбор порождающего элемента для таблиц логарифмов
+// и совпадение табличного умножения и обращения с побитовым
+func TestGF28Tables(t *testing.T) {
+	fmt.Println("\nТЕСТ ТАБЛИЦ ЛОГАРИФМОВ GF(2^8)")
+
+	tables, err := cripta.NewGF28ServiceWithTables(0x1B, 0x03)
+	if err != nil {
+		t.Fatalf("0x03 должен быть примитивным по модулю 0x1B: %v", err)
+	}
+
+	// 0x02 по модулю AES имеет порядок 51 и порождает лишь часть поля
+	if _, err := cripta.NewGF28ServiceWithTables(0x1B, 0x02); err == nil {
+		t.Errorf("0x02 не примитивен по модулю 0x1B, ожидалась ошибка")
+	}
+	if _, err := cripta.NewGF28ServiceWithTables(0x1B, 0x00); err == nil {
+		t.Errorf("0x00 не может быть порождающим элементом, ожидалась ошибка")
+	}
+	if _, err := cripta.NewGF28ServiceWithTables(0x00, 0x03); err == nil {
+		t.Errorf("Для приводимого модуля ожидалась ошибка")
+	}
+
+	gf := cripta.NewGF28Service()
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			expected, _ := gf.Multiply(byte(a), byte(b), 0x1B)
+			actual, _ := tables.Multiply(byte(a), byte(b), 0x1B)
+			if actual != expected {
+				t.Fatalf("0x%02x * 0x%02x: по таблицам 0x%02x, ожидалось 0x%02x", a, b, actual, expected)
+			}
+		}
+		if a == 0 {
+			continue
+		}
+		expected, _ := gf.Inverse(byte(a), 0x1B)
+		actual, _ := tables.Inverse(byte(a), 0x1B)
+		if actual != expected {
+			t.Fatalf("Обратный к 0x%02x: по таблицам 0x%02x, ожидалось 0x%02x", a, actual, expected)
+		}
+	}
+
+	// Для другого модуля сервис с таблицами умножает побитово
+	expected, _ := gf.Multiply(0x57, 0x83, 0x1D)
+	actual, _ := tables.Multiply(0x57, 0x83, 0x1D)
+	if actual != expected {
+		t.Errorf("Модуль 0x1D: 0x%02x, ожидалось 0x%02x", actual, expected)
+	}
+}
+
+// TestGF28MultiplicativeOrder проверяет порядки элементов поля AES
+func TestGF28MultiplicativeOrder(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОРЯДКА ЭЛЕМЕНТОВ GF(2^8)")
+
+	gf := cripta.NewGF28Service()
+
+	cases := []struct {
+		element byte
+		order   int
+	}{
+		{0x01, 1},
+		{0x02, 51},
+		{0x03, 255},
+	}
+	for _, tc := range cases {
+		order, err := gf.MultiplicativeOrder(tc.element, 0x1B)
+		if err != nil {
+			t.Fatalf("0x%02x: ошибка: %v", tc.element, err)
+		}
+		if order != tc.order {
+			t.Errorf("Порядок 0x%02x по модулю 0x1B: %d, ожидалось %d", tc.element, order, tc.order)
+		}
+	}
+
+	// Порядок любого ненулевого элемента делит 255
+	primitive := 0
+	for a := 1; a < 256; a++ {
+		order, err := gf.MultiplicativeOrder(byte(a), 0x1B)
+		if err != nil {
+			t.Fatalf("0x%02x: ошибка: %v", a, err)
+		}
+		if 255%order != 0 {
+			t.Errorf("Порядок 0x%02x равен %d и не делит 255", a, order)
+		}
+		if order == 255 {
+			primitive++
+		}
+	}
+	// Число примитивных элементов равно φ(255) = 128
+	if primitive != 128 {
+		t.Errorf("Примитивных элементов %d, ожидалось 128", primitive)
+	}
+
+	if _, err := gf.MultiplicativeOrder(0x00, 0x1B); err == nil {
+		t.Errorf("Для нуля ожидалась ошибка")
+	}
+}
+
+// TestRijndaelWideBlockVectors сверяет Rijndael со всеми сочетаниями блока и ключа
+// 128/192/256 бит с эталонными векторами (ключ и открытый текст из FIPS-197, приложение B)
+func TestRijndaelWideBlockVectors(t *testing.T) {
+	fmt.Println("\nТЕСТ ВЕКТОРОВ RIJNDAEL ДЛЯ БЛОКОВ 128/192/256 БИТ")
+
+	const (
+		keyHex   = "2b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfe"
+		plainHex = "3243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c8"
+	)
+
+	tests := []struct {
+		blockSize int
+		keySize   int
+		expected  string
+	}{
+		{16, 16, "3925841d02dc09fbdc118597196a0b32"},
+		{16, 24, "f9fb29aefc384a250340d833b87ebc00"},
+		{16, 32, "1a6e6c2c662e7da6501ffb62bc9e93f3"},
+		{24, 16, "b24d275489e82bb8f7375e0d5fcdb1f481757c538b65148a"},
+		{24, 24, "725ae43b5f3161de806a7c93e0bca93c967ec1ae1b71e1cf"},
+		{24, 32, "0ebacf199e3315c2e34b24fcc7c46ef4388aa475d66c194c"},
+		{32, 16, "7d15479076b69a46ffb3b3beae97ad8313f622f67fedb487de9f06b9ed9c8f19"},
+		{32, 24, "5d7101727bb25781bf6715b0e6955282b9610e23a43c2eb062699f0ebf5887b2"},
+		{32, 32, "a49406115dfb30a40418aafa4869b7c6a886ff31602a7dd19c889dc64f7e4e7a"},
+	}
+
+	key, _ := hex.DecodeString(keyHex)
+	plaintext, _ := hex.DecodeString(plainHex)
+
+	for _, tt := range tests {
+		name := fmt.Sprintf("Rijndael-%d/%d", tt.blockSize*8, tt.keySize*8)
+
+		cipher, err := cripta.NewRijndaelCipher(tt.blockSize, tt.keySize, 0x1B)
+		if err != nil {
+			t.Fatalf("%s: ошибка создания шифра: %v", name, err)
+		}
+		if err := cipher.SetKey(key[:tt.keySize]); err != nil {
+			t.Fatalf("%s: ошибка установки ключа: %v", name, err)
+		}
+
+		encrypted, err := cipher.EncryptBlock(plaintext[:tt.blockSize])
+		if err != nil {
+			t.Fatalf("%s: ошибка шифрования: %v", name, err)
+		}
+		if hex.EncodeToString(encrypted) != tt.expected {
+			t.Errorf("%s: получено %x, ожидалось %s", name, encrypted, tt.expected)
+			continue
+		}
+
+		decrypted, err := cipher.DecryptBlock(encrypted)
+		if err != nil || hex.EncodeToString(decrypted) != plainHex[:tt.blockSize*2] {
+			t.Errorf("%s: расшифровка дала %x", name, decrypted)
+		}
+	}
+}
+
+// TestGCMNonStandardIV сверяет GCM на Rijndael-128 с эталонной реализацией
+// стандартной библиотеки, в том числе для 64-битного IV (J0 через GHASH)
+func TestGCMNonStandardIV(t *testing.T) {
+	fmt.Println("\nТЕСТ GCM С НЕСТАНДАРТНОЙ ДЛИНОЙ IV")
+
+	key := make([]byte, 16)
+	rand.Read(key)
+	plaintext := []byte("GCM с восьмибайтным IV должен совпадать с эталоном")
+	aad := []byte("заголовок пакета")
+
+	rijndael, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if err := rijndael.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+	gcm, err := cripta.NewGCM(rijndael)
+	if err != nil {
+		t.Fatalf("Ошибка создания GCM: %v", err)
+	}
+
+	block, _ := aes.NewCipher(key)
+
+	for _, ivLen := range []int{8, 12, 16, 60} {
+		iv := make([]byte, ivLen)
+		rand.Read(iv)
+
+		reference, err := stdcipher.NewGCMWithNonceSize(block, ivLen)
+		if err != nil {
+			t.Fatalf("IV %d байт: ошибка создания эталонного GCM: %v", ivLen, err)
+		}
+		expected := reference.Seal(nil, iv, plaintext, aad)
+
+		sealed, err := gcm.Seal(iv, plaintext, aad)
+		if err != nil {
+			t.Fatalf("IV %d байт: ошибка шифрования: %v", ivLen, err)
+		}
+		if hex.EncodeToString(sealed) != hex.EncodeToString(expected) {
+			t.Errorf("IV %d байт: получено %x, ожидалось %x", ivLen, sealed, expected)
+			continue
+		}
+
+		opened, err := gcm.Open(iv, sealed, aad)
+		if err != nil || string(opened) != string(plaintext) {
+			t.Errorf("IV %d байт: ошибка расшифровки: %v", ivLen, err)
+		}
+
+		if _, err := gcm.Open(iv, sealed, []byte("другой заголовок")); err != cripta.ErrAuthenticationFailed {
+			t.Errorf("IV %d байт: измененные AAD должны отклоняться, получено %v", ivLen, err)
+		}
+	}
+}
+
+// TestStreamingMAC проверяет CMAC по векторам RFC 4493 и совпадение потокового
+// MAC с однократным при разбиении данных на порции произвольного размера
+func TestStreamingMAC(t *testing.T) {
+	fmt.Println("\nТЕСТ ПОТОКОВОГО MAC")
+
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411")
+
+	cipher, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+
+	vectors := []struct {
+		length   int
+		expected string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+	}
+	for _, v := range vectors {
+		mac, err := cripta.CMAC(cipher, 16, message[:v.length])
+		if err != nil {
+			t.Fatalf("Ошибка вычисления CMAC: %v", err)
+		}
+		if hex.EncodeToString(mac) != v.expected {
+			t.Errorf("CMAC %d байт: получено %x, ожидалось %s", v.length, mac, v.expected)
+		}
+	}
+
+	data := make([]byte, 1000)
+	rand.Read(data)
+
+	macs := []struct {
+		name    string
+		oneShot func([]byte) ([]byte, error)
+		writer  func() (cripta.MACWriter, error)
+	}{
+		{"CBC-MAC",
+			func(d []byte) ([]byte, error) { return cripta.CBCMAC(cipher, 16, d) },
+			func() (cripta.MACWriter, error) { return cripta.NewCBCMACWriter(cipher, 16) }},
+		{"CMAC",
+			func(d []byte) ([]byte, error) { return cripta.CMAC(cipher, 16, d) },
+			func() (cripta.MACWriter, error) { return cripta.NewCMACWriter(cipher, 16) }},
+	}
+
+	for _, m := range macs {
+		for _, size := range []int{0, 16, 17, 999, 1000} {
+			expected, err := m.oneShot(data[:size])
+			if err != nil {
+				t.Fatalf("%s: ошибка вычисления: %v", m.name, err)
+			}
+
+			for _, chunk := range []int{1, 7, 16, 33} {
+				writer, err := m.writer()
+				if err != nil {
+					t.Fatalf("%s: ошибка создания: %v", m.name, err)
+				}
+				for offset := 0; offset < size; offset += chunk {
+					writer.Write(data[offset:min(offset+chunk, size)])
+				}
+				if got := writer.Sum(); hex.EncodeToString(got) != hex.EncodeToString(expected) {
+					t.Errorf("%s: %d байт порциями по %d: MAC не совпадает", m.name, size, chunk)
+				}
+			}
+		}
+	}
+}
+
+// TestFPE проверяет FF1 по примерам NIST и сохранение формата 16-значной строки
+func TestFPE(t *testing.T) {
+	fmt.Println("\nТЕСТ ШИФРОВАНИЯ С СОХРАНЕНИЕМ ФОРМАТА (FF1)")
+
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	cipher, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+
+	fpe, err := cripta.NewFPECipher(cipher, 10)
+	if err != nil {
+		t.Fatalf("Ошибка создания FF1: %v", err)
+	}
+
+	// Примеры 1 и 2 для FF1-AES128 из NIST
+	vectors := []struct {
+		tweak    string
+		expected string
+	}{
+		{"", "2433477484"},
+		{"39383736353433323130", "6124200773"},
+	}
+	for _, v := range vectors {
+		tweak, _ := hex.DecodeString(v.tweak)
+		encrypted, err := fpe.Encrypt("0123456789", tweak)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования: %v", err)
+		}
+		if encrypted != v.expected {
+			t.Errorf("Настройка %q: получено %s, ожидалось %s", v.tweak, encrypted, v.expected)
+		}
+	}
+
+	card := "4111111111111111"
+	tweak := []byte("card")
+	encrypted, err := fpe.Encrypt(card, tweak)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if len(encrypted) != len(card) || strings.Trim(encrypted, "0123456789") != "" {
+		t.Errorf("Шифртекст %q не является 16-значной строкой", encrypted)
+	}
+	if encrypted == card {
+		t.Errorf("Шифртекст совпадает с открытым текстом")
+	}
+
+	decrypted, err := fpe.Decrypt(encrypted, tweak)
+	if err != nil || decrypted != card {
+		t.Errorf("Расшифровано %q, ожидалось %q (%v)", decrypted, card, err)
+	}
+
+	if other, _ := fpe.Encrypt(card, []byte("other")); other == encrypted {
+		t.Errorf("Разные настройки должны давать разные шифртексты")
+	}
+	if _, err := fpe.Encrypt("12345", nil); err == nil {
+		t.Errorf("Слишком короткая строка должна отклоняться")
+	}
+	if _, err := fpe.Encrypt("12a4567890", nil); err == nil {
+		t.Errorf("Символ вне алфавита должен отклоняться")
+	}
+}
+
+// TestAESSIV проверяет AES-SIV по тестовым векторам RFC 5297 (приложение A)
+func TestAESSIV(t *testing.T) {
+	fmt.Println("\nТЕСТ AES-SIV (RFC 5297)")
+
+	decode := func(s string) []byte {
+		data, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+		if err != nil {
+			t.Fatalf("Некорректный hex: %v", err)
+		}
+		return data
+	}
+
+	vectors := []struct {
+		name      string
+		key       string
+		aad       []string
+		plaintext string
+		expected  string
+	}{
+		{
+			name:      "A.1 детерминированный режим",
+			key:       "fffefdfc fbfaf9f8 f7f6f5f4 f3f2f1f0 f0f1f2f3 f4f5f6f7 f8f9fafb fcfdfeff",
+			aad:       []string{"10111213 14151617 18191a1b 1c1d1e1f 20212223 24252627"},
+			plaintext: "11223344 55667788 99aabbcc ddee",
+			expected:  "85632d07 c6e8f37f 950acd32 0a2ecc93 40c02b96 90c4dc04 daef7f6a fe5c",
+		},
+		{
+			name: "A.2 режим с nonce",
+			key:  "7f7e7d7c 7b7a7978 77767574 73727170 40414243 44454647 48494a4b 4c4d4e4f",
+			aad: []string{
+				"00112233 44556677 8899aabb ccddeeff deaddada deaddada ffeeddcc bbaa9988 77665544 33221100",
+				"10203040 50607080 90a0",
+				"09f91102 9d74e35b d84156c5 635688c0",
+			},
+			plaintext: "74686973 20697320 736f6d65 20706c61 696e7465 78742074 6f20656e 63727970 74207573 696e6720 5349562d 414553",
+			expected: "7bdb6e3b 432667eb 06f4d14b ff2fbd0f cb900f2f ddbe4043 26601965 c889bf17 dba77ceb 094fa663 " +
+				"b7a3f748 ba8af829 ea64ad54 4a272e9c 485b62a3 fd5c0d",
+		},
+	}
+
+	for _, v := range vectors {
+		siv, err := cripta.NewSIV(decode(v.key))
+		if err != nil {
+			t.Fatalf("%s: ошибка создания SIV: %v", v.name, err)
+		}
+
+		var aad [][]byte
+		for _, component := range v.aad {
+			aad = append(aad, decode(component))
+		}
+
+		encrypted, err := siv.EncryptSIV(decode(v.plaintext), aad)
+		if err != nil {
+			t.Fatalf("%s: ошибка шифрования: %v", v.name, err)
+		}
+		if hex.EncodeToString(encrypted) != hex.EncodeToString(decode(v.expected)) {
+			t.Errorf("%s: получено %x", v.name, encrypted)
+			continue
+		}
+
+		decrypted, err := siv.DecryptSIV(encrypted, aad)
+		if err != nil || hex.EncodeToString(decrypted) != hex.EncodeToString(decode(v.plaintext)) {
+			t.Errorf("%s: ошибка расшифровки: %v", v.name, err)
+		}
+
+		encrypted[len(encrypted)-1] ^= 1
+		if _, err := siv.DecryptSIV(encrypted, aad); err != cripta.ErrAuthenticationFailed {
+			t.Errorf("%s: измененный шифртекст должен отклоняться, получено %v", v.name, err)
+		}
+	}
+}
+
+// TestSplitKey проверяет разбиение ключевого материала двухключевых режимов на половины
+func TestSplitKey(t *testing.T) {
+	fmt.Println("\nТЕСТ РАЗБИЕНИЯ КЛЮЧА НА ПОЛОВИНЫ")
+
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	k1, k2, err := cripta.SplitKey(key)
+	if err != nil {
+		t.Fatalf("Ошибка разбиения ключа: %v", err)
+	}
+	if hex.EncodeToString(k1) != "000102030405060708090a0b0c0d0e0f" || hex.EncodeToString(k2) != "101112131415161718191a1b1c1d1e1f" {
+		t.Errorf("Неверные половины: %x и %x", k1, k2)
+	}
+
+	k1[0] = 0xFF
+	if key[0] != 0 {
+		t.Errorf("Половины ключа не должны разделять память с исходным ключом")
+	}
+	k1[0] = 0
+	if joined := cripta.JoinKey(k1, k2); hex.EncodeToString(joined) != hex.EncodeToString(key) {
+		t.Errorf("JoinKey вернул %x, ожидалось %x", joined, key)
+	}
+
+	for _, length := range []int{0, 1, 33} {
+		if _, _, err := cripta.SplitKey(make([]byte, length)); !errors.Is(err, cripta.ErrInvalidKeyLength) {
+			t.Errorf("Ключ длиной %d должен отклоняться, получено %v", length, err)
+		}
+	}
+}
+
+// TestRijndaelStateMapping сверяет состояние после каждого этапа первого раунда
+// с примером из приложения B FIPS-197. Состояние записано по столбцам:
+// байт i находится в строке i%4 столбца i/4
+func TestRijndaelStateMapping(t *testing.T) {
+	fmt.Println("\nТЕСТ РАСКЛАДКИ СОСТОЯНИЯ RIJNDAEL (FIPS-197, ПРИЛОЖЕНИЕ B)")
+
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	input, _ := hex.DecodeString("3243f6a8885a308d313198a2e0370734")
+
+	expected := map[string]string{
+		"0 input":   "3243f6a8885a308d313198a2e0370734",
+		"0 k_sch":   "2b7e151628aed2a6abf7158809cf4f3c",
+		"1 start":   "193de3bea0f4e22b9ac68d2ae9f84808",
+		"1 s_box":   "d42711aee0bf98f1b8b45de51e415230",
+		"1 s_row":   "d4bf5d30e0b452aeb84111f11e2798e5",
+		"1 m_col":   "046681e5e0cb199a48f8d37a2806264c",
+		"1 k_sch":   "a0fafe1788542cb123a339392a6c7605",
+		"2 start":   "a49c7ff2689f352b6b5bea43026a5049",
+		"10 output": "3925841d02dc09fbdc118597196a0b32",
+	}
+
+	cipher, err := cripta.NewRijndaelCipher(16, 16, 0x1B)
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+
+	seen := 0
+	_, err = cipher.EncryptBlockWithTrace(input, func(round int, stage string, state []byte) {
+		name := fmt.Sprintf("%d %s", round, stage)
+		want, ok := expected[name]
+		if !ok {
+			return
+		}
+		seen++
+		if got := hex.EncodeToString(state); got != want {
+			t.Errorf("round[%s]: %s, ожидалось %s", name, got, want)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if seen != len(expected) {
+		t.Errorf("Трассировка содержит %d из %d ожидаемых этапов", seen, len(expected))
+	}
+
+	// Матричное представление состояния в начале первого раунда (FIPS-197, рисунок 3)
+	state, _ := hex.DecodeString(expected["1 start"])
+	rows := [4]string{"19a09ae9", "3df4c6f8", "e3e28d48", "be2b2a08"}
+	for r := 0; r < 4; r++ {
+		row := make([]byte, 4)
+		for c := 0; c < 4; c++ {
+			row[c] = state[4*c+r]
+		}
+		if got := hex.EncodeToString(row); got != rows[r] {
+			t.Errorf("Строка %d: %s, ожидалось %s", r, got, rows[r])
+		}
+	}
+}
+
+func TestRijndaelEquivalentInverse(t *testing.T) {
+	for _, blockSize := range []int{16, 24, 32} {
+		for _, keySize := range []int{16, 24, 32} {
+			standard, err := cripta.NewRijndaelCipher(blockSize, keySize, 0x1B)
+			if err != nil {
+				t.Fatalf("Ошибка создания шифра: %v", err)
+			}
+			equivalent, err := cripta.NewRijndaelCipherWithOptions(blockSize, keySize, 0x1B,
+				cripta.RijndaelOptions{EquivalentInverse: true})
+			if err != nil {
+				t.Fatalf("Ошибка создания шифра: %v", err)
+			}
+
+			key := make([]byte, keySize)
+			rand.Read(key)
+			if err := standard.SetKey(key); err != nil {
+				t.Fatalf("Ошибка установки ключа: %v", err)
+			}
+			if err := equivalent.SetKey(key); err != nil {
+				t.Fatalf("Ошибка установки ключа: %v", err)
+			}
+
+			for i := 0; i < 20; i++ {
+				block := make([]byte, blockSize)
+				rand.Read(block)
+
+				expected, err := standard.DecryptBlock(block)
+				if err != nil {
+					t.Fatalf("Ошибка дешифрования: %v", err)
+				}
+				got, err := equivalent.DecryptBlock(block)
+				if err != nil {
+					t.Fatalf("Ошибка дешифрования: %v", err)
+				}
+				if hex.EncodeToString(got) != hex.EncodeToString(expected) {
+					t.Fatalf("Блок %d/ключ %d: эквивалентный обратный шифр дал %x, ожидалось %x",
+						blockSize*8, keySize*8, got, expected)
+				}
+			}
+		}
+	}
+
+	// FIPS-197, приложение C.1
+	cipher, err := cripta.NewRijndaelCipherWithOptions(16, 16, 0x1B, cripta.RijndaelOptions{EquivalentInverse: true})
+	if err != nil {
+		t.Fatalf("Ошибка создания шифра: %v", err)
+	}
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	ciphertext, _ := hex.DecodeString("69c4e0d86a7b0430d8cdb78070b4c55a")
+	if err := cipher.SetKey(key); err != nil {
+		t.Fatalf("Ошибка установки ключа: %v", err)
+	}
+	plaintext, err := cipher.DecryptBlock(ciphertext)
+	if err != nil {
+		t.Fatalf("Ошибка дешифрования: %v", err)
+	}
+	if got := hex.EncodeToString(plaintext); got != "00112233445566778899aabbccddeeff" {
+		t.Errorf("FIPS-197 C.1: %s, ожидалось 00112233445566778899aabbccddeeff", got)
+	}
+}
+
+// checkSBoxPermutation проверяет, что sBox - перестановка 0..255, а invSBox - ее обратная
+func checkSBoxPermutation(sBox, invSBox []byte) error {
+	if len(sBox) != 256 || len(invSBox) != 256 {
+		return fmt.Errorf("размер S-бокса должен быть 256, получено %d и %d", len(sBox), len(invSBox))
+	}
+
+	var seen [256]int
+	for _, v := range sBox {
+		seen[v]++
+	}
+	for v, count := range seen {
+		if count != 1 {
+			return fmt.Errorf("значение 0x%02x встречается в S-боксе %d раз", v, count)
+		}
+	}
+
+	for i := 0; i < 256; i++ {
+		if invSBox[sBox[i]] != byte(i) {
+			return fmt.Errorf("обратный S-бокс[0x%02x] = 0x%02x, ожидалось 0x%02x", sBox[i], invSBox[sBox[i]], i)
+		}
+		if sBox[invSBox[i]] != byte(i) {
+			return fmt.Errorf("S-бокс[0x%02x] = 0x%02x, ожидалось 0x%02x", invSBox[i], sBox[invSBox[i]], i)
+		}
+	}
+
+	return nil
+}
+
+func TestRijndaelCustomModulusSBox(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	plaintext, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+
+	for _, modulus := range []byte{0x1B, 0x1D, 0x2B} {
+		cipher, err := cripta.NewRijndaelCipher(16, 16, modulus)
+		if err != nil {
+			t.Fatalf("Модуль 0x%02x: ошибка создания шифра: %v", modulus, err)
+		}
+
+		if err := checkSBoxPermutation(cipher.GetSBox(), cipher.GetInvSBox()); err != nil {
+			t.Errorf("Модуль 0x%02x: %v", modulus, err)
+		}
+
+		if err := cipher.SetKey(key); err != nil {
+			t.Fatalf("Ошибка установки ключа: %v", err)
+		}
+		ciphertext, err := cipher.EncryptBlock(plaintext)
+		if err != nil {
+			t.Fatalf("Ошибка шифрования: %v", err)
+		}
+		decrypted, err := cipher.DecryptBlock(ciphertext)
+		if err != nil {
+			t.Fatalf("Ошибка дешифрования: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Модуль 0x%02x: дешифрование дало %x, ожидалось %x", modulus, decrypted, plaintext)
+		}
+	}
+
+	// При приводимом модуле часть элементов не имеет обратных, все они отображаются
+	// в 0 и S-бокс перестает быть перестановкой: такой шифр не должен создаваться
+	gf := cripta.NewGF28Service()
+	for _, modulus := range []byte{0x00, 0x11, 0x1A} {
+		if gf.IsIrreducible(modulus) {
+			t.Fatalf("Модуль 0x%02x должен быть приводимым", modulus)
+		}
+		_, err := cripta.NewRijndaelCipherWithOptions(16, 16, modulus, cripta.RijndaelOptions{AllowReducibleModulus: true})
+		if err == nil {
+			t.Errorf("Модуль 0x%02x: шифр с вырожденным S-боксом не должен создаваться", modulus)
+		}
+	}
+}